@@ -0,0 +1,199 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+// Package capture imports traffic captured by other tools, and normalises it into a HAR document so it can run
+// through the same validation as a HAR file.
+package capture
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pb33f/harhar"
+	"github.com/pb33f/wiretap/har"
+)
+
+const (
+	FormatHAR     = "har"
+	FormatPostman = "postman"
+	FormatCurl    = "curl"
+	FormatRaw     = "raw"
+	FormatNDJSON  = "ndjson"
+)
+
+// Formats are the capture formats that can be imported.
+var Formats = []string{FormatHAR, FormatPostman, FormatCurl, FormatRaw, FormatNDJSON}
+
+var requestLine = regexp.MustCompile(`^[A-Z]+ \S+ HTTP/\d(\.\d)?$`)
+
+// Detect works out the format of captured traffic from its content, it returns an empty string if the format is
+// not recognised.
+func Detect(data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return ""
+	}
+	switch trimmed[0] {
+	case '[':
+		return FormatNDJSON
+	case '{':
+		var document map[string]json.RawMessage
+		if json.Unmarshal(trimmed, &document) != nil {
+			// not a single document, a log of one document per line.
+			return FormatNDJSON
+		}
+		if _, ok := document["log"]; ok {
+			return FormatHAR
+		}
+		_, hasInfo := document["info"]
+		_, hasItem := document["item"]
+		_, hasType := document["_type"]
+		if hasInfo && hasItem || hasType {
+			return FormatPostman
+		}
+		return FormatNDJSON
+	}
+
+	firstLine, _, _ := strings.Cut(string(trimmed), "\n")
+	if requestLine.MatchString(strings.TrimSpace(firstLine)) {
+		return FormatRaw
+	}
+	for _, line := range strings.Split(string(trimmed), "\n") {
+		if strings.HasPrefix(line, "> ") || strings.HasPrefix(line, "< ") {
+			return FormatCurl
+		}
+	}
+	return ""
+}
+
+// Load converts captured traffic into a HAR document. When no format is given, it is detected from the content.
+// The format used is returned with the document.
+func Load(data []byte, format string) (*harhar.HAR, string, error) {
+	if format == "" {
+		if format = Detect(data); format == "" {
+			return nil, "", fmt.Errorf("unable to detect the capture format, use one of: %s",
+				strings.Join(Formats, ", "))
+		}
+	}
+	var harFile *harhar.HAR
+	var err error
+	switch strings.ToLower(format) {
+	case FormatHAR:
+		harFile, err = har.BuildHAR(data)
+	case FormatPostman:
+		harFile, err = FromPostman(data)
+	case FormatCurl:
+		harFile, err = FromCurl(data)
+	case FormatRaw:
+		harFile, err = FromRaw(data)
+	case FormatNDJSON:
+		harFile, err = FromNDJSON(data)
+	default:
+		return nil, "", fmt.Errorf("unknown capture format '%s', use one of: %s", format,
+			strings.Join(Formats, ", "))
+	}
+	if err != nil {
+		return nil, format, fmt.Errorf("unable to read %s capture: %w", format, err)
+	}
+	return harFile, format, nil
+}
+
+// exchange is a request and its response, before it is converted into a HAR entry.
+type exchange struct {
+	method          string
+	url             string
+	requestHeaders  http.Header
+	requestBody     []byte
+	status          int
+	statusText      string
+	responseHeaders http.Header
+	responseBody    []byte
+}
+
+// newHAR wraps entries into a HAR document, created by the tool the traffic was captured with.
+func newHAR(creator string, exchanges []*exchange) *harhar.HAR {
+	harFile := &harhar.HAR{Log: harhar.Log{
+		Version: har.HARVersion,
+		Creator: harhar.Creator{Name: creator},
+		Entries: make([]harhar.Entry, 0, len(exchanges)),
+	}}
+	for _, e := range exchanges {
+		harFile.Log.Entries = append(harFile.Log.Entries, e.entry())
+	}
+	return harFile
+}
+
+func (e *exchange) entry() harhar.Entry {
+	statusText := e.statusText
+	if statusText == "" {
+		statusText = http.StatusText(e.status)
+	}
+	entry := harhar.Entry{
+		Request: harhar.Request{
+			Method:      e.method,
+			URL:         e.url,
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harhar.Cookie{},
+			Headers:     headerPairs(e.requestHeaders),
+			QueryParams: []harhar.NameValuePair{},
+			HeadersSize: -1,
+			BodySize:    len(e.requestBody),
+		},
+		Response: harhar.Response{
+			StatusCode:  e.status,
+			StatusText:  statusText,
+			HTTPVersion: "HTTP/1.1",
+			// the response converter only copies headers when there are cookies.
+			Cookies:     []harhar.Cookie{},
+			Headers:     headerPairs(e.responseHeaders),
+			RedirectURL: e.responseHeaders.Get("Location"),
+			Body: harhar.BodyResponseType{
+				Size:     len(e.responseBody),
+				MIMEType: mediaType(e.responseHeaders, e.responseBody),
+				Content:  string(e.responseBody),
+			},
+			HeadersSize: -1,
+			BodySize:    len(e.responseBody),
+		},
+		Timings: harhar.Timings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1},
+	}
+	if len(e.requestBody) > 0 {
+		entry.Request.Body = harhar.BodyType{
+			MIMEType: mediaType(e.requestHeaders, e.requestBody),
+			Content:  string(e.requestBody),
+		}
+	}
+	return entry
+}
+
+// mediaType returns the content type of a body, JSON bodies without a content type are given one.
+func mediaType(headers http.Header, body []byte) string {
+	if contentType := headers.Get("Content-Type"); contentType != "" {
+		return contentType
+	}
+	if len(body) > 0 && json.Valid(body) {
+		return "application/json"
+	}
+	if len(body) > 0 {
+		return "text/plain"
+	}
+	return ""
+}
+
+func headerPairs(headers http.Header) []harhar.NameValuePair {
+	pairs := make([]harhar.NameValuePair, 0, len(headers))
+	for name, values := range headers {
+		for _, value := range values {
+			pairs = append(pairs, harhar.NameValuePair{Name: name, Value: value})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].Name < pairs[j].Name })
+	return pairs
+}
@@ -0,0 +1,218 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package capture
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pb33f/harhar"
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/wiretap/har"
+	"github.com/pb33f/wiretap/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const postmanExport = `{
+  "info": {"name": "pets", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+  "variable": [{"key": "baseUrl", "value": "https://api.pets.com/v1"}],
+  "item": [{
+    "name": "pets",
+    "item": [{
+      "name": "create pet",
+      "request": {
+        "method": "POST",
+        "url": {"raw": "{{baseUrl}}/pets", "host": ["{{baseUrl}}"]},
+        "header": [{"key": "Content-Type", "value": "application/json"}, {"key": "X-Old", "value": "1", "disabled": true}],
+        "body": {"mode": "raw", "raw": "{\"name\":\"fido\"}"}
+      },
+      "response": [{
+        "name": "created",
+        "code": 201,
+        "status": "Created",
+        "header": [{"key": "Content-Type", "value": "application/json"}],
+        "body": "{\"id\":1,\"name\":\"fido\"}"
+      }]
+    }, {
+      "name": "no saved responses",
+      "request": {"method": "GET", "url": "{{baseUrl}}/pets"},
+      "response": []
+    }]
+  }]
+}`
+
+const curlDump = `*   Trying 1.2.3.4:443...
+* Connected to api.pets.com (1.2.3.4) port 443 (#0)
+* SSL connection using TLSv1.3 / TLS_AES_256_GCM_SHA384
+> GET /v1/pets/1 HTTP/2
+> Host: api.pets.com
+> Accept: */*
+>
+< HTTP/2 200
+< content-type: application/json
+<
+{"id":1,
+"name":"fido"}
+* Connection #0 to host api.pets.com left intact
+* Connected to localhost (127.0.0.1) port 8080 (#1)
+> GET /pets/2 HTTP/1.1
+> Host: localhost:8080
+>
+< HTTP/1.1 100 Continue
+<
+< HTTP/1.1 404 Not Found
+< Content-Type: application/xml
+<
+<error/>
+`
+
+const rawDump = "POST /v1/pets HTTP/1.1\r\nHost: api.pets.com:443\r\nContent-Type: application/json\r\n" +
+	"Content-Length: 15\r\n\r\n{\"name\":\"fido\"}\r\n" +
+	"HTTP/1.1 201 Created\r\nContent-Type: application/json\r\nContent-Length: 8\r\n\r\n{\"id\":1}\r\n\r\n" +
+	"GET http://proxy.local/v1/pets HTTP/1.1\r\nHost: proxy.local\r\n\r\n" +
+	"HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\n[]"
+
+const ndjsonLog = `{"request":{"method":"get","url":"https://api.pets.com/v1/pets/1","headers":{"Accept":["application/json"]}},"response":{"status":200,"headers":{"Content-Type":"application/json"},"body":{"name":"fido"}}}
+
+{"method":"POST","url":"https://api.pets.com/v1/pets","body":"name=rex","headers":{"Content-Type":"application/x-www-form-urlencoded"},"statusCode":415}
+`
+
+func TestDetect(t *testing.T) {
+	assert.Equal(t, FormatHAR, Detect([]byte(`{"log":{"entries":[]}}`)))
+	assert.Equal(t, FormatPostman, Detect([]byte(postmanExport)))
+	assert.Equal(t, FormatCurl, Detect([]byte(curlDump)))
+	assert.Equal(t, FormatRaw, Detect([]byte(rawDump)))
+	assert.Equal(t, FormatNDJSON, Detect([]byte(ndjsonLog)))
+	assert.Equal(t, FormatNDJSON, Detect([]byte(`[{"url":"/"}]`)))
+	assert.Empty(t, Detect([]byte("hello")))
+
+	_, _, err := Load([]byte("hello"), "")
+	assert.ErrorContains(t, err, "unable to detect the capture format")
+	_, _, err = Load([]byte("hello"), "pcap")
+	assert.ErrorContains(t, err, "unknown capture format 'pcap'")
+}
+
+func TestFromPostman(t *testing.T) {
+	harFile, format, err := Load([]byte(postmanExport), "")
+	require.NoError(t, err)
+	assert.Equal(t, FormatPostman, format)
+	require.Len(t, harFile.Log.Entries, 1)
+
+	entry := harFile.Log.Entries[0]
+	assert.Equal(t, "POST", entry.Request.Method)
+	assert.Equal(t, "https://api.pets.com/v1/pets", entry.Request.URL)
+	assert.Len(t, entry.Request.Headers, 1)
+	assert.Equal(t, `{"name":"fido"}`, entry.Request.Body.Content)
+	assert.Equal(t, 201, entry.Response.StatusCode)
+	assert.Equal(t, "application/json", entry.Response.Body.MIMEType)
+	assert.Equal(t, `{"id":1,"name":"fido"}`, entry.Response.Body.Content)
+}
+
+func TestFromCurl(t *testing.T) {
+	harFile, err := FromCurl([]byte(curlDump))
+	require.NoError(t, err)
+	require.Len(t, harFile.Log.Entries, 2)
+
+	first := harFile.Log.Entries[0]
+	assert.Equal(t, "https://api.pets.com/v1/pets/1", first.Request.URL)
+	assert.Equal(t, 200, first.Response.StatusCode)
+	assert.Equal(t, "{\"id\":1,\n\"name\":\"fido\"}", first.Response.Body.Content)
+
+	second := harFile.Log.Entries[1]
+	assert.Equal(t, "http://localhost:8080/pets/2", second.Request.URL)
+	assert.Equal(t, 404, second.Response.StatusCode)
+	assert.Equal(t, "Not Found", second.Response.StatusText)
+	assert.Equal(t, "<error/>", second.Response.Body.Content)
+}
+
+func TestFromRaw(t *testing.T) {
+	harFile, err := FromRaw([]byte(rawDump))
+	require.NoError(t, err)
+	require.Len(t, harFile.Log.Entries, 2)
+
+	first := harFile.Log.Entries[0]
+	assert.Equal(t, "https://api.pets.com:443/v1/pets", first.Request.URL)
+	assert.Equal(t, `{"name":"fido"}`, first.Request.Body.Content)
+	assert.Equal(t, 201, first.Response.StatusCode)
+	assert.Equal(t, `{"id":1}`, first.Response.Body.Content)
+
+	second := harFile.Log.Entries[1]
+	assert.Equal(t, "http://proxy.local/v1/pets", second.Request.URL)
+	assert.Equal(t, "[]", second.Response.Body.Content)
+
+	_, err = FromRaw([]byte("GET / HTTP/1.1\r\nHost: a\r\n\r\n"))
+	assert.ErrorContains(t, err, "request 1 has no response")
+}
+
+func TestFromNDJSON(t *testing.T) {
+	harFile, err := FromNDJSON([]byte(ndjsonLog))
+	require.NoError(t, err)
+	require.Len(t, harFile.Log.Entries, 2)
+
+	first := harFile.Log.Entries[0]
+	assert.Equal(t, "GET", first.Request.Method)
+	assert.Equal(t, `{"name":"fido"}`, first.Response.Body.Content)
+	assert.Equal(t, "application/json", first.Response.Body.MIMEType)
+
+	second := harFile.Log.Entries[1]
+	assert.Equal(t, "name=rex", second.Request.Body.Content)
+	assert.Equal(t, "application/x-www-form-urlencoded", second.Request.Body.MIMEType)
+	assert.Equal(t, 415, second.Response.StatusCode)
+
+	_, err = FromNDJSON([]byte(`{"url":"/"}` + "\n" + `{"oops"`))
+	assert.ErrorContains(t, err, "line 2")
+	_, err = FromNDJSON([]byte(`[{"method":"GET","status":200}]`))
+	assert.ErrorContains(t, err, "record 1: request has no url")
+}
+
+func TestCapture_ValidateHAR(t *testing.T) {
+	spec := `openapi: 3.1.0
+info:
+  title: pets
+  version: 1.0.0
+servers:
+  - url: https://api.pets.com/v1
+paths:
+  /pets/{id}:
+    get:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        "200":
+          description: a pet
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [name]
+                properties:
+                  name:
+                    type: string`
+	d, err := libopenapi.NewDocument([]byte(spec))
+	require.NoError(t, err)
+	m, errs := d.BuildV3Model()
+	require.Empty(t, errs)
+
+	config := &shared.WiretapConfiguration{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError})),
+	}
+	for _, capture := range []string{curlDump, ndjsonLog} {
+		var harFile *harhar.HAR
+		harFile, _, err = Load([]byte(capture), "")
+		require.NoError(t, err)
+
+		report, vErr := har.ValidateHAR(harFile, &m.Model, config)
+		require.NoError(t, vErr)
+		assert.Equal(t, 1, report.Valid, strings.Split(capture, "\n")[0])
+	}
+}
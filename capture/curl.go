@@ -0,0 +1,218 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pb33f/harhar"
+)
+
+// FromCurl converts the output of `curl -v` into a HAR document, a file can hold the output of several calls.
+// Lines starting with `>` are the request, lines starting with `<` the response, and the lines that follow the
+// response headers are the response body. curl does not print request bodies, so requests have none. The scheme is
+// https when curl reports a TLS connection, or a connection on port 443.
+func FromCurl(data []byte) (*harhar.HAR, error) {
+	var exchanges []*exchange
+	var current *exchange
+	var host string
+	secure := false
+	inBody := false
+
+	finish := func() {
+		if current != nil && current.status > 0 {
+			current.url = curlURL(current.url, host, secure)
+			exchanges = append(exchanges, current)
+		}
+		current, inBody = nil, false
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		info, isInfo := curlLine(line, "*")
+		sent, isSent := curlLine(line, ">")
+		received, isReceived := curlLine(line, "<")
+		switch {
+		case isInfo:
+			inBody = false
+			if strings.HasPrefix(info, "Connected to ") {
+				if current != nil && current.status > 0 {
+					finish()
+				}
+				secure = strings.Contains(info+" ", " port 443 ")
+			}
+			if strings.Contains(info, "SSL connection") || strings.Contains(info, "TLS") {
+				secure = true
+			}
+		case isSent:
+			content := sent
+			if current != nil && current.method != "" && requestLine.MatchString(content) {
+				finish()
+			}
+			if current == nil {
+				current = &exchange{requestHeaders: http.Header{}, responseHeaders: http.Header{}}
+			}
+			switch {
+			case content == "":
+			case current.method == "":
+				parts := strings.Fields(content)
+				if len(parts) < 2 {
+					return nil, fmt.Errorf("invalid request line '%s'", content)
+				}
+				current.method, current.url = parts[0], parts[1]
+			default:
+				if name, value, ok := strings.Cut(content, ":"); ok {
+					current.requestHeaders.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+					if strings.EqualFold(strings.TrimSpace(name), "Host") {
+						host = strings.TrimSpace(value)
+					}
+				}
+			}
+		case isReceived:
+			if current == nil {
+				continue
+			}
+			content := received
+			switch {
+			case content == "":
+				// an interim response (such as 100 Continue) is followed by the final response.
+				if current.status >= 200 {
+					inBody = true
+				}
+			case strings.HasPrefix(content, "HTTP/"):
+				parts := strings.SplitN(content, " ", 3)
+				if len(parts) < 2 {
+					return nil, fmt.Errorf("invalid status line '%s'", content)
+				}
+				status, err := strconv.Atoi(parts[1])
+				if err != nil {
+					return nil, fmt.Errorf("invalid status line '%s'", content)
+				}
+				current.status, current.responseHeaders, current.responseBody = status, http.Header{}, nil
+				if len(parts) == 3 {
+					current.statusText = parts[2]
+				}
+			default:
+				if name, value, ok := strings.Cut(content, ":"); ok {
+					current.responseHeaders.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+				}
+			}
+		case strings.HasPrefix(line, "{ [") || strings.HasPrefix(line, "} ["):
+			// data markers of the progress meter.
+		default:
+			if inBody && current != nil {
+				if current.responseBody != nil {
+					current.responseBody = append(current.responseBody, '\n')
+				}
+				current.responseBody = append(current.responseBody, line...)
+			}
+		}
+	}
+	finish()
+
+	for _, e := range exchanges {
+		e.responseBody = bytes.TrimRight(e.responseBody, "\n")
+	}
+	return newHAR("curl", exchanges), nil
+}
+
+// curlLine returns the content of a line curl marked as information, sent or received. Marked lines start with the
+// marker and a space, so a body line such as `<pet/>` is not mistaken for one.
+func curlLine(line, marker string) (string, bool) {
+	if line == marker || strings.HasPrefix(line, marker+" ") {
+		return strings.TrimSpace(strings.TrimPrefix(line, marker)), true
+	}
+	return "", false
+}
+
+// curlURL builds the URL of a request, the target is a path unless the request was sent to a proxy.
+func curlURL(target, host string, secure bool) string {
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		return target
+	}
+	scheme := "http"
+	if secure {
+		scheme = "https"
+	}
+	return scheme + "://" + host + target
+}
+
+// FromRaw converts raw HTTP messages into a HAR document, such as a raw export of mitmproxy. Each request is
+// followed by its response, bodies are read using their Content-Length. The scheme is https when the host is on
+// port 443, otherwise http.
+func FromRaw(data []byte) (*harhar.HAR, error) {
+	reader := bufio.NewReader(bytes.NewReader(data))
+	var exchanges []*exchange
+	for {
+		if err := skipBlankLines(reader); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		request, err := http.ReadRequest(reader)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read request %d: %w", len(exchanges)+1, err)
+		}
+		requestBody, err := io.ReadAll(request.Body)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read request %d body: %w", len(exchanges)+1, err)
+		}
+		if err = skipBlankLines(reader); err != nil {
+			return nil, fmt.Errorf("request %d has no response", len(exchanges)+1)
+		}
+		response, err := http.ReadResponse(reader, request)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read response %d: %w", len(exchanges)+1, err)
+		}
+		responseBody, err := io.ReadAll(response.Body)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read response %d body: %w", len(exchanges)+1, err)
+		}
+
+		target := request.URL.String()
+		if request.URL.Host == "" {
+			scheme := "http"
+			if strings.HasSuffix(request.Host, ":443") {
+				scheme = "https"
+			}
+			target = scheme + "://" + request.Host + request.URL.RequestURI()
+		}
+		request.Header.Set("Host", request.Host)
+		exchanges = append(exchanges, &exchange{
+			method:          request.Method,
+			url:             target,
+			requestHeaders:  request.Header,
+			requestBody:     requestBody,
+			status:          response.StatusCode,
+			statusText:      strings.TrimSpace(strings.TrimPrefix(response.Status, strconv.Itoa(response.StatusCode))),
+			responseHeaders: response.Header,
+			responseBody:    responseBody,
+		})
+	}
+	return newHAR("raw", exchanges), nil
+}
+
+// skipBlankLines moves the reader to the next line with content, it returns io.EOF when there is none.
+func skipBlankLines(reader *bufio.Reader) error {
+	for {
+		b, err := reader.Peek(1)
+		if err != nil {
+			return err
+		}
+		if b[0] != '\n' && b[0] != '\r' {
+			return nil
+		}
+		_, _ = reader.ReadByte()
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pb33f/harhar"
+)
+
+// logRecord is a request log record. The request and response can be nested objects, or the fields can be flat
+// with the response prefixed, such as `status`, `responseHeaders` and `responseBody`.
+type logRecord struct {
+	Method          string          `json:"method"`
+	URL             string          `json:"url"`
+	Headers         json.RawMessage `json:"headers"`
+	Body            json.RawMessage `json:"body"`
+	Status          int             `json:"status"`
+	StatusCode      int             `json:"statusCode"`
+	ResponseHeaders json.RawMessage `json:"responseHeaders"`
+	ResponseBody    json.RawMessage `json:"responseBody"`
+	Request         *logRecord      `json:"request"`
+	Response        *logRecord      `json:"response"`
+}
+
+// FromNDJSON converts request logs into a HAR document. The log is newline delimited JSON with a record per line,
+// or a JSON array of records. Each record has a request (`method`, `url`, `headers` and `body`) and a response
+// (`status`, `headers` and `body`), either nested under `request` and `response` or flat. Headers are an object of
+// names to a value or a list of values, and bodies are a string or any JSON value.
+func FromNDJSON(data []byte) (*harhar.HAR, error) {
+	var records []*logRecord
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &records); err != nil {
+			return nil, err
+		}
+	} else {
+		scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		line := 0
+		for scanner.Scan() {
+			line++
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				continue
+			}
+			var record logRecord
+			if err := json.Unmarshal([]byte(text), &record); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			records = append(records, &record)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	exchanges := make([]*exchange, 0, len(records))
+	for i, record := range records {
+		e, err := record.exchange()
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", i+1, err)
+		}
+		exchanges = append(exchanges, e)
+	}
+	return newHAR("ndjson", exchanges), nil
+}
+
+func (r *logRecord) exchange() (*exchange, error) {
+	request := r
+	if r.Request != nil {
+		request = r.Request
+	}
+	if request.URL == "" {
+		return nil, fmt.Errorf("request has no url")
+	}
+	e := &exchange{
+		method: strings.ToUpper(request.Method),
+		url:    request.URL,
+	}
+	if e.method == "" {
+		e.method = http.MethodGet
+	}
+	var err error
+	if e.requestHeaders, err = logHeaders(request.Headers); err != nil {
+		return nil, fmt.Errorf("request headers: %w", err)
+	}
+	e.requestBody = logBody(request.Body)
+
+	if r.Response != nil {
+		e.status = r.Response.status()
+		e.responseHeaders, err = logHeaders(r.Response.Headers)
+		e.responseBody = logBody(r.Response.Body)
+	} else {
+		e.status = r.status()
+		e.responseHeaders, err = logHeaders(r.ResponseHeaders)
+		e.responseBody = logBody(r.ResponseBody)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("response headers: %w", err)
+	}
+	if e.status == 0 {
+		return nil, fmt.Errorf("response has no status")
+	}
+	return e, nil
+}
+
+func (r *logRecord) status() int {
+	if r.Status > 0 {
+		return r.Status
+	}
+	return r.StatusCode
+}
+
+// logHeaders reads headers that map names to a value, or to a list of values.
+func logHeaders(raw json.RawMessage) (http.Header, error) {
+	headers := http.Header{}
+	if len(raw) == 0 || string(raw) == "null" {
+		return headers, nil
+	}
+	var values map[string]any
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, err
+	}
+	for name, value := range values {
+		switch v := value.(type) {
+		case []any:
+			for _, item := range v {
+				headers.Add(name, fmt.Sprint(item))
+			}
+		default:
+			headers.Add(name, fmt.Sprint(v))
+		}
+	}
+	return headers, nil
+}
+
+// logBody returns the content of a body, a string is the content itself and any other JSON value is the content
+// as JSON.
+func logBody(raw json.RawMessage) []byte {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return []byte(text)
+	}
+	return raw
+}
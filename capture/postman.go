@@ -0,0 +1,213 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package capture
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/pb33f/harhar"
+)
+
+// postmanCollection is a Postman collection (v2.0 or v2.1), only the parts that describe traffic are read.
+type postmanCollection struct {
+	Item     []*postmanItem     `json:"item"`
+	Variable []*postmanKeyValue `json:"variable"`
+}
+
+// postmanItem is a request, or a folder of items.
+type postmanItem struct {
+	Name     string             `json:"name"`
+	Item     []*postmanItem     `json:"item"`
+	Request  *postmanRequest    `json:"request"`
+	Response []*postmanResponse `json:"response"`
+}
+
+type postmanRequest struct {
+	Method string             `json:"method"`
+	URL    postmanURL         `json:"url"`
+	Header []*postmanKeyValue `json:"header"`
+	Body   *postmanBody       `json:"body"`
+}
+
+// postmanURL is either a string, or an object with the raw URL.
+type postmanURL struct {
+	Raw string `json:"raw"`
+}
+
+func (u *postmanURL) UnmarshalJSON(data []byte) error {
+	var raw string
+	if json.Unmarshal(data, &raw) == nil {
+		u.Raw = raw
+		return nil
+	}
+	type plain postmanURL
+	return json.Unmarshal(data, (*plain)(u))
+}
+
+type postmanBody struct {
+	Mode       string             `json:"mode"`
+	Raw        string             `json:"raw"`
+	URLEncoded []*postmanKeyValue `json:"urlencoded"`
+	GraphQL    json.RawMessage    `json:"graphql"`
+}
+
+type postmanResponse struct {
+	Name            string             `json:"name"`
+	Code            int                `json:"code"`
+	Status          string             `json:"status"`
+	Header          []*postmanKeyValue `json:"header"`
+	Body            string             `json:"body"`
+	OriginalRequest *postmanRequest    `json:"originalRequest"`
+}
+
+type postmanKeyValue struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled"`
+}
+
+// PostmanVariable matches a Postman `{{variable}}` reference, the first group is the name of the variable.
+var PostmanVariable = regexp.MustCompile(`\{\{\s*([^}\s]+)\s*}}`)
+
+// PostmanExample is a saved example response of a Postman collection, with the request it was saved for.
+type PostmanExample struct {
+	// Folders are the names of the folders the request is in, outermost first.
+	Folders []string
+	// Request is the name of the request in the collection.
+	Request string
+	// Name is the name of the example.
+	Name string
+	// Entry is the request and response of the example.
+	Entry harhar.Entry
+}
+
+// PostmanExamples reads the saved example responses of a Postman collection, in collection order. A request without
+// a saved response has no example and is skipped. Collection variables are replaced in URLs, headers and bodies,
+// variables the collection does not define are left as they are.
+func PostmanExamples(data []byte) ([]*PostmanExample, error) {
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	if t, ok := document["_type"]; ok && string(t) == `"export"` {
+		return nil, fmt.Errorf("insomnia exports do not contain saved responses, import the export into Postman " +
+			"and save example responses for its requests")
+	}
+	var collection postmanCollection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, err
+	}
+	variables := make(map[string]string)
+	for _, v := range collection.Variable {
+		if !v.Disabled {
+			variables[v.Key] = v.Value
+		}
+	}
+	replace := func(s string) string {
+		return PostmanVariable.ReplaceAllStringFunc(s, func(match string) string {
+			if value, ok := variables[PostmanVariable.FindStringSubmatch(match)[1]]; ok {
+				return value
+			}
+			return match
+		})
+	}
+
+	var examples []*PostmanExample
+	var walk func(items []*postmanItem, folders []string)
+	walk = func(items []*postmanItem, folders []string) {
+		for _, item := range items {
+			if item.Request == nil && len(item.Response) == 0 {
+				walk(item.Item, append(folders[:len(folders):len(folders)], item.Name))
+				continue
+			}
+			for _, response := range item.Response {
+				request := response.OriginalRequest
+				if request == nil {
+					request = item.Request
+				}
+				if request == nil {
+					continue
+				}
+				examples = append(examples, &PostmanExample{
+					Folders: folders,
+					Request: item.Name,
+					Name:    response.Name,
+					Entry:   postmanExchange(request, response, replace).entry(),
+				})
+			}
+		}
+	}
+	walk(collection.Item, nil)
+	return examples, nil
+}
+
+// FromPostman converts the saved responses of a Postman collection into a HAR document, a request without a saved
+// response has nothing to validate and is skipped. Collection variables are replaced in URLs, headers and bodies.
+func FromPostman(data []byte) (*harhar.HAR, error) {
+	examples, err := PostmanExamples(data)
+	if err != nil {
+		return nil, err
+	}
+	harFile := newHAR("postman", nil)
+	for _, example := range examples {
+		harFile.Log.Entries = append(harFile.Log.Entries, example.Entry)
+	}
+	return harFile, nil
+}
+
+func postmanExchange(request *postmanRequest, response *postmanResponse, replace func(string) string) *exchange {
+	method := strings.ToUpper(request.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	e := &exchange{
+		method:          method,
+		url:             replace(request.URL.Raw),
+		requestHeaders:  postmanHeaders(request.Header, replace),
+		status:          response.Code,
+		statusText:      response.Status,
+		responseHeaders: postmanHeaders(response.Header, replace),
+		responseBody:    []byte(response.Body),
+	}
+	if body := request.Body; body != nil {
+		switch body.Mode {
+		case "raw":
+			e.requestBody = []byte(replace(body.Raw))
+		case "urlencoded":
+			values := url.Values{}
+			for _, kv := range body.URLEncoded {
+				if !kv.Disabled {
+					values.Add(kv.Key, replace(kv.Value))
+				}
+			}
+			e.requestBody = []byte(values.Encode())
+			if e.requestHeaders.Get("Content-Type") == "" {
+				e.requestHeaders.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+		case "graphql":
+			e.requestBody = body.GraphQL
+			if e.requestHeaders.Get("Content-Type") == "" {
+				e.requestHeaders.Set("Content-Type", "application/json")
+			}
+		}
+	}
+	return e
+}
+
+func postmanHeaders(pairs []*postmanKeyValue, replace func(string) string) http.Header {
+	headers := http.Header{}
+	for _, kv := range pairs {
+		if !kv.Disabled && kv.Key != "" {
+			headers.Add(kv.Key, replace(kv.Value))
+		}
+	}
+	return headers
+}
@@ -0,0 +1,155 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/wiretap/capture"
+	"github.com/pb33f/wiretap/har"
+	"github.com/pb33f/wiretap/shared"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+var contractDiffCmd = &cobra.Command{
+	SilenceUsage: true,
+	Use:          "contract-diff <old-contract> <new-contract> <capture>",
+	Short:        "Check which recorded traffic a new version of a contract would break, or fix.",
+	Long: `Validate a HAR file, or traffic captured by another tool (Postman, curl, raw HTTP or NDJSON request logs),
+against an old and a new version of an OpenAPI contract, and report the operations that newly fail or newly pass.
+The changes between the contracts are summarized with what-changed. The command fails when any operation newly
+fails.`,
+	Args: cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		base, _ := cmd.Flags().GetString("base")
+		captureFormat, _ := cmd.Flags().GetString("capture-format")
+		reportFile, _ := cmd.Flags().GetString("report-filename")
+		harAllow, _ := cmd.Flags().GetStringArray("har-allow")
+		harInclude, _ := cmd.Flags().GetStringArray("har-include")
+		harExclude, _ := cmd.Flags().GetStringArray("har-exclude")
+
+		oldDoc, err := loadContractModel(args[0], base)
+		if err != nil {
+			return err
+		}
+		newDoc, err := loadContractModel(args[1], base)
+		if err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(args[2])
+		if err != nil {
+			pterm.Error.Printf("Cannot read capture: %s (%s)\n", args[2], err.Error())
+			return err
+		}
+		harFile, _, err := capture.Load(data, captureFormat)
+		if err != nil {
+			pterm.Error.Printf("Cannot parse capture: %s (%s)\n", args[2], err.Error())
+			return err
+		}
+
+		oldModel, _ := oldDoc.BuildV3Model()
+		newModel, _ := newDoc.BuildV3Model()
+		report, err := har.CompareContracts(harFile, &oldModel.Model, &newModel.Model, &shared.WiretapConfiguration{
+			HARPathAllowList: harAllow,
+			HARInclude:       harInclude,
+			HARExclude:       harExclude,
+			Logger:           slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError})),
+		})
+		if err != nil {
+			pterm.Error.Printf("Cannot compare contracts: %s\n", err.Error())
+			return err
+		}
+		report.OldContract, report.NewContract = args[0], args[1]
+		if changes, _ := libopenapi.CompareDocuments(oldDoc, newDoc); changes != nil {
+			report.Changes, report.BreakingChanges = changes.TotalChanges(), changes.TotalBreakingChanges()
+		}
+
+		printComparisonReport(report)
+
+		if reportFile != "" {
+			if rErr := har.WriteComparisonReport(reportFile, report); rErr != nil {
+				pterm.Error.Printf("Cannot write report: %s (%s)\n", reportFile, rErr.Error())
+			} else {
+				pterm.Printf("Report generated and saved to: %s\n", pterm.LightMagenta(reportFile))
+			}
+		}
+
+		regressions := report.Regressions()
+		if len(regressions) > 0 {
+			return fmt.Errorf("%d %s newly failing", len(regressions),
+				shared.Pluralize(len(regressions), "operation is", "operations are"))
+		}
+		return nil
+	},
+}
+
+// loadContractModel loads a contract, and checks a v3 model can be built from it.
+func loadContractModel(contract, base string) (libopenapi.Document, error) {
+	doc, err := loadOpenAPISpec(contract, base)
+	if err != nil {
+		pterm.Error.Printf("Cannot load OpenAPI specification: %s (%s)\n", contract, err.Error())
+		return nil, err
+	}
+	docModel, errs := doc.BuildV3Model()
+	if docModel == nil {
+		pterm.Error.Printf("Failed to load / read OpenAPI specification: %s\n", contract)
+		return nil, errors.Join(errs...)
+	}
+	for _, e := range errs {
+		pterm.Warning.Printf("%s: %s\n", contract, e.Error())
+	}
+	return doc, nil
+}
+
+func printComparisonReport(report *har.ComparisonReport) {
+	pterm.Info.Printf("what-changed found %d %s between the contracts, %d of them breaking\n", report.Changes,
+		shared.Pluralize(report.Changes, "change", "changes"), report.BreakingChanges)
+	pterm.Println()
+
+	for _, op := range report.Operations {
+		switch op.Outcome {
+		case har.NewlyFailing:
+			pterm.Printf("💥 %s newly fails (%d of %d %s)\n", pterm.LightRed(op.Operation), op.NewlyFailing,
+				op.Entries, shared.Pluralize(op.Entries, "entry", "entries"))
+		case har.NewlyPassing:
+			pterm.Printf("🩹 %s newly passes (%d of %d %s)\n", pterm.LightGreen(op.Operation), op.NewlyPassing,
+				op.Entries, shared.Pluralize(op.Entries, "entry", "entries"))
+		}
+	}
+	for _, entry := range report.Entries {
+		if entry.Outcome != har.NewlyFailing {
+			continue
+		}
+		pterm.Printf("%s %s\n", pterm.LightCyan(entry.Method), entry.URL)
+		var items []pterm.BulletListItem
+		for _, v := range entry.Introduced {
+			items = append(items, pterm.BulletListItem{Level: 0, Text: pterm.LightRed(v.Message)})
+			if v.Reason != v.Message {
+				items = append(items, pterm.BulletListItem{
+					Level: 1, Text: pterm.Sprintf("Reason: %s", pterm.Gray(v.Reason)),
+				})
+			}
+		}
+		pterm.DefaultBulletList.WithItems(items).Render()
+	}
+	pterm.Println()
+
+	summary := pterm.Sprintf("%d newly failing, %d newly passing, %d still failing, %d still passing and %d not "+
+		"compared, out of %d HAR %s", report.NewlyFailing, report.NewlyPassing, report.StillFailing,
+		report.StillPassing, report.NotCompared, report.Total, shared.Pluralize(report.Total, "entry", "entries"))
+	if report.NewlyFailing > 0 {
+		pterm.Error.Println("The new contract breaks recorded traffic: " + summary)
+	} else {
+		pterm.Success.Println("The new contract does not break recorded traffic: " + summary)
+	}
+	pterm.Println()
+}
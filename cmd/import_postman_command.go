@@ -0,0 +1,60 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pb33f/wiretap/shared"
+	staticMock "github.com/pb33f/wiretap/static-mock"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+var importPostmanCmd = &cobra.Command{
+	SilenceUsage: true,
+	Use:          "import-postman <collection>",
+	Short:        "Convert the saved examples of a Postman collection into static mock definitions.",
+	Long: `Convert the saved example responses of a Postman collection (v2.0 or v2.1) into static mock definitions,
+written into the mock-definitions directory of the static mock directory. Folders of the collection become
+directories, and each request with examples becomes a definition file.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		staticMockDir, _ := cmd.Flags().GetString("static-mock-dir")
+		if staticMockDir == "" {
+			pterm.Error.Println("No static mock directory provided, use '--static-mock-dir'")
+			return fmt.Errorf("no static mock directory")
+		}
+
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			pterm.Error.Printf("Cannot read collection: %s (%s)\n", args[0], err.Error())
+			return err
+		}
+		imported, err := staticMock.ImportPostman(data)
+		if err != nil {
+			pterm.Error.Printf("Cannot import collection: %s (%s)\n", args[0], err.Error())
+			return err
+		}
+		if len(imported) == 0 {
+			pterm.Warning.Printf("Collection '%s' has no saved example responses, nothing to import\n", args[0])
+			return nil
+		}
+		written, err := staticMock.WriteImportedDefinitions(staticMockDir, imported)
+		for _, file := range written {
+			pterm.Printf("📥 Wrote mock definitions '%s'\n", pterm.LightCyan(file))
+		}
+		if err != nil {
+			pterm.Error.Printf("Cannot write mock definitions: %s\n", err.Error())
+			return err
+		}
+		pterm.Println()
+		pterm.Success.Printf("Imported %d %s into %d %s\n", len(imported),
+			shared.Pluralize(len(imported), "example", "examples"), len(written), shared.Pluralize(len(written), "file", "files"))
+		return nil
+	},
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pb33f/wiretap/capture"
+	"github.com/pb33f/wiretap/learn"
+	"github.com/pb33f/wiretap/shared"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+var learnCmd = &cobra.Command{
+	SilenceUsage: true,
+	Use:          "learn <capture>",
+	Short:        "Draft an OpenAPI 3.1 document from a HAR file, or other captured traffic.",
+	Long: `Draft an OpenAPI 3.1 document from a HAR file, or traffic captured by another tool (Postman, curl, raw HTTP
+or NDJSON request logs). Paths, methods, query and header parameters, request and response schemas and status
+codes are inferred from the traffic. Numeric and UUID path segments are collapsed into path parameters.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		captureFormat, _ := cmd.Flags().GetString("capture-format")
+		title, _ := cmd.Flags().GetString("title")
+
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			pterm.Error.Printf("Cannot read capture: %s (%s)\n", args[0], err.Error())
+			return err
+		}
+		harFile, _, err := capture.Load(data, captureFormat)
+		if err != nil {
+			pterm.Error.Printf("Cannot parse capture: %s (%s)\n", args[0], err.Error())
+			return err
+		}
+
+		learner := learn.NewLearner(&shared.WiretapLearnConfig{Title: title})
+		learned, skipped := learner.LearnHAR(harFile)
+		if skipped > 0 {
+			pterm.Warning.Printf("Skipped %d %s that could not be read\n", skipped,
+				shared.Pluralize(skipped, "entry", "entries"))
+		}
+		if learned == 0 {
+			pterm.Error.Printf("Nothing to learn from: %s\n", args[0])
+			return fmt.Errorf("no traffic")
+		}
+		if err = learner.WriteDocument(output); err != nil {
+			pterm.Error.Printf("Cannot write OpenAPI document: %s (%s)\n", output, err.Error())
+			return err
+		}
+		pterm.Success.Printf("Learned %d %s from %d %s, OpenAPI document saved to: %s\n", learner.Operations(),
+			shared.Pluralize(learner.Operations(), "operation", "operations"), learned,
+			shared.Pluralize(learned, "request", "requests"), pterm.LightMagenta(output))
+		return nil
+	},
+}
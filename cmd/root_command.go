@@ -5,24 +5,33 @@ package cmd
 
 import (
 	"embed"
-	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/pb33f/harhar"
 	"github.com/pb33f/libopenapi"
 	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
 	"github.com/pb33f/libopenapi/orderedmap"
+	"github.com/pb33f/wiretap/capture"
+	"github.com/pb33f/wiretap/drift"
 	"github.com/pb33f/wiretap/har"
+	"github.com/pb33f/wiretap/learn"
+	"github.com/pb33f/wiretap/shadow"
 	"github.com/pb33f/wiretap/shared"
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 )
 
+// defaultReportFilename is the report file used when none is given.
+const defaultReportFilename = "wiretap-report.json"
+
 var (
 	Version string
 	Commit  string
@@ -65,6 +74,8 @@ var (
 
 			// static mock dir
 			var staticMockDir string
+			var staticMockStrict bool
+			var staticMockRecord bool
 
 			// mock mode
 			var mockMode bool
@@ -85,9 +96,21 @@ var (
 			harFlag, _ := cmd.Flags().GetString("har")
 			harValidate, _ := cmd.Flags().GetBool("har-validate")
 			harWhiteList, _ := cmd.Flags().GetStringArray("har-allow")
+			harInclude, _ := cmd.Flags().GetStringArray("har-include")
+			harExclude, _ := cmd.Flags().GetStringArray("har-exclude")
+			reportFormat, _ := cmd.Flags().GetString("report-format")
+			harExport, _ := cmd.Flags().GetString("har-export")
+			captureFormat, _ := cmd.Flags().GetString("capture-format")
+			harReplay, _ := cmd.Flags().GetBool("har-replay")
+			learnOutput, _ := cmd.Flags().GetString("learn")
+			driftOutput, _ := cmd.Flags().GetString("drift")
+			driftFormat, _ := cmd.Flags().GetString("drift-format")
+			failOn, _ := cmd.Flags().GetString("fail-on")
 
 			debug, _ := cmd.Flags().GetBool("debug")
 			staticMockDir, _ = cmd.Flags().GetString("static-mock-dir")
+			staticMockStrict, _ = cmd.Flags().GetBool("static-mock-strict")
+			staticMockRecord, _ = cmd.Flags().GetBool("static-mock-record")
 			mockMode, _ = cmd.Flags().GetBool("mock-mode")
 			useAllMockResponseFields, _ = cmd.Flags().GetBool("enable-all-mock-response-fields")
 			hardError, _ = cmd.Flags().GetBool("hard-validation")
@@ -190,6 +213,17 @@ var (
 						config.StaticMockDir = staticMockDir
 					}
 				}
+				if staticMockStrict {
+					if !config.StaticMockStrict {
+						config.StaticMockStrict = true
+					}
+				}
+				if staticMockRecord {
+					if config.StaticMockRecord == nil {
+						config.StaticMockRecord = &shared.WiretapRecordConfig{}
+					}
+					config.StaticMockRecord.Enabled = true
+				}
 				if mockMode {
 					if !config.MockMode {
 						config.MockMode = true
@@ -227,6 +261,47 @@ var (
 				if len(harWhiteList) > 0 {
 					config.HARPathAllowList = harWhiteList
 				}
+				if len(harInclude) > 0 {
+					config.HARInclude = harInclude
+				}
+				if len(harExclude) > 0 {
+					config.HARExclude = harExclude
+				}
+				if reportFormat != "" {
+					config.ReportFormat = reportFormat
+				}
+				if harExport != "" {
+					config.HARExport = harExport
+				}
+				if captureFormat != "" {
+					config.CaptureFormat = captureFormat
+				}
+				if harReplay {
+					if config.HARReplay == nil {
+						config.HARReplay = &shared.WiretapHARReplayConfig{}
+					}
+					config.HARReplay.Enabled = true
+				}
+				if learnOutput != "" {
+					if config.Learn == nil {
+						config.Learn = &shared.WiretapLearnConfig{}
+					}
+					config.Learn.Enabled = true
+					config.Learn.Output = learnOutput
+				}
+				if driftOutput != "" {
+					if config.Drift == nil {
+						config.Drift = &shared.WiretapDriftConfig{}
+					}
+					config.Drift.Enabled = true
+					config.Drift.Output = driftOutput
+				}
+				if driftFormat != "" && config.Drift != nil {
+					config.Drift.Format = driftFormat
+				}
+				if failOn != "" {
+					config.FailOn = failOn
+				}
 
 			} else {
 
@@ -237,6 +312,12 @@ var (
 						config.StaticMockDir = staticMockDir
 					}
 				}
+				if staticMockStrict {
+					config.StaticMockStrict = true
+				}
+				if staticMockRecord {
+					config.StaticMockRecord = &shared.WiretapRecordConfig{Enabled: true}
+				}
 				if mockMode {
 					config.MockMode = true
 				}
@@ -256,6 +337,21 @@ var (
 				config.HAR = harFlag
 				config.HARValidate = harValidate
 				config.HARPathAllowList = harWhiteList
+				config.HARInclude = harInclude
+				config.HARExclude = harExclude
+				config.ReportFormat = reportFormat
+				config.HARExport = harExport
+				config.CaptureFormat = captureFormat
+				if harReplay {
+					config.HARReplay = &shared.WiretapHARReplayConfig{Enabled: true}
+				}
+				if learnOutput != "" {
+					config.Learn = &shared.WiretapLearnConfig{Enabled: true, Output: learnOutput}
+				}
+				if driftOutput != "" {
+					config.Drift = &shared.WiretapDriftConfig{Enabled: true, Output: driftOutput, Format: driftFormat}
+				}
+				config.FailOn = failOn
 			}
 
 			if spec == "" {
@@ -403,6 +499,16 @@ var (
 				printLoadedMockModeList(config.MockModeList)
 			}
 
+			if len(config.MockScenarios) > 0 {
+				config.CompileMockScenarios()
+				printLoadedMockScenarios(config.MockScenarios)
+			}
+
+			if config.MockCallbacks != nil && config.MockCallbacks.Enabled {
+				config.CompileMockCallbacks()
+				printLoadedMockCallbacks(config.MockCallbacks)
+			}
+
 			if len(config.HardErrorsList) > 0 && !config.HardErrors {
 				config.CompileHardErrorList()
 				printLoadedHardErrorList(config.MockModeList)
@@ -418,6 +524,22 @@ var (
 				printLoadedValidationAllowList(config.ValidationAllowList)
 			}
 
+			if len(config.ValidationRules) > 0 {
+				if rErr := config.CompileValidationRules(); rErr != nil {
+					pterm.Error.Printf("Cannot load validation rules: %s\n", rErr.Error())
+					pterm.Println()
+					return nil
+				}
+				printLoadedValidationRules(config.ValidationRules)
+			}
+
+			if config.FailOn != "" && (!shared.IsSeverity(config.FailOn) || strings.EqualFold(config.FailOn, shared.SeverityOff)) {
+				pterm.Error.Printf("Unknown fail-on severity '%s', use one of: %s\n", config.FailOn,
+					strings.Join(shared.Severities[:3], ", "))
+				pterm.Println()
+				return nil
+			}
+
 			// static headers
 			if config.Headers != nil && len(config.Headers.DropHeaders) > 0 {
 				pterm.Info.Printf("Dropping the following %d %s globally:\n", len(config.Headers.DropHeaders),
@@ -451,6 +573,80 @@ var (
 			if len(config.StaticMockDir) != 0 {
 				pterm.Printf("Ⓜ️ %s. Requests matching mock definitions in the static-mock-dir will return mocked responses.\n",
 					pterm.LightCyan("Static mock directory defined"))
+				if config.StaticMockRecord != nil && config.StaticMockRecord.Enabled {
+					pterm.Printf("🎙️  %s. Proxied traffic will be recorded into the static-mock-dir as mock definitions, recorded definitions are not served while recording.\n",
+						pterm.LightRed("Record mode enabled"))
+				}
+				if config.StaticMockStrict {
+					pterm.Printf("📜 %s. Mock definitions that do not comply with the contract will not be loaded.\n",
+						pterm.LightRed("Strict static mocks enabled"))
+				}
+				pterm.Println()
+			} else if config.StaticMockRecord != nil && config.StaticMockRecord.Enabled {
+				pterm.Warning.Println("Record mode requires a static mock directory, use --static-mock-dir. " +
+					"Traffic will not be recorded.")
+				pterm.Println()
+			}
+
+			// HAR export
+			if config.HARExport != "" {
+				pterm.Printf("📼 Traffic will be exported as a HAR file when wiretap shuts down: %s\n",
+					pterm.LightMagenta(config.HARExport))
+				pterm.Println()
+			}
+
+			// HAR replay
+			if config.HARReplay != nil && config.HARReplay.Enabled {
+				switch {
+				case config.HAR == "":
+					pterm.Warning.Println("HAR replay requires a HAR file, use --har. Nothing will be replayed.")
+				case config.MockMode:
+					pterm.Warning.Println("Cannot replay a HAR file in mock mode, there is no API to replay it against.")
+					config.HARReplay.Enabled = false
+				default:
+					pterm.Printf("🔁 HAR file requests will be replayed against the API and compared with the recorded responses\n")
+				}
+				pterm.Println()
+			}
+
+			// learning mode
+			if config.Learn != nil && config.Learn.Enabled {
+				if config.MockMode {
+					pterm.Warning.Println("Cannot learn an OpenAPI document in mock mode, there is no API traffic to learn from.")
+					config.Learn.Enabled = false
+				} else {
+					output := config.Learn.Output
+					if output == "" {
+						output = learn.DefaultLearnOutputFile
+					}
+					pterm.Printf("🎓 An OpenAPI document will be learned from traffic, and saved when wiretap shuts down: %s\n",
+						pterm.LightMagenta(output))
+				}
+				pterm.Println()
+			}
+
+			// contract drift
+			if config.Drift != nil && config.Drift.Enabled {
+				switch {
+				case !slices.Contains(append([]string{""}, drift.Formats...), config.Drift.Format):
+					pterm.Error.Printf("Unknown drift format '%s', use one of: %s\n", config.Drift.Format,
+						strings.Join(drift.Formats, ", "))
+					pterm.Println()
+					return nil
+				case config.MockMode:
+					pterm.Warning.Println("Cannot detect contract drift in mock mode, there is no API traffic to compare.")
+					config.Drift.Enabled = false
+				case config.Contract == "":
+					pterm.Warning.Println("Contract drift detection requires an OpenAPI specification, use '-s'.")
+					config.Drift.Enabled = false
+				default:
+					output := config.Drift.Output
+					if output == "" {
+						output = drift.DefaultDriftOutputFile
+					}
+					pterm.Printf("🧭 Contract drift will be detected, and contract updates suggested in: %s\n",
+						pterm.LightMagenta(output))
+				}
 				pterm.Println()
 			}
 
@@ -461,6 +657,25 @@ var (
 				pterm.Println()
 			}
 
+			// mock echo
+			if config.MockEcho {
+				pterm.Printf("🔁 Mock mode will echo path, query and body values into same-named response properties.\n")
+				pterm.Println()
+			}
+
+			// mock collection size
+			if config.MockCollectionSize > 0 {
+				pterm.Printf("📚 Paginated mocks will page through a collection of %d items.\n", config.MockCollectionSize)
+				pterm.Println()
+			}
+
+			// mock JWKS
+			if config.MockJWKS != "" {
+				pterm.Printf("🔑 Mock mode will verify JWT signatures using key set: %s\n",
+					pterm.LightMagenta(config.MockJWKS))
+				pterm.Println()
+			}
+
 			// using TLS?
 			if config.CertificateKey != "" && config.Certificate != "" {
 				pterm.Printf("🔐 Running over %s using certificate: %s and key: %s\n",
@@ -495,11 +710,16 @@ var (
 					return nil
 				}
 
-				harFile, fErr = har.BuildHAR(harBytes)
+				var format string
+				harFile, format, fErr = capture.Load(harBytes, config.CaptureFormat)
 				if fErr != nil {
 					pterm.Error.Printf("Cannot parse HAR file: %s (%s)\n", config.HAR, fErr.Error())
 					return nil
 				}
+				if format != capture.FormatHAR {
+					pterm.Printf("🔄 Converted %d %s from %s capture\n", len(harFile.Log.Entries),
+						shared.Pluralize(len(harFile.Log.Entries), "request", "requests"), pterm.LightCyan(format))
+				}
 				pterm.Println()
 				config.HARFile = harFile
 			}
@@ -514,13 +734,26 @@ var (
 			if config.HARValidate && !config.MockMode && config.Contract != "" {
 				pterm.Printf("🔍 Validating HAR file against OpenAPI specification: %s\n", pterm.LightMagenta(config.Contract))
 
-				// check if whitelist is empty, if so, fail.
-				if len(config.HARPathAllowList) == 0 {
-					pterm.Error.Println("Cannot validate HAR file against OpenAPI specification, no paths provided to whitelist, use '-j' / '--har-whitelist' to define whitelist paths")
+				if !har.IsReportFormat(config.ReportFormat) {
+					pterm.Error.Printf("Unknown report format '%s', use one of: %s\n", config.ReportFormat,
+						strings.Join(har.ReportFormats, ", "))
 					pterm.Println()
 					return nil
 				}
-				printLoadedHarWhitelist(config.HARPathAllowList)
+				// the default report file is named for the format it is written in.
+				if !cmd.Flags().Changed("report-filename") && config.ReportFile == defaultReportFilename {
+					config.ReportFile = strings.TrimSuffix(defaultReportFilename, ".json") +
+						har.ReportExtension(config.ReportFormat)
+				}
+				if len(config.HARPathAllowList) > 0 {
+					printLoadedHarWhitelist(config.HARPathAllowList)
+				}
+				for _, include := range config.HARInclude {
+					pterm.Printf("➕ HAR entries matching '%s' will be validated\n", pterm.LightCyan(include))
+				}
+				for _, exclude := range config.HARExclude {
+					pterm.Printf("➖ HAR entries matching '%s' will be skipped\n", pterm.LightCyan(exclude))
+				}
 
 			} else {
 				// we can't use this mode, print an error and return
@@ -608,23 +841,35 @@ var (
 
 				if harFile != nil && config.HARValidate {
 
-					count := 0
-					for _, entry := range harFile.Log.Entries {
-						if entry.Request.Method != "" {
-							count++
-						}
-						if entry.Response.StatusCode > 0 {
-							count++
+					report, vErr := har.ValidateHAR(harFile, &docModel.Model, &config)
+					if vErr != nil {
+						pterm.Error.Printf("Cannot validate HAR file: %s\n", vErr.Error())
+						pterm.Println()
+						return nil
+					}
+
+					for _, entry := range report.Entries {
+						switch entry.Status {
+						case har.EntryError:
+							pterm.Warning.Printf("HAR entry %d (%s %s) could not be validated: %s\n",
+								entry.Index, entry.Method, entry.URL, entry.Reason)
+						case har.EntrySkipped:
+							pterm.Debug.Printf("[HAR] skipping entry %d (%s %s): %s\n",
+								entry.Index, entry.Method, entry.URL, entry.Reason)
 						}
 					}
 
-					validationErrors := har.ValidateHAR(harFile, &docModel.Model, &config)
-					if len(validationErrors) > 0 {
+					if report.Invalid > 0 {
 						pterm.Println()
 						pterm.Error.Printf("HAR file failed validation against OpenAPI specification: %s\n", config.Contract)
 						pterm.Println()
-
-						for _, e := range validationErrors {
+					}
+					for _, entry := range report.Entries {
+						if entry.Status != har.EntryInvalid {
+							continue
+						}
+						pterm.Printf("%s %s\n", pterm.LightCyan(entry.Method), entry.URL)
+						for _, e := range append(entry.RequestValidation, entry.ResponseValidation...) {
 
 							location := pterm.Sprintf("Violation location: %s:%d:%d", pterm.LightCyan(config.Contract), e.SpecLine, e.SpecCol)
 							var items []pterm.BulletListItem
@@ -657,29 +902,48 @@ var (
 							}
 							pterm.DefaultBulletList.WithItems(items).Render()
 						}
+					}
 
-						if len(validationErrors) > 0 {
-							// render validationErrors to JSON
-							b, _ := json.MarshalIndent(validationErrors, "", "  ")
-							os.WriteFile(reportFilename, b, 0644)
-							pterm.Printf("Report generated and saved to: %s", pterm.LightMagenta(reportFilename))
-							pterm.Println()
+					shadow.PrintFindings(report.ShadowAPI)
 
-							pterm.Println()
-							pterm.Error.Printf("Wiretap detected %d contract violations against %d requests and responses",
-								len(validationErrors), count)
-							pterm.Println()
+					if rErr := har.WriteValidationReport(config.ReportFile, config.ReportFormat, report); rErr != nil {
+						pterm.Error.Printf("Cannot write report: %s (%s)\n", config.ReportFile, rErr.Error())
+					} else {
+						pterm.Printf("Report generated and saved to: %s", pterm.LightMagenta(config.ReportFile))
+						pterm.Println()
+					}
 
+					for _, entry := range report.Entries {
+						for _, notice := range entry.Notices {
+							pterm.Printf("%s %s %s %s\n", pterm.LightYellow(notice.Severity), pterm.LightCyan(entry.Method),
+								entry.URL, pterm.Gray(notice.Message))
 						}
+					}
 
-						return nil
+					pterm.Println()
+					summary := pterm.Sprintf("%d valid, %d invalid, %d skipped and %d %s, out of %d HAR %s",
+						report.Valid, report.Invalid, report.Skipped, report.Errors,
+						shared.Pluralize(report.Errors, "error", "errors"), report.Total,
+						shared.Pluralize(report.Total, "entry", "entries"))
+					if report.Warnings > 0 || report.Info > 0 {
+						summary += pterm.Sprintf(" (%d %s, %d info)", report.Warnings,
+							shared.Pluralize(report.Warnings, "warning", "warnings"), report.Info)
+					}
+					if report.Invalid > 0 {
+						pterm.Error.Printf("Wiretap detected %d contract violations: %s",
+							len(report.Violations()), summary)
 					} else {
-						pterm.Println()
-						pterm.Success.Printf("HAR file passed validation against %d requests and responses", count)
-						pterm.Println()
+						pterm.Success.Printf("HAR file passed validation: %s", summary)
+					}
+					pterm.Println()
 
+					if config.Drift != nil && config.Drift.Enabled {
+						printDriftSuggestion(&config, doc, harFile)
 					}
 
+					if config.FailOn != "" && report.Exceeds(config.FailOn) {
+						return fmt.Errorf("HAR validation found violations at least as severe as '%s'", config.FailOn)
+					}
 				}
 
 			}
@@ -711,6 +975,8 @@ func Execute(version, commit, date string, fs embed.FS) {
 	rootCmd.Flags().IntP("hard-validation-code", "q", 400, "Set a custom http error code for non-compliant requests when using the hard-error flag")
 	rootCmd.Flags().IntP("hard-validation-return-code", "y", 502, "Set a custom http error code for non-compliant responses when using the hard-error flag")
 	rootCmd.Flags().StringP("static-mock-dir", "", "", "Directory containing static mock definitions. All requests matching these definitions will return mocked responses.")
+	rootCmd.Flags().BoolP("static-mock-strict", "", false, "Refuse static mock definitions that do not comply with the OpenAPI specification")
+	rootCmd.Flags().BoolP("static-mock-record", "", false, "Record proxied traffic into the static-mock-dir as static mock definitions")
 	rootCmd.Flags().BoolP("mock-mode", "x", false, "Run in mock mode, responses are mocked and no traffic is sent to the target API (requires OpenAPI spec)")
 	rootCmd.Flags().BoolP("enable-all-mock-response-fields", "o", true, "Enable usage of all property examples in mock responses. When set to false, only required field examples will be used.")
 	rootCmd.Flags().StringP("config", "c", "", "Location of wiretap configuration file to use (default is .wiretap in current directory)")
@@ -719,10 +985,34 @@ func Execute(version, commit, date string, fs embed.FS) {
 	rootCmd.Flags().StringP("har", "z", "", "Load a HAR file instead of sniffing traffic")
 	rootCmd.Flags().BoolP("har-validate", "g", false, "Load a HAR file instead of sniffing traffic, and validate against the OpenAPI specification (requires -s)")
 	rootCmd.Flags().StringArrayP("har-allow", "j", nil, "Add a path to the HAR allow list, can use arg multiple times")
-	rootCmd.Flags().StringP("report-filename", "f", "wiretap-report.json", "Filename for any headless report generation output")
+	rootCmd.Flags().StringP("capture-format", "", "", "Format of the file loaded with --har: har, postman, curl, raw or ndjson (detected by default)")
+	rootCmd.Flags().StringArrayP("har-include", "", nil, "Only validate HAR entries with a path (or URL) matching a glob, can use arg multiple times")
+	rootCmd.Flags().StringArrayP("har-exclude", "", nil, "Skip HAR entries with a path (or URL) matching a glob, can use arg multiple times")
+	rootCmd.Flags().StringP("har-export", "", "", "Export all traffic as a HAR file when wiretap shuts down")
+	rootCmd.Flags().BoolP("har-replay", "", false, "Replay the HAR file against the API, report regressions and violations, and exit with an error when it fails")
+	rootCmd.Flags().StringP("learn", "", "", "Learn an OpenAPI 3.1 document from traffic, and write it to this file when wiretap shuts down")
+	rootCmd.Flags().StringP("drift", "", "", "Detect contract drift, and write suggested contract updates to this file")
+	rootCmd.Flags().StringP("drift-format", "", "", "Format of suggested contract updates: json-patch (default) or overlay")
+	rootCmd.Flags().StringP("fail-on", "", "", "Exit with an error when HAR validation (--har-validate) or replay (--har-replay) finds a violation at least this severe: error, warning or info. Only applies to HAR runs, proxied traffic never fails the run")
+	rootCmd.Flags().StringP("report-filename", "f", defaultReportFilename, "Filename for any headless report generation output, the extension of the default follows the HAR validation report format")
+	rootCmd.Flags().StringP("report-format", "", "", "Format of the HAR validation report: json (default), junit or sarif")
 	rootCmd.Flags().BoolP("stream-report", "a", false, "Stream violations to report JSON file as they occur (headless mode)")
 	rootCmd.Flags().BoolP("strict-redirect-location", "r", false, "Rewrite the redirect `Location` header on redirect responses to wiretap's API Gateway Host")
 
+	importPostmanCmd.Flags().StringP("static-mock-dir", "", "", "Directory to write the static mock definitions into")
+	rootCmd.AddCommand(importPostmanCmd)
+	learnCmd.Flags().StringP("output", "o", learn.DefaultLearnOutputFile, "File to write the OpenAPI document to, as JSON when it has a .json extension")
+	learnCmd.Flags().StringP("capture-format", "", "", "Format of the capture: har, postman, curl, raw or ndjson (detected by default)")
+	learnCmd.Flags().StringP("title", "", learn.DefaultTitle, "Title of the OpenAPI document")
+	rootCmd.AddCommand(learnCmd)
+	contractDiffCmd.Flags().StringP("base", "b", "", "Set a base path to resolve relative file references from, or a overriding base URL to resolve remote references from")
+	contractDiffCmd.Flags().StringP("capture-format", "", "", "Format of the capture: har, postman, curl, raw or ndjson (detected by default)")
+	contractDiffCmd.Flags().StringP("report-filename", "f", "", "Write the comparison report to this file as JSON")
+	contractDiffCmd.Flags().StringArrayP("har-allow", "j", nil, "Add a path to the HAR allow list, can use arg multiple times")
+	contractDiffCmd.Flags().StringArrayP("har-include", "", nil, "Only compare HAR entries with a path (or URL) matching a glob, can use arg multiple times")
+	contractDiffCmd.Flags().StringArrayP("har-exclude", "", nil, "Skip HAR entries with a path (or URL) matching a glob, can use arg multiple times")
+	rootCmd.AddCommand(contractDiffCmd)
+
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
 	}
@@ -853,6 +1143,27 @@ func printLoadedValidationAllowList(validationAllowList []string) {
 	pterm.Println()
 }
 
+func printLoadedValidationRules(rules []*shared.WiretapValidationRule) {
+	pterm.Info.Printf("Loaded %d validation %s:\n", len(rules), shared.Pluralize(len(rules), "rule", "rules"))
+
+	for _, x := range rules {
+		violation := x.Violation
+		if violation == "" {
+			violation = "**"
+		}
+		scope := ""
+		if x.Method != "" {
+			scope = " for " + strings.ToUpper(x.Method)
+		}
+		if x.Path != "" {
+			scope += pterm.Sprintf(" on paths matching '%s'", pterm.LightCyan(x.Path))
+		}
+		pterm.Printf("🚦 Violations matching '%s' are reported as %s%s\n", pterm.LightCyan(violation),
+			pterm.LightMagenta(strings.ToLower(x.Severity)), scope)
+	}
+	pterm.Println()
+}
+
 func printLoadedMockModeList(mockModeList []string) {
 	pterm.Info.Printf("Loaded %d %s from mock mode list:\n", len(mockModeList),
 		shared.Pluralize(len(mockModeList), "path", "paths"))
@@ -863,6 +1174,45 @@ func printLoadedMockModeList(mockModeList []string) {
 	pterm.Println()
 }
 
+func printLoadedMockScenarios(scenarios []*shared.WiretapMockScenario) {
+	pterm.Info.Printf("Loaded %d mock %s:\n", len(scenarios),
+		shared.Pluralize(len(scenarios), "scenario", "scenarios"))
+
+	for _, x := range scenarios {
+		var outcome []string
+		if x.StatusCode > 0 {
+			outcome = append(outcome, fmt.Sprintf("status %s", pterm.LightRed(x.StatusCode)))
+		}
+		if x.Example != "" {
+			outcome = append(outcome, fmt.Sprintf("example '%s'", pterm.LightGreen(x.Example)))
+		}
+		if x.Delay > 0 {
+			outcome = append(outcome, fmt.Sprintf("delay %sms", pterm.LightCyan(x.Delay)))
+		}
+		pterm.Printf("🎬 Scenario '%s' on '%s' will mock %s\n", pterm.LightMagenta(x.Name), x.Path, strings.Join(outcome, ", "))
+	}
+	pterm.Println()
+}
+
+func printLoadedMockCallbacks(callbacks *shared.WiretapCallbackConfig) {
+	pterm.Info.Printf("Mock callbacks enabled, %d webhook %s configured:\n", len(callbacks.Webhooks),
+		shared.Pluralize(len(callbacks.Webhooks), "trigger", "triggers"))
+
+	if callbacks.Target != "" {
+		pterm.Printf("📞 Callbacks that cannot be resolved and webhooks without a target will be sent to '%s'\n",
+			pterm.LightMagenta(callbacks.Target))
+	}
+	for _, x := range callbacks.Webhooks {
+		target := x.Target
+		if target == "" {
+			target = callbacks.Target
+		}
+		pterm.Printf("📞 Webhook '%s' will be sent to '%s' when '%s' is mocked\n", pterm.LightMagenta(x.Webhook),
+			pterm.LightCyan(target), x.Path)
+	}
+	pterm.Println()
+}
+
 func printLoadedHardErrorList(HardErrorList []string) {
 	pterm.Info.Printf("Loaded %d %s from hard validation list:\n", len(HardErrorList),
 		shared.Pluralize(len(HardErrorList), "path", "paths"))
@@ -872,3 +1222,29 @@ func printLoadedHardErrorList(HardErrorList []string) {
 	}
 	pterm.Println()
 }
+
+// printDriftSuggestion compares a validated HAR file with the contract, prints the drift detected and writes the
+// suggested contract updates.
+func printDriftSuggestion(config *shared.WiretapConfiguration, doc libopenapi.Document, harFile *harhar.HAR) {
+	analyzer, err := drift.NewAnalyzer(*doc.GetSpecInfo().SpecBytes)
+	if err != nil {
+		pterm.Error.Printf("Cannot detect contract drift: %s\n", err.Error())
+		return
+	}
+	if refs := analyzer.ExternalReferences(); len(refs) > 0 {
+		pterm.Warning.Printf("Contract drift is not detected for schemas referenced from other files: %s\n",
+			strings.Join(refs, ", "))
+	}
+	analyzer.ObserveHAR(harFile)
+	drift.PrintFindings(analyzer.Findings(config.Drift.MinOccurrences))
+	output := config.Drift.Output
+	if output == "" {
+		output = drift.DefaultDriftOutputFile
+	}
+	if err = analyzer.WriteSuggestion(output, config.Drift.Format, config.Drift.MinOccurrences); err != nil {
+		pterm.Error.Printf("Cannot write contract drift suggestion: %s (%s)\n", output, err.Error())
+		return
+	}
+	pterm.Printf("Contract drift suggestion saved to: %s\n", pterm.LightMagenta(output))
+	pterm.Println()
+}
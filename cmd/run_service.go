@@ -14,11 +14,15 @@ import (
 	"github.com/pb33f/wiretap/config"
 	"github.com/pb33f/wiretap/controls"
 	"github.com/pb33f/wiretap/daemon"
+	"github.com/pb33f/wiretap/drift"
 	"github.com/pb33f/wiretap/har"
+	"github.com/pb33f/wiretap/learn"
 	"github.com/pb33f/wiretap/report"
+	"github.com/pb33f/wiretap/shadow"
 	"github.com/pb33f/wiretap/shared"
 	"github.com/pb33f/wiretap/specs"
 	staticMock "github.com/pb33f/wiretap/static-mock"
+	"github.com/pterm/pterm"
 )
 
 func runWiretapService(wiretapConfig *shared.WiretapConfiguration, doc libopenapi.Document) (server.PlatformServer, error) {
@@ -65,7 +69,10 @@ func runWiretapService(wiretapConfig *shared.WiretapConfiguration, doc libopenap
 	platformServer := server.NewPlatformServer(ranchConfig)
 
 	// create wiretap service
-	wtService := daemon.NewWiretapService(doc, wiretapConfig)
+	wtService, err := daemon.NewWiretapService(doc, wiretapConfig)
+	if err != nil {
+		return nil, err
+	}
 
 	// register wiretap service
 	if err = platformServer.RegisterService(wtService, daemon.WiretapServiceChan); err != nil {
@@ -81,6 +88,41 @@ func runWiretapService(wiretapConfig *shared.WiretapConfiguration, doc libopenap
 	// Start watcher to look for changes to static mock definitions
 	staticMockService.StartWatcher()
 
+	// record proxied traffic into static mock definitions
+	if wiretapConfig.StaticMockRecord != nil && wiretapConfig.StaticMockRecord.Enabled &&
+		wiretapConfig.StaticMockDir != "" {
+		wtService.AddTrafficObserver(staticMock.NewRecorder(wiretapConfig.StaticMockDir,
+			wiretapConfig.StaticMockRecord, wiretapConfig.Logger))
+	}
+
+	// learn an OpenAPI document from proxied traffic
+	if wiretapConfig.Learn != nil && wiretapConfig.Learn.Enabled {
+		if err = platformServer.RegisterService(
+			learn.NewLearnService(wtService, wiretapConfig, wiretapConfig.Logger), learn.LearnServiceChan); err != nil {
+			panic(err)
+		}
+	}
+
+	// compare proxied traffic with the contract
+	if wiretapConfig.Drift != nil && wiretapConfig.Drift.Enabled && doc != nil {
+		driftService, dErr := drift.NewDriftService(wtService, *doc.GetSpecInfo().SpecBytes, wiretapConfig.Drift,
+			wiretapConfig.Logger)
+		if dErr != nil {
+			return nil, dErr
+		}
+		if err = platformServer.RegisterService(driftService, drift.DriftServiceChan); err != nil {
+			panic(err)
+		}
+	}
+
+	// detect traffic the contract does not describe
+	if doc != nil {
+		if err = platformServer.RegisterService(
+			shadow.NewShadowService(wtService, wiretapConfig), shadow.ShadowServiceChan); err != nil {
+			panic(err)
+		}
+	}
+
 	// register spec service
 	if err = platformServer.RegisterService(
 		specs.NewSpecService(doc), specs.SpecServiceChan); err != nil {
@@ -106,8 +148,8 @@ func runWiretapService(wiretapConfig *shared.WiretapConfiguration, doc libopenap
 	}
 
 	// register HAR Service
-	if err = platformServer.RegisterService(
-		har.NewHARService(wtService, wiretapConfig.Logger), har.HARServiceChan); err != nil {
+	harService := har.NewHARService(wtService, wiretapConfig.Logger)
+	if err = platformServer.RegisterService(harService, har.HARServiceChan); err != nil {
 		panic(err)
 	}
 
@@ -133,6 +175,16 @@ func runWiretapService(wiretapConfig *shared.WiretapConfiguration, doc libopenap
 		daemon.MonitorStatic(wiretapConfig)
 	}
 
+	// replay the HAR file against the API, failing the run if it regressed.
+	if wiretapConfig.HARReplay != nil && wiretapConfig.HARReplay.Enabled {
+		go func() {
+			if replayReport := harService.ReplayLoadedHAR(); replayReport != nil && replayReport.Failed(wiretapConfig.FailOn) {
+				pterm.Error.Println("HAR replay failed, wiretap is exiting")
+				os.Exit(1)
+			}
+		}()
+	}
+
 	// boot wiretap
 	platformServer.StartServer(sysChan)
 	return platformServer, nil
@@ -0,0 +1,66 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: AGPL
+
+package config
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pb33f/wiretap/shared"
+)
+
+// FindMockScenario returns the first configured mock scenario that matches the request path, method and all
+// query, header and body predicates. Scenarios are evaluated in the order they are configured. An empty predicate
+// value only checks that the query parameter, header or body path is present.
+func FindMockScenario(request *http.Request, configuration *shared.WiretapConfiguration) *shared.WiretapMockScenario {
+	if len(configuration.CompiledMockScenarios) == 0 {
+		return nil
+	}
+
+	var body []byte
+	if request.Body != nil && request.Body != http.NoBody {
+		body, _ = io.ReadAll(request.Body)
+		_ = request.Body.Close()
+		request.Body = io.NopCloser(bytes.NewBuffer(body))
+	}
+
+	for _, compiled := range configuration.CompiledMockScenarios {
+		if mockScenarioMatches(compiled, request, body) {
+			return compiled.Scenario
+		}
+	}
+	return nil
+}
+
+func mockScenarioMatches(compiled *shared.CompiledMockScenario, request *http.Request, body []byte) bool {
+	scenario := compiled.Scenario
+	if compiled.CompiledPath != nil && !compiled.CompiledPath.Match(request.URL.Path) {
+		return false
+	}
+	if scenario.Method != "" && !strings.EqualFold(scenario.Method, request.Method) {
+		return false
+	}
+
+	query := request.URL.Query()
+	for k, v := range scenario.Query {
+		if !query.Has(k) || (v != "" && !shared.StringCompare(v, query.Get(k))) {
+			return false
+		}
+	}
+
+	for k, v := range scenario.Headers {
+		if request.Header.Get(k) == "" || (v != "" && !shared.StringCompare(v, request.Header.Get(k))) {
+			return false
+		}
+	}
+
+	for path, v := range scenario.Body {
+		if !shared.JSONPathMatches(body, path, v) {
+			return false
+		}
+	}
+	return true
+}
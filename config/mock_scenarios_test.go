@@ -0,0 +1,76 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: AGPL
+
+package config
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/pb33f/wiretap/shared"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestFindMockScenario(t *testing.T) {
+	config := `mockScenarios:
+  - name: empty-list
+    path: /pets/**
+    method: GET
+    query:
+      state: empty
+    statusCode: 200
+    example: emptyList
+  - name: server-error
+    path: /pets/**
+    headers:
+      X-Scenario: boom
+    statusCode: 500
+  - name: slow
+    path: /pets
+    method: POST
+    body:
+      $.name: slow.*
+    delay: 2000`
+
+	var wcConfig shared.WiretapConfiguration
+	_ = yaml.Unmarshal([]byte(config), &wcConfig)
+	wcConfig.CompileMockScenarios()
+
+	r, _ := http.NewRequest(http.MethodGet, "http://localhost/pets/dogs?state=empty", nil)
+	scenario := FindMockScenario(r, &wcConfig)
+	assert.NotNil(t, scenario)
+	assert.Equal(t, "empty-list", scenario.Name)
+	assert.Equal(t, "emptyList", scenario.Example)
+
+	r, _ = http.NewRequest(http.MethodDelete, "http://localhost/pets/dogs", nil)
+	r.Header.Set("X-Scenario", "boom")
+	scenario = FindMockScenario(r, &wcConfig)
+	assert.NotNil(t, scenario)
+	assert.Equal(t, 500, scenario.StatusCode)
+
+	r, _ = http.NewRequest(http.MethodPost, "http://localhost/pets",
+		bytes.NewBufferString(`{"name": "slowpoke"}`))
+	scenario = FindMockScenario(r, &wcConfig)
+	assert.NotNil(t, scenario)
+	assert.Equal(t, 2000, scenario.Delay)
+
+	// the body must still be readable after matching.
+	b, _ := io.ReadAll(r.Body)
+	assert.Equal(t, `{"name": "slowpoke"}`, string(b))
+
+	r, _ = http.NewRequest(http.MethodPost, "http://localhost/pets",
+		bytes.NewBufferString(`{"name": "speedy"}`))
+	assert.Nil(t, FindMockScenario(r, &wcConfig))
+
+	r, _ = http.NewRequest(http.MethodGet, "http://localhost/pets/dogs?state=full", nil)
+	assert.Nil(t, FindMockScenario(r, &wcConfig))
+}
+
+func TestFindMockScenario_NoScenarios(t *testing.T) {
+	var wcConfig shared.WiretapConfiguration
+	r, _ := http.NewRequest(http.MethodGet, "http://localhost/pets", nil)
+	assert.Nil(t, FindMockScenario(r, &wcConfig))
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package config
+
+import (
+	"strings"
+
+	"github.com/pb33f/libopenapi-validator/errors"
+	"github.com/pb33f/wiretap/shared"
+)
+
+// ViolationSeverity returns the severity the validation rules give a violation of a request, later rules take
+// precedence over earlier ones. A schema violation is as severe as the most severe of the keywords that failed.
+func ViolationSeverity(violation *errors.ValidationError, method, path string,
+	configuration *shared.WiretapConfiguration) string {
+
+	if len(configuration.CompiledValidationRules) == 0 {
+		return shared.SeverityError
+	}
+	id := ViolationId(violation)
+	var keywords []string
+	for _, failure := range violation.SchemaValidationErrors {
+		location := strings.TrimSuffix(failure.Location, "/")
+		if i := strings.LastIndex(location, "/"); i >= 0 && i < len(location)-1 {
+			keywords = append(keywords, location[i+1:])
+		}
+	}
+	if len(keywords) == 0 {
+		return ruleSeverity(method, path, configuration, id)
+	}
+	severity := shared.SeverityOff
+	for _, keyword := range keywords {
+		severity = shared.MostSevere(severity, ruleSeverity(method, path, configuration, id, id+"/"+keyword))
+	}
+	return severity
+}
+
+// ruleSeverity returns the severity of the last rule that matches the request and any of the violation ids.
+func ruleSeverity(method, path string, configuration *shared.WiretapConfiguration, ids ...string) string {
+	severity := shared.SeverityError
+	for _, compiled := range configuration.CompiledValidationRules {
+		if compiled.Rule.Method != "" && !strings.EqualFold(compiled.Rule.Method, method) {
+			continue
+		}
+		if compiled.CompiledPath != nil && !compiled.CompiledPath.Match(path) {
+			continue
+		}
+		for _, id := range ids {
+			if compiled.CompiledViolation.Match(id) {
+				severity = strings.ToLower(compiled.Rule.Severity)
+				break
+			}
+		}
+	}
+	return severity
+}
+
+// ClassifyViolations splits the violations of a request into errors, and notices for the violations the validation
+// rules give a lower severity. Violations that are switched off are dropped.
+func ClassifyViolations(violations []*errors.ValidationError, method, path string,
+	configuration *shared.WiretapConfiguration) ([]*errors.ValidationError, []*shared.Notice) {
+
+	var errs []*errors.ValidationError
+	var notices []*shared.Notice
+	for _, violation := range violations {
+		switch severity := ViolationSeverity(violation, method, path, configuration); severity {
+		case shared.SeverityError:
+			errs = append(errs, violation)
+		case shared.SeverityOff:
+		default:
+			notices = append(notices, &shared.Notice{Severity: severity, ValidationError: violation})
+		}
+	}
+	return errs, notices
+}
+
+// ViolationId identifies the kind of violation, such as `parameter/header` or `response/statusCode`.
+func ViolationId(violation *errors.ValidationError) string {
+	if violation.ValidationSubType == "" {
+		return violation.ValidationType
+	}
+	return violation.ValidationType + "/" + violation.ValidationSubType
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package config
+
+import (
+	"testing"
+
+	"github.com/pb33f/libopenapi-validator/errors"
+	"github.com/pb33f/wiretap/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestViolationSeverity(t *testing.T) {
+	config := `validationRules:
+  - violation: parameter/header
+    severity: warning
+  - violation: "*/schema/additionalProperties"
+    severity: info
+  - violation: response/statusCode
+    severity: off
+  - violation: parameter/header
+    path: /admin/*
+    severity: error
+  - violation: "**"
+    method: DELETE
+    severity: info`
+
+	var wcConfig shared.WiretapConfiguration
+	require.NoError(t, yaml.Unmarshal([]byte(config), &wcConfig))
+	require.NoError(t, wcConfig.CompileValidationRules())
+
+	header := &errors.ValidationError{ValidationType: "parameter", ValidationSubType: "header"}
+	assert.Equal(t, shared.SeverityWarning, ViolationSeverity(header, "GET", "/pets", &wcConfig))
+	assert.Equal(t, shared.SeverityError, ViolationSeverity(header, "GET", "/admin/users", &wcConfig))
+	assert.Equal(t, shared.SeverityInfo, ViolationSeverity(header, "delete", "/admin/users", &wcConfig))
+
+	additional := &errors.ValidationError{ValidationType: "response", ValidationSubType: "schema",
+		SchemaValidationErrors: []*errors.SchemaValidationFailure{{Location: "/additionalProperties"}}}
+	assert.Equal(t, shared.SeverityInfo, ViolationSeverity(additional, "GET", "/pets", &wcConfig))
+
+	// the most severe keyword wins.
+	additional.SchemaValidationErrors = append(additional.SchemaValidationErrors,
+		&errors.SchemaValidationFailure{Location: "/properties/name/type"})
+	assert.Equal(t, shared.SeverityError, ViolationSeverity(additional, "GET", "/pets", &wcConfig))
+
+	status := &errors.ValidationError{ValidationType: "response", ValidationSubType: "statusCode"}
+	errs, notices := ClassifyViolations([]*errors.ValidationError{header, additional, status}, "GET", "/pets",
+		&wcConfig)
+	assert.Equal(t, []*errors.ValidationError{additional}, errs)
+	require.Len(t, notices, 1)
+	assert.Equal(t, shared.SeverityWarning, notices[0].Severity)
+	assert.Equal(t, header, notices[0].ValidationError)
+
+	wcConfig.ValidationRules[0].Severity = "fatal"
+	assert.ErrorContains(t, wcConfig.CompileValidationRules(), "unknown severity 'fatal'")
+}
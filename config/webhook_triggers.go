@@ -0,0 +1,26 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: AGPL
+
+package config
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pb33f/wiretap/shared"
+)
+
+// FindWebhookTriggers returns every configured webhook trigger that matches the request path and method.
+func FindWebhookTriggers(request *http.Request, configuration *shared.WiretapConfiguration) []*shared.WiretapWebhookTrigger {
+	var triggers []*shared.WiretapWebhookTrigger
+	for _, compiled := range configuration.CompiledWebhookTriggers {
+		if compiled.CompiledPath != nil && !compiled.CompiledPath.Match(request.URL.Path) {
+			continue
+		}
+		if compiled.Trigger.Method != "" && !strings.EqualFold(compiled.Trigger.Method, request.Method) {
+			continue
+		}
+		triggers = append(triggers, compiled.Trigger)
+	}
+	return triggers
+}
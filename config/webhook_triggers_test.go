@@ -0,0 +1,46 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: AGPL
+
+package config
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/pb33f/wiretap/shared"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestFindWebhookTriggers(t *testing.T) {
+	config := `mockCallbacks:
+  enabled: true
+  target: http://localhost:8080/hooks
+  webhooks:
+    - webhook: newPet
+      path: /pets
+      method: POST
+      delay: 0
+    - webhook: petEvent
+      path: /pets*`
+
+	var wcConfig shared.WiretapConfiguration
+	_ = yaml.Unmarshal([]byte(config), &wcConfig)
+	wcConfig.CompileMockCallbacks()
+
+	r, _ := http.NewRequest(http.MethodPost, "http://localhost/pets", nil)
+	triggers := FindWebhookTriggers(r, &wcConfig)
+	assert.Len(t, triggers, 2)
+	assert.Equal(t, "newPet", triggers[0].Webhook)
+	assert.NotNil(t, triggers[0].Delay)
+	assert.Equal(t, 0, *triggers[0].Delay)
+	assert.Nil(t, triggers[1].Delay)
+
+	r, _ = http.NewRequest(http.MethodGet, "http://localhost/pets", nil)
+	triggers = FindWebhookTriggers(r, &wcConfig)
+	assert.Len(t, triggers, 1)
+	assert.Equal(t, "petEvent", triggers[0].Webhook)
+
+	r, _ = http.NewRequest(http.MethodGet, "http://localhost/owners", nil)
+	assert.Empty(t, FindWebhookTriggers(r, &wcConfig))
+}
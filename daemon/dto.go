@@ -5,6 +5,7 @@ package daemon
 
 import (
 	"github.com/pb33f/libopenapi-validator/errors"
+	"github.com/pb33f/wiretap/shared"
 	"net/textproto"
 	"time"
 )
@@ -49,9 +50,13 @@ type HttpResponse struct {
 type HttpTransaction struct {
 	Request            *HttpRequest              `json:"httpRequest,omitempty"`
 	RequestValidation  []*errors.ValidationError `json:"requestValidation,omitempty"`
+	RequestNotices     []*shared.Notice          `json:"requestNotices,omitempty"`
 	Response           *HttpResponse             `json:"httpResponse,omitempty"`
 	ResponseValidation []*errors.ValidationError `json:"responseValidation,omitempty"`
+	ResponseNotices    []*shared.Notice          `json:"responseNotices,omitempty"`
 	Id                 string                    `json:"id,omitempty"`
+	ParentId           string                    `json:"parentId,omitempty"`
+	Callback           string                    `json:"callback,omitempty"`
 }
 
 type FormPart struct {
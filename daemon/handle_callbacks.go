@@ -0,0 +1,144 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: AGPL
+
+package daemon
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pb33f/ranch/model"
+	configModel "github.com/pb33f/wiretap/config"
+	"github.com/pb33f/wiretap/mock"
+	"github.com/pb33f/wiretap/shared"
+)
+
+// prepareOutboundCalls builds the callbacks of the mocked operation and any webhooks triggered by the request.
+// This must happen before the request body is consumed, the calls are fired later by fireOutboundCalls.
+func (ws *WiretapService) prepareOutboundCalls(request *model.Request, config *shared.WiretapConfiguration,
+	mockResponse *mock.MockResponse) map[int][]*mock.OutboundCall {
+
+	cbConfig := config.MockCallbacks
+	if cbConfig == nil || !cbConfig.Enabled {
+		return nil
+	}
+
+	// calls are grouped by the delay they are sent after.
+	calls := make(map[int][]*mock.OutboundCall)
+
+	callbacks, errs := ws.mockEngine.BuildCallbacks(request.HttpRequest, mockResponse, cbConfig.Target)
+	for _, err := range errs {
+		config.Logger.Warn("[wiretap] unable to build callback", "url", request.HttpRequest.URL.String(), "error", err.Error())
+	}
+	calls[cbConfig.Delay] = append(calls[cbConfig.Delay], callbacks...)
+
+	for _, trigger := range configModel.FindWebhookTriggers(request.HttpRequest, config) {
+		target := trigger.Target
+		if target == "" {
+			target = cbConfig.Target
+		}
+		delay := cbConfig.Delay
+		if trigger.Delay != nil {
+			delay = *trigger.Delay
+		}
+		webhooks, err := ws.mockEngine.BuildWebhook(trigger.Webhook, target)
+		if err != nil {
+			config.Logger.Warn("[wiretap] unable to build webhook", "webhook", trigger.Webhook, "error", err.Error())
+		}
+		calls[delay] = append(calls[delay], webhooks...)
+	}
+	return calls
+}
+
+// fireOutboundCalls sends prepared callbacks and webhooks after their delay, validates the responses returned by
+// the targets and broadcasts each call to the monitor as a transaction linked to the request that caused it.
+func (ws *WiretapService) fireOutboundCalls(parent *model.Request, config *shared.WiretapConfiguration,
+	calls map[int][]*mock.OutboundCall) {
+
+	for delay, group := range calls {
+		if len(group) == 0 {
+			continue
+		}
+		go func(delay int, group []*mock.OutboundCall) {
+			if delay > 0 {
+				time.Sleep(time.Duration(delay) * time.Millisecond)
+			}
+			for _, call := range group {
+				ws.fireOutboundCall(parent, config, call)
+			}
+		}(delay, group)
+	}
+}
+
+func (ws *WiretapService) fireOutboundCall(parent *model.Request, config *shared.WiretapConfiguration,
+	call *mock.OutboundCall) {
+
+	id, _ := uuid.NewUUID()
+	callRequest := &model.Request{Id: &id, HttpRequest: call.Request}
+
+	var body []byte
+	if call.Request.Body != nil {
+		body, _ = io.ReadAll(call.Request.Body)
+		_ = call.Request.Body.Close()
+		call.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+	}
+
+	headers := make(map[string]any)
+	for k, v := range call.Request.Header {
+		headers[k] = v[0]
+	}
+	transaction := &HttpTransaction{
+		Id:       id.String(),
+		ParentId: parent.Id.String(),
+		Callback: call.Name,
+		Request: &HttpRequest{
+			URL:       call.Request.URL.String(),
+			Method:    call.Request.Method,
+			Path:      call.Request.URL.Path,
+			Host:      call.Request.URL.Host,
+			Query:     call.Request.URL.RawQuery,
+			Headers:   headers,
+			Body:      string(body),
+			Timestamp: time.Now().UnixMilli(),
+		},
+	}
+	ws.storeTransaction(transaction)
+	ws.broadcastRequest(callRequest, transaction)
+
+	config.Logger.Info("[wiretap] firing mock callback", "name", call.Name, "url", call.Request.URL.String(),
+		"method", call.Request.Method, "parent", parent.Id.String())
+
+	client := &http.Client{Transport: ws.transport, Timeout: 30 * time.Second}
+	response, err := client.Do(call.Request)
+	if err != nil {
+		config.Logger.Error("[wiretap] mock callback failed", "name", call.Name, "url", call.Request.URL.String(),
+			"error", err.Error())
+		ws.broadcastResponseError(callRequest, nil, err)
+		return
+	}
+	defer response.Body.Close()
+
+	validationErrors := ws.mockEngine.ValidateOutboundResponse(call, response)
+
+	responseTransaction := BuildResponse(callRequest, response)
+	responseTransaction.ParentId = parent.Id.String()
+	responseTransaction.Callback = call.Name
+	responseTransaction.ResponseValidation = validationErrors
+	ws.storeTransaction(responseTransaction)
+
+	if len(validationErrors) > 0 {
+		ws.streamChan <- validationErrors
+	}
+	msgId, _ := uuid.NewUUID()
+	ws.broadcastChan.Send(&model.Message{
+		Id:            &msgId,
+		DestinationId: callRequest.Id,
+		Channel:       WiretapBroadcastChan,
+		Destination:   WiretapBroadcastChan,
+		Payload:       responseTransaction,
+		Direction:     model.ResponseDir,
+	})
+}
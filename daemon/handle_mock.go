@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/pb33f/ranch/model"
@@ -18,9 +19,17 @@ import (
 
 func (ws *WiretapService) handleMockRequest(
 	request *model.Request, config *shared.WiretapConfiguration, newReq *http.Request) {
+	// check if a configured scenario wants to shape this mock.
+	scenario := configModel.FindMockScenario(request.HttpRequest, config)
+	if scenario != nil {
+		config.Logger.Info("[wiretap] mock scenario matched", "url", request.HttpRequest.URL.String(), "scenario", scenario.Name)
+	}
+
 	// dip out early if we're in mock mode.
 	delay := configModel.FindPathDelay(request.HttpRequest.URL.Path, config)
-	if delay > 0 {
+	if scenario != nil && scenario.Delay > 0 {
+		time.Sleep(time.Duration(scenario.Delay) * time.Millisecond) // simulate a slow response, configured for scenario.
+	} else if delay > 0 {
 		time.Sleep(time.Duration(delay) * time.Millisecond) // simulate a slow response, configured for path.
 	} else {
 		if config.GlobalAPIDelay > 0 {
@@ -29,7 +38,14 @@ func (ws *WiretapService) handleMockRequest(
 	}
 
 	// build a mock based on the request.
-	mock, mockStatus, mockErr := ws.mockEngine.GenerateResponse(request.HttpRequest)
+	mockResponse, mockErr := ws.mockEngine.GenerateMockResponse(request.HttpRequest, scenario)
+	mock, mockStatus := mockResponse.Body, mockResponse.StatusCode
+
+	// build any callbacks or webhooks before the request body is consumed, they are fired once the mock is sent.
+	if mockErr == nil {
+		outboundCalls := ws.prepareOutboundCalls(request, config, mockResponse)
+		defer ws.fireOutboundCalls(request, config, outboundCalls)
+	}
 
 	// validate http request.
 	ws.ValidateRequest(request, newReq)
@@ -41,6 +57,15 @@ func (ws *WiretapService) handleMockRequest(
 	headers := make(map[string][]string)
 	shared.SetCORSHeaders(headers)
 	headers["Content-Type"] = []string{"application/json"}
+	var exposed []string
+	for k, v := range mockResponse.Headers {
+		headers[k] = v
+		exposed = append(exposed, k)
+	}
+	if len(exposed) > 0 {
+		// browsers can only read headers that are exposed.
+		headers["Access-Control-Expose-Headers"] = []string{strings.Join(exposed, ", ")}
+	}
 
 	buff := bytes.NewBuffer(mock)
 
@@ -68,6 +93,7 @@ func (ws *WiretapService) handleMockRequest(
 
 		// validate response async
 		resp.StatusCode = mockStatus
+		ws.storeResponse(request, resp)
 		go ws.broadcastResponse(request, resp)
 		return
 	}
@@ -81,12 +107,14 @@ func (ws *WiretapService) handleMockRequest(
 
 		// validate response async
 		resp.StatusCode = mockStatus
+		ws.storeResponse(request, resp)
 		go ws.broadcastResponse(request, resp)
 		return
 	}
 
 	// validate response async
 	resp.StatusCode = mockStatus
+	ws.storeResponse(request, resp)
 	go ws.broadcastResponse(request, resp)
 
 	// if the mock is empty
@@ -32,6 +32,10 @@ type staticTemplateModel struct {
 }
 
 func (ws *WiretapService) handleHttpRequest(request *model.Request) {
+	ws.handleHttpRequestWithModifier(request, nil)
+}
+
+func (ws *WiretapService) handleHttpRequestWithModifier(request *model.Request, modifier ResponseModifier) {
 
 	// determine if this is a request for a file or not.
 	if ws.config.StaticDir != "" {
@@ -97,36 +101,7 @@ func (ws *WiretapService) handleHttpRequest(request *model.Request) {
 	configStore, _ := ws.controlsStore.Get(shared.ConfigKey)
 	config := configStore.(*shared.WiretapConfiguration)
 
-	if config.Headers == nil || len(config.Headers.DropHeaders) == 0 {
-		config.Headers = &shared.WiretapHeaderConfig{
-			DropHeaders: []string{},
-		}
-	}
-
-	dropHeaders, injectHeaders, auth := ws.getHeadersAndAuth(config, request)
-
-	newReq := CloneExistingRequest(CloneRequest{
-		Request:       request.HttpRequest,
-		Protocol:      config.RedirectProtocol,
-		Host:          config.RedirectHost,
-		Port:          config.RedirectPort,
-		DropHeaders:   dropHeaders,
-		InjectHeaders: injectHeaders,
-		Auth:          auth,
-		Variables:     config.CompiledVariables,
-	})
-
-	apiRequest := CloneExistingRequest(CloneRequest{
-		Request:       request.HttpRequest,
-		Protocol:      config.RedirectProtocol,
-		Host:          config.RedirectHost,
-		BasePath:      config.RedirectBasePath,
-		Port:          config.RedirectPort,
-		DropHeaders:   dropHeaders,
-		InjectHeaders: injectHeaders,
-		Auth:          auth,
-		Variables:     config.CompiledVariables,
-	})
+	newReq, apiRequest := ws.cloneAPIRequests(config, request)
 
 	if newReq == nil || apiRequest == nil {
 		ws.config.Logger.Error("[wiretap] unable to clone API request, failed", "url", request.HttpRequest.URL.String())
@@ -157,6 +132,15 @@ func (ws *WiretapService) handleHttpRequest(request *model.Request) {
 	// call the API being requested.
 	returnedResponse, returnedError = ws.callAPI(apiRequest)
 
+	// modify the response (such as a static mock patching it), so the modified response is what gets validated.
+	if modifier != nil && returnedResponse != nil && returnedError == nil {
+		if modified, err := modifier(returnedResponse); err != nil {
+			returnedResponse, returnedError = nil, err
+		} else {
+			returnedResponse = modified
+		}
+	}
+
 	if returnedResponse == nil && returnedError != nil {
 		config.Logger.Info("[wiretap] request failed", "url", apiRequest.URL.String(), "code", 500,
 			"error", returnedError.Error())
@@ -191,6 +175,9 @@ func (ws *WiretapService) handleHttpRequest(request *model.Request) {
 	body, _ := io.ReadAll(returnedResponse.Body)
 	headers := ExtractHeaders(returnedResponse)
 
+	// let observers (such as record mode) see the transaction, before the headers are modified.
+	ws.observeTraffic(request.HttpRequest, returnedResponse, body)
+
 	// wiretap needs to work from anywhere, so allow everything.
 	shared.SetCORSHeaders(headers)
 
@@ -229,6 +216,45 @@ func (ws *WiretapService) handleHttpRequest(request *model.Request) {
 	_, _ = request.HttpResponseWriter.Write(body)
 }
 
+// cloneAPIRequests clones an incoming request twice, applying the header rules, authentication and variables of the
+// configuration. The first clone is validated against the contract, the second one is sent to the API.
+func (ws *WiretapService) cloneAPIRequests(config *shared.WiretapConfiguration,
+	request *model.Request) (*http.Request, *http.Request) {
+
+	if config.Headers == nil || len(config.Headers.DropHeaders) == 0 {
+		config.Headers = &shared.WiretapHeaderConfig{
+			DropHeaders: []string{},
+		}
+	}
+
+	dropHeaders, injectHeaders, auth := ws.getHeadersAndAuth(config, request)
+
+	newReq := CloneExistingRequest(CloneRequest{
+		Request:       request.HttpRequest,
+		Protocol:      config.RedirectProtocol,
+		Host:          config.RedirectHost,
+		Port:          config.RedirectPort,
+		DropHeaders:   dropHeaders,
+		InjectHeaders: injectHeaders,
+		Auth:          auth,
+		Variables:     config.CompiledVariables,
+	})
+
+	apiRequest := CloneExistingRequest(CloneRequest{
+		Request:       request.HttpRequest,
+		Protocol:      config.RedirectProtocol,
+		Host:          config.RedirectHost,
+		BasePath:      config.RedirectBasePath,
+		Port:          config.RedirectPort,
+		DropHeaders:   dropHeaders,
+		InjectHeaders: injectHeaders,
+		Auth:          auth,
+		Variables:     config.CompiledVariables,
+	})
+
+	return newReq, apiRequest
+}
+
 var gorillaDropHeaders = []string{
 	// Gorilla fills in the following headers, and complains if they are already present
 	"Upgrade",
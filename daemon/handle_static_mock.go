@@ -13,6 +13,17 @@ import (
 )
 
 func (ws *WiretapService) handleStaticMockResponse(request *model.Request, response *http.Response) {
+	// keep the mocked transaction, so it can be reported and exported like any other.
+	if request.Id != nil && ws.config != nil {
+		ws.storeTransaction(BuildHttpTransaction(HttpTransactionConfig{
+			OriginalRequest:   request.HttpRequest,
+			NewRequest:        request.HttpRequest,
+			ID:                request.Id,
+			TransactionConfig: ws.config,
+		}))
+		ws.storeResponse(request, response)
+	}
+
 	// validate response async
 	go ws.broadcastResponse(request, response)
 
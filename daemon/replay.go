@@ -0,0 +1,54 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: AGPL
+
+package daemon
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/pb33f/libopenapi-validator/errors"
+	"github.com/pb33f/ranch/model"
+	"github.com/pb33f/wiretap/shared"
+)
+
+// ReplayResult is the response of the API to a replayed request, with the results of validating the request and
+// the response against the contract.
+type ReplayResult struct {
+	StatusCode         int
+	Header             http.Header
+	Body               []byte
+	RequestValidation  []*errors.ValidationError
+	ResponseValidation []*errors.ValidationError
+	Error              error
+}
+
+// ReplayRequest sends a request to the API the same way a proxied request is sent, with the header rules and path
+// rewrites of the configuration. The request and response are validated synchronously and stored as a transaction,
+// and the response is returned instead of being written to a client.
+func (ws *WiretapService) ReplayRequest(request *model.Request) *ReplayResult {
+	configStore, _ := ws.controlsStore.Get(shared.ConfigKey)
+	config := configStore.(*shared.WiretapConfiguration)
+
+	newReq, apiRequest := ws.cloneAPIRequests(config, request)
+	if newReq == nil || apiRequest == nil {
+		return &ReplayResult{Error: fmt.Errorf("unable to clone request '%s'", request.HttpRequest.URL.String())}
+	}
+
+	result := &ReplayResult{RequestValidation: ws.ValidateRequest(request, newReq)}
+
+	response, err := ws.callAPI(apiRequest)
+	if err != nil {
+		go ws.broadcastResponseError(request, nil, err)
+		result.Error = err
+		return result
+	}
+	defer response.Body.Close()
+
+	result.ResponseValidation = ws.ValidateResponse(request, CloneExistingResponse(response))
+	result.StatusCode = response.StatusCode
+	result.Header = response.Header
+	result.Body, result.Error = io.ReadAll(response.Body)
+	return result
+}
@@ -0,0 +1,56 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: AGPL
+
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+)
+
+// ObservedTransaction is a request proxied to the API, along with the response the API returned.
+type ObservedTransaction struct {
+	Request        *http.Request
+	RequestBody    []byte
+	StatusCode     int
+	ResponseHeader http.Header
+	ResponseBody   []byte
+}
+
+// TrafficObserver is notified of every transaction proxied to the API.
+type TrafficObserver interface {
+	ObserveTransaction(transaction *ObservedTransaction)
+}
+
+// AddTrafficObserver registers an observer that is notified of every transaction proxied to the API.
+func (ws *WiretapService) AddTrafficObserver(observer TrafficObserver) {
+	ws.trafficObservers = append(ws.trafficObservers, observer)
+}
+
+// observeTraffic notifies every observer of a proxied transaction. The request is copied, so observers can use it
+// after the request has completed.
+func (ws *WiretapService) observeTraffic(request *http.Request, response *http.Response, responseBody []byte) {
+	if len(ws.trafficObservers) == 0 {
+		return
+	}
+	var requestBody []byte
+	if request.Body != nil && request.Body != http.NoBody {
+		requestBody, _ = io.ReadAll(request.Body)
+		_ = request.Body.Close()
+		request.Body = io.NopCloser(bytes.NewBuffer(requestBody))
+	}
+	transaction := &ObservedTransaction{
+		Request:        request.Clone(context.Background()),
+		RequestBody:    requestBody,
+		StatusCode:     response.StatusCode,
+		ResponseHeader: response.Header.Clone(),
+		ResponseBody:   responseBody,
+	}
+	go func() {
+		for _, observer := range ws.trafficObservers {
+			observer.ObserveTransaction(transaction)
+		}
+	}()
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: AGPL
+
+package daemon
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/pb33f/ranch/model"
+)
+
+// storeTransaction merges a request or response transaction into the transaction held in the store under the same
+// id, so a stored transaction carries both the request and the response, whichever one is stored first.
+func (ws *WiretapService) storeTransaction(transaction *HttpTransaction) {
+	ws.transactionLock.Lock()
+	defer ws.transactionLock.Unlock()
+
+	if existing, ok := ws.transactionStore.Get(transaction.Id); ok {
+		if stored, isTransaction := existing.(*HttpTransaction); isTransaction {
+			merged := *stored
+			if transaction.Request != nil {
+				merged.Request = transaction.Request
+				merged.RequestValidation = transaction.RequestValidation
+				merged.RequestNotices = transaction.RequestNotices
+			}
+			if transaction.Response != nil {
+				merged.Response = transaction.Response
+				merged.ResponseValidation = transaction.ResponseValidation
+				merged.ResponseNotices = transaction.ResponseNotices
+			}
+			transaction = &merged
+		}
+	}
+	ws.transactionStore.Put(transaction.Id, transaction, nil)
+}
+
+// storeResponse stores a response that was not validated, such as a mocked response.
+func (ws *WiretapService) storeResponse(request *model.Request, response *http.Response) {
+	if request.Id == nil || response == nil {
+		return
+	}
+	ws.storeTransaction(BuildResponse(request, response))
+}
+
+// Transactions returns every transaction in the transaction store, ordered by the time of the request.
+func (ws *WiretapService) Transactions() []*HttpTransaction {
+	return SortedTransactions(ws.transactionStore.AllValues())
+}
+
+// SortedTransactions picks the transactions out of the values of a transaction store, ordered by the time of the
+// request. Transactions without a request are ordered by the time of the response.
+func SortedTransactions(values []any) []*HttpTransaction {
+	var transactions []*HttpTransaction
+	for _, value := range values {
+		if t, ok := value.(*HttpTransaction); ok {
+			transactions = append(transactions, t)
+		}
+	}
+	timestamp := func(t *HttpTransaction) int64 {
+		if t.Request != nil {
+			return t.Request.Timestamp
+		}
+		if t.Response != nil {
+			return t.Response.Timestamp
+		}
+		return 0
+	}
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return timestamp(transactions[i]) < timestamp(transactions[j])
+	})
+	return transactions
+}
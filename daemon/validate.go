@@ -6,6 +6,7 @@ package daemon
 import (
 	"github.com/pb33f/libopenapi-validator/errors"
 	"github.com/pb33f/ranch/model"
+	configModel "github.com/pb33f/wiretap/config"
 	"net/http"
 )
 
@@ -27,15 +28,23 @@ func (ws *WiretapService) ValidateResponse(
 		}
 	}
 
+	// violations with a lower severity are kept as notices, and do not fail validation.
+	method, path := request.HttpRequest.Method, request.HttpRequest.URL.Path
+	cleanedErrors, notices := configModel.ClassifyViolations(cleanedErrors, method, path, ws.config)
+	validationErrors, _ = configModel.ClassifyViolations(validationErrors, method, path, ws.config)
+
 	transaction := BuildResponse(request, returnedResponse)
 	if len(cleanedErrors) > 0 {
 		transaction.ResponseValidation = cleanedErrors
 	}
-	ws.transactionStore.Put(request.Id.String(), transaction, nil)
+	transaction.ResponseNotices = notices
+	ws.storeTransaction(transaction)
 
 	if len(cleanedErrors) > 0 {
 		ws.streamChan <- cleanedErrors
-		ws.broadcastResponseValidationErrors(request, returnedResponse, cleanedErrors)
+	}
+	if len(cleanedErrors) > 0 || len(notices) > 0 {
+		ws.broadcastResponseValidationErrors(request, returnedResponse, cleanedErrors, notices)
 	} else {
 		ws.broadcastResponse(request, returnedResponse)
 	}
@@ -46,16 +55,17 @@ func (ws *WiretapService) ValidateRequest(
 	modelRequest *model.Request,
 	httpRequest *http.Request) []*errors.ValidationError {
 
-	var validationErrors, cleanedErrors []*errors.ValidationError
+	var validationErrors []*errors.ValidationError
 
 	if ws.document != nil && ws.docModel != nil {
 		validator := ws.validator
 		_, validationErrors = validator.ValidateHttpRequest(httpRequest)
 	}
 
-	for _, validationError := range validationErrors {
-		cleanedErrors = append(cleanedErrors, validationError)
-	}
+	// violations with a lower severity are kept as notices, and do not fail validation.
+	cleanedErrors, notices := configModel.ClassifyViolations(validationErrors, httpRequest.Method,
+		httpRequest.URL.Path, ws.config)
+
 	// record results
 	buildTransConfig := HttpTransactionConfig{
 		OriginalRequest:   modelRequest.HttpRequest,
@@ -68,7 +78,8 @@ func (ws *WiretapService) ValidateRequest(
 	if len(cleanedErrors) > 0 {
 		transaction.RequestValidation = cleanedErrors
 	}
-	ws.transactionStore.Put(modelRequest.Id.String(), modelRequest, nil)
+	transaction.RequestNotices = notices
+	ws.storeTransaction(transaction)
 
 	// broadcast what we found.
 	if len(cleanedErrors) > 0 {
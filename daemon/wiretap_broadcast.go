@@ -82,11 +82,13 @@ func (ws *WiretapService) broadcastResponseError(request *model.Request, respons
 	})
 }
 
-func (ws *WiretapService) broadcastResponseValidationErrors(request *model.Request, response *http.Response, errors []*errors.ValidationError) {
+func (ws *WiretapService) broadcastResponseValidationErrors(request *model.Request, response *http.Response,
+	errors []*errors.ValidationError, notices []*shared.Notice) {
 	id, _ := uuid.NewUUID()
 
 	ht := BuildResponse(request, response)
 	ht.ResponseValidation = errors
+	ht.ResponseNotices = notices
 
 	ws.broadcastChan.Send(&model.Message{
 		Id:            &id,
@@ -4,7 +4,9 @@
 package daemon
 
 import (
+	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/pb33f/libopenapi"
@@ -43,10 +45,17 @@ type WiretapService struct {
 	streamChan       chan []*errors.ValidationError
 	streamViolations []*errors.ValidationError
 	reportFile       string
+	trafficObservers []TrafficObserver
+	transactionLock  sync.Mutex
 	StaticMockDir    string
+	StaticMockStrict bool
+	// StaticMockRecord is set while record mode writes proxied traffic into the static mock directory.
+	StaticMockRecord bool
 }
 
-func NewWiretapService(document libopenapi.Document, config *shared.WiretapConfiguration) *WiretapService {
+// NewWiretapService creates the wiretap service. It fails if the mock JWKS cannot be loaded, as JWT signatures would
+// not be verified.
+func NewWiretapService(document libopenapi.Document, config *shared.WiretapConfiguration) (*WiretapService, error) {
 	storeManager := bus.GetBus().GetStoreManager()
 	controlsStore := storeManager.CreateStore(controls.ControlServiceChan)
 	transactionStore := storeManager.CreateStore(WiretapServiceChan)
@@ -64,6 +73,8 @@ func NewWiretapService(document libopenapi.Document, config *shared.WiretapConfi
 		controlsStore:    controlsStore,
 		transactionStore: transactionStore,
 		StaticMockDir:    config.StaticMockDir,
+		StaticMockStrict: config.StaticMockStrict,
+		StaticMockRecord: config.StaticMockRecord != nil && config.StaticMockRecord.Enabled,
 	}
 	if document != nil {
 		m, _ := document.BuildV3Model()
@@ -79,13 +90,26 @@ func NewWiretapService(document libopenapi.Document, config *shared.WiretapConfi
 	wts.mockEngine = mock.NewMockEngine(wts.docModel, config.MockModePretty,
 		config.UseAllMockResponseFields)
 
+	// echo request values into mocked responses.
+	wts.mockEngine.SetEcho(config.MockEcho)
+
+	// size the collection paginated mocks page through.
+	wts.mockEngine.SetCollectionSize(config.MockCollectionSize)
+
+	// verify JWT signatures in mock mode, if a key set has been supplied.
+	if config.MockJWKS != "" {
+		if err := wts.mockEngine.LoadJWKS(config.MockJWKS); err != nil {
+			return nil, fmt.Errorf("unable to load mock JWKS '%s': %w", config.MockJWKS, err)
+		}
+	}
+
 	// hard-wire the config, change this later if needed.
 	wts.config = config
 
 	// listen for violations
 	wts.listenForValidationErrors()
 
-	return wts
+	return wts, nil
 
 }
 
@@ -102,10 +126,24 @@ func (ws *WiretapService) HandleHttpRequest(request *model.Request) {
 	ws.handleHttpRequest(request)
 }
 
+// ResponseModifier changes the response returned by the API, before it is validated and returned to the client.
+type ResponseModifier func(response *http.Response) (*http.Response, error)
+
+// HandleHttpRequestWithModifier proxies a request to the API like HandleHttpRequest, and passes the response
+// through the modifier before it is used.
+func (ws *WiretapService) HandleHttpRequestWithModifier(request *model.Request, modifier ResponseModifier) {
+	ws.handleHttpRequestWithModifier(request, modifier)
+}
+
 func (ws *WiretapService) HandleStaticMockResponse(request *model.Request, response *http.Response) {
 	ws.handleStaticMockResponse(request, response)
 }
 
+// GetDocumentModel returns the OpenAPI model of the contract, or nil if no contract has been loaded.
+func (ws *WiretapService) GetDocumentModel() *v3.Document {
+	return ws.docModel
+}
+
 func (ws *WiretapService) HandleWebsocketRequest(request *model.Request) {
 	ws.handleWebsocketRequest(request)
 }
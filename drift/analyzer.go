@@ -0,0 +1,562 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+// Package drift compares observed traffic with the contract, and suggests the contract updates that would make the
+// traffic compliant, for when the contract is wrong rather than the API.
+package drift
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pb33f/harhar"
+	"github.com/pb33f/wiretap/daemon"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	UndocumentedProperty  = "undocumented-property"
+	UndocumentedStatus    = "undocumented-status"
+	UndocumentedEnumValue = "undocumented-enum-value"
+	NullableValue         = "nullable-value"
+)
+
+// maxSamples is the most values kept for a finding, to infer the schema of what was observed.
+const maxSamples = 20
+
+// Finding is a difference between the contract and observed traffic, aggregated over every observation of it.
+type Finding struct {
+	Kind string `json:"kind"`
+	// Operations are the operations the difference was observed for, such as `GET /pets/{id}`. A schema can be
+	// shared by several operations.
+	Operations []string `json:"operations"`
+	// Pointer is the JSON pointer, into the contract, of the schema or operation that needs to change.
+	Pointer string `json:"pointer"`
+	// Name is the undocumented property or status code.
+	Name string `json:"name,omitempty"`
+	// Values are the undocumented enum values observed.
+	Values  []any  `json:"values,omitempty"`
+	Count   int    `json:"count"`
+	Message string `json:"message"`
+
+	samples   []any
+	mediaType string
+}
+
+// Analyzer compares observed traffic with a contract, and aggregates the differences. It is safe for concurrent
+// use, and can be registered as a traffic observer of the wiretap service.
+type Analyzer struct {
+	spec      any
+	openAPI31 bool
+	basePaths []string
+	// externalRefs are the references to other files, drift is not detected for the schemas they point to.
+	externalRefs []string
+
+	lock     sync.Mutex
+	findings map[string]*Finding
+	order    []string
+}
+
+// NewAnalyzer creates an analyzer for a contract, in JSON or YAML.
+func NewAnalyzer(spec []byte) (*Analyzer, error) {
+	var document any
+	if err := yaml.Unmarshal(spec, &document); err != nil {
+		return nil, fmt.Errorf("unable to read the contract: %w", err)
+	}
+	document = normalize(document)
+	root, ok := document.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("the contract is not an object")
+	}
+	version, _ := root["openapi"].(string)
+	analyzer := &Analyzer{
+		spec:      document,
+		openAPI31: strings.HasPrefix(version, "3.1"),
+		findings:  make(map[string]*Finding),
+	}
+	analyzer.externalRefs = externalReferences(document, nil)
+	servers, _ := root["servers"].([]any)
+	for _, s := range servers {
+		server, _ := s.(map[string]any)
+		serverURL, _ := server["url"].(string)
+		if u, err := url.Parse(serverURL); err == nil && u.Path != "" && u.Path != "/" {
+			analyzer.basePaths = append(analyzer.basePaths, strings.TrimSuffix(u.Path, "/"))
+		}
+	}
+	return analyzer, nil
+}
+
+// externalReferences returns every reference ($ref) to another file or URL, in the order they are found.
+func externalReferences(node any, refs []string) []string {
+	switch n := node.(type) {
+	case map[string]any:
+		if ref, ok := n["$ref"].(string); ok && !strings.HasPrefix(ref, "#") && !slices.Contains(refs, ref) {
+			refs = append(refs, ref)
+		}
+		keys := make([]string, 0, len(n))
+		for key := range n {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			refs = externalReferences(n[key], refs)
+		}
+	case []any:
+		for _, item := range n {
+			refs = externalReferences(item, refs)
+		}
+	}
+	return refs
+}
+
+// ExternalReferences returns the references of the contract to other files or URLs. Only local references are
+// followed, so drift is not detected for the schemas they point to.
+func (a *Analyzer) ExternalReferences() []string {
+	return a.externalRefs
+}
+
+// normalize converts decoded YAML into decoded JSON, YAML maps can have keys that are not strings (such as status
+// codes).
+func normalize(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = normalize(item)
+		}
+		return v
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = normalize(item)
+		}
+		return m
+	case []any:
+		for i, item := range v {
+			v[i] = normalize(item)
+		}
+		return v
+	case int:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	}
+	return value
+}
+
+// ObserveTransaction compares a transaction proxied to the API with the contract.
+func (a *Analyzer) ObserveTransaction(transaction *daemon.ObservedTransaction) {
+	a.Observe(transaction.Request.Method, transaction.Request.URL.Path, transaction.Request.Header.Get("Content-Type"),
+		transaction.RequestBody, transaction.StatusCode, transaction.ResponseHeader.Get("Content-Type"),
+		transaction.ResponseBody)
+}
+
+// ObserveHAR compares every entry of a HAR document with the contract.
+func (a *Analyzer) ObserveHAR(harFile *harhar.HAR) {
+	for _, entry := range harFile.Log.Entries {
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil {
+			continue
+		}
+		responseBody := []byte(entry.Response.Body.Content)
+		if strings.EqualFold(entry.Response.Body.Encoding, "base64") {
+			if responseBody, err = base64.StdEncoding.DecodeString(entry.Response.Body.Content); err != nil {
+				continue
+			}
+		}
+		a.Observe(entry.Request.Method, u.Path, entry.Request.Body.MIMEType, []byte(entry.Request.Body.Content),
+			entry.Response.StatusCode, entry.Response.Body.MIMEType, responseBody)
+	}
+}
+
+// Observe compares a request and its response with the contract. Traffic for paths the contract does not describe
+// is ignored.
+func (a *Analyzer) Observe(method, path, requestContentType string, requestBody []byte, status int,
+	responseContentType string, responseBody []byte) {
+
+	specPath, operation := a.findOperation(strings.ToLower(method), path)
+	if operation == nil {
+		return
+	}
+	opPointer := "/paths/" + pointerToken(specPath) + "/" + strings.ToLower(method)
+	opName := strings.ToUpper(method) + " " + specPath
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if body, ok := decodeJSON(requestContentType, requestBody); ok {
+		requestBodyNode, pointer := a.resolve(operation["requestBody"], opPointer+"/requestBody")
+		if schema, schemaPointer := a.mediaSchema(requestBodyNode, pointer, requestContentType); schema != nil {
+			a.compare(opName, body, schema, schemaPointer)
+		}
+	}
+
+	responses, _ := operation["responses"].(map[string]any)
+	if responses == nil || status == 0 {
+		return
+	}
+	code := strconv.Itoa(status)
+	var response any
+	var responsePointer string
+	for _, key := range []string{code, code[:1] + "XX", code[:1] + "xx", "default"} {
+		if r, found := responses[key]; found {
+			response, responsePointer = a.resolve(r, opPointer+"/responses/"+key)
+			break
+		}
+	}
+	if response == nil {
+		finding := a.finding(UndocumentedStatus, opPointer, code, opName,
+			fmt.Sprintf("status code %s is not documented for %s", code, opName))
+		if body, ok := decodeJSON(responseContentType, responseBody); ok {
+			finding.sample(body)
+			if finding.mediaType == "" {
+				finding.mediaType = mediaType(responseContentType)
+			}
+		}
+		return
+	}
+	if body, ok := decodeJSON(responseContentType, responseBody); ok {
+		if schema, schemaPointer := a.mediaSchema(response, responsePointer, responseContentType); schema != nil {
+			a.compare(opName, body, schema, schemaPointer)
+		}
+	}
+}
+
+// findOperation finds the operation of the contract for a method and path. Literal path segments are preferred
+// over path parameters, and server base paths are stripped.
+func (a *Analyzer) findOperation(method, path string) (string, map[string]any) {
+	root, _ := a.spec.(map[string]any)
+	paths, _ := root["paths"].(map[string]any)
+	candidates := []string{path}
+	for _, base := range a.basePaths {
+		if strings.HasPrefix(path, base+"/") {
+			candidates = append(candidates, strings.TrimPrefix(path, base))
+		}
+	}
+
+	bestParams := -1
+	var bestPath string
+	var best map[string]any
+	for _, candidate := range candidates {
+		segments := strings.Split(strings.Trim(candidate, "/"), "/")
+		for template, item := range paths {
+			params, ok := matchTemplate(strings.Split(strings.Trim(template, "/"), "/"), segments)
+			if !ok {
+				continue
+			}
+			resolved, _ := a.resolve(item, "")
+			pathItem, _ := resolved.(map[string]any)
+			operation, _ := pathItem[method].(map[string]any)
+			if operation == nil {
+				continue
+			}
+			if bestParams == -1 || params < bestParams || params == bestParams && template < bestPath {
+				bestParams, bestPath, best = params, template, operation
+			}
+		}
+	}
+	return bestPath, best
+}
+
+// matchTemplate matches path segments with the segments of a path template, it returns the number of parameters.
+func matchTemplate(template, segments []string) (int, bool) {
+	if len(template) != len(segments) {
+		return 0, false
+	}
+	params := 0
+	for i, t := range template {
+		if strings.HasPrefix(t, "{") && strings.HasSuffix(t, "}") {
+			if segments[i] == "" {
+				return 0, false
+			}
+			params++
+			continue
+		}
+		if t != segments[i] {
+			return 0, false
+		}
+	}
+	return params, true
+}
+
+// mediaSchema returns the schema of the media type of a request body or response, and its pointer.
+func (a *Analyzer) mediaSchema(node any, pointer, contentType string) (map[string]any, string) {
+	object, _ := node.(map[string]any)
+	content, _ := object["content"].(map[string]any)
+	if content == nil {
+		return nil, ""
+	}
+	mt := mediaType(contentType)
+	key := ""
+	if _, found := content[mt]; found {
+		key = mt
+	} else {
+		for candidate := range content {
+			if strings.Contains(candidate, "json") && (key == "" || candidate < key) {
+				key = candidate
+			}
+		}
+	}
+	if key == "" {
+		return nil, ""
+	}
+	media, _ := content[key].(map[string]any)
+	if media == nil || media["schema"] == nil {
+		return nil, ""
+	}
+	schema, schemaPointer := a.resolve(media["schema"], pointer+"/content/"+pointerToken(key)+"/schema")
+	s, _ := schema.(map[string]any)
+	return s, schemaPointer
+}
+
+// compare walks an observed value and its schema, and records every difference.
+func (a *Analyzer) compare(operation string, value any, schema map[string]any, pointer string) {
+	if value == nil {
+		if schema["type"] != nil && !a.allowsNull(schema) {
+			a.finding(NullableValue, pointer, "", operation,
+				fmt.Sprintf("'%s' was null, the schema does not allow null", pointer))
+		}
+		return
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && !containsValue(enum, value) {
+		switch value.(type) {
+		case string, float64, bool:
+			finding := a.finding(UndocumentedEnumValue, pointer, "", operation,
+				fmt.Sprintf("'%s' had values that are not in its enum", pointer))
+			if !containsValue(finding.Values, value) {
+				finding.Values = append(finding.Values, value)
+			}
+		}
+	}
+
+	// properties and items of composed schemas are found in the schemas they are composed of.
+	members := []struct {
+		schema  map[string]any
+		pointer string
+	}{{schema, pointer}}
+	composed := false
+	for _, keyword := range []string{"allOf", "oneOf", "anyOf"} {
+		list, _ := schema[keyword].([]any)
+		for i, item := range list {
+			resolved, p := a.resolve(item, fmt.Sprintf("%s/%s/%d", pointer, keyword, i))
+			if m, ok := resolved.(map[string]any); ok {
+				members = append(members, struct {
+					schema  map[string]any
+					pointer string
+				}{m, p})
+				composed = true
+			}
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for key, property := range v {
+			found := false
+			for _, member := range members {
+				properties, _ := member.schema["properties"].(map[string]any)
+				if propertySchema, ok := properties[key]; ok {
+					resolved, p := a.resolve(propertySchema, member.pointer+"/properties/"+pointerToken(key))
+					if m, isMap := resolved.(map[string]any); isMap {
+						a.compare(operation, property, m, p)
+					}
+					found = true
+					break
+				}
+				if additional, ok := member.schema["additionalProperties"].(map[string]any); ok {
+					resolved, p := a.resolve(additional, member.pointer+"/additionalProperties")
+					if m, isMap := resolved.(map[string]any); isMap {
+						a.compare(operation, property, m, p)
+					}
+					found = true
+					break
+				}
+			}
+			// undocumented properties of composed schemas are ambiguous, there is no single schema to add them to.
+			if !found && !composed && describesObject(schema) {
+				finding := a.finding(UndocumentedProperty, pointer, key, operation,
+					fmt.Sprintf("property '%s' is not documented by '%s'", key, pointer))
+				finding.sample(property)
+			}
+		}
+	case []any:
+		for _, member := range members {
+			if items, ok := member.schema["items"].(map[string]any); ok {
+				resolved, p := a.resolve(items, member.pointer+"/items")
+				if m, isMap := resolved.(map[string]any); isMap {
+					for _, item := range v {
+						a.compare(operation, item, m, p)
+					}
+				}
+				break
+			}
+		}
+	}
+}
+
+func describesObject(schema map[string]any) bool {
+	if _, ok := schema["properties"]; ok {
+		return true
+	}
+	return schema["type"] == "object"
+}
+
+// allowsNull checks the type of a schema, OpenAPI 3.1 lists null as a type, OpenAPI 3.0 uses nullable.
+func (a *Analyzer) allowsNull(schema map[string]any) bool {
+	if nullable, _ := schema["nullable"].(bool); nullable {
+		return true
+	}
+	switch t := schema["type"].(type) {
+	case string:
+		return t == "null"
+	case []any:
+		return containsValue(t, "null")
+	}
+	return false
+}
+
+// finding returns the finding of a kind for a pointer and name, creating it on first observation. Every call
+// counts as an observation.
+func (a *Analyzer) finding(kind, pointer, name, operation, message string) *Finding {
+	key := kind + "\x00" + pointer + "\x00" + name
+	finding, found := a.findings[key]
+	if !found {
+		finding = &Finding{Kind: kind, Pointer: pointer, Name: name, Message: message}
+		a.findings[key] = finding
+		a.order = append(a.order, key)
+	}
+	finding.Count++
+	if !slices.Contains(finding.Operations, operation) {
+		finding.Operations = append(finding.Operations, operation)
+		sort.Strings(finding.Operations)
+	}
+	return finding
+}
+
+func (f *Finding) sample(value any) {
+	if len(f.samples) < maxSamples {
+		f.samples = append(f.samples, value)
+	}
+}
+
+// Findings returns a copy of the findings observed at least the minimum number of times, in the order they were
+// first observed.
+func (a *Analyzer) Findings(minOccurrences int) []*Finding {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	var findings []*Finding
+	for _, key := range a.order {
+		if finding := a.findings[key]; finding.Count >= minOccurrences {
+			findings = append(findings, finding.copy())
+		}
+	}
+	return findings
+}
+
+func (f *Finding) copy() *Finding {
+	c := *f
+	c.Operations = slices.Clone(f.Operations)
+	c.Values = slices.Clone(f.Values)
+	c.samples = slices.Clone(f.samples)
+	return &c
+}
+
+// resolve follows local references ($ref) to the node they point to, it returns the node and its pointer. References
+// to other files are not followed, see ExternalReferences.
+func (a *Analyzer) resolve(node any, pointer string) (any, string) {
+	for i := 0; i < 32; i++ {
+		object, ok := node.(map[string]any)
+		if !ok {
+			return node, pointer
+		}
+		ref, ok := object["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#/") {
+			return node, pointer
+		}
+		pointer = ref[1:]
+		node = a.lookup(pointer)
+	}
+	return node, pointer
+}
+
+// lookup returns the node of the contract a JSON pointer points to, or nil.
+func (a *Analyzer) lookup(pointer string) any {
+	node := a.spec
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		if unescaped, err := url.PathUnescape(token); err == nil {
+			token = unescaped
+		}
+		switch n := node.(type) {
+		case map[string]any:
+			node = n[token]
+		case []any:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(n) {
+				return nil
+			}
+			node = n[index]
+		default:
+			return nil
+		}
+	}
+	return node
+}
+
+func pointerToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// decodeJSON decodes a JSON body, bodies of other media types are not compared.
+func decodeJSON(contentType string, body []byte) (any, bool) {
+	if len(body) == 0 {
+		return nil, false
+	}
+	mt := mediaType(contentType)
+	if mt != "" && mt != "application/json" && !strings.HasSuffix(mt, "+json") {
+		return nil, false
+	}
+	var value any
+	if json.Unmarshal(body, &value) != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+func mediaType(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	}
+	return mt
+}
+
+func containsValue(list []any, value any) bool {
+	for _, item := range list {
+		if reflect.DeepEqual(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// statusDescription describes an undocumented status code.
+func statusDescription(code string) string {
+	status, _ := strconv.Atoi(code)
+	if text := http.StatusText(status); text != "" {
+		return text
+	}
+	return "status " + code
+}
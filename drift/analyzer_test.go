@@ -0,0 +1,210 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package drift
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/wiretap/capture"
+	"github.com/pb33f/wiretap/har"
+	"github.com/pb33f/wiretap/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const petContract = `openapi: 3.1.0
+info:
+  title: pets
+  version: 1.0.0
+servers:
+  - url: https://api.pets.com/v1
+paths:
+  /pets/{id}:
+    get:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        200:
+          description: a pet
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+components:
+  schemas:
+    Pet:
+      type: object
+      additionalProperties: false
+      required: [name]
+      properties:
+        name:
+          type: string
+        status:
+          type: string
+          enum: [available, sold]
+        tag:
+          type: string`
+
+const driftTraffic = `{"request":{"method":"GET","url":"https://api.pets.com/v1/pets/1"},"response":{"status":200,"headers":{"Content-Type":"application/json"},"body":{"name":"fido","status":"pending","tag":null,"age":3}}}
+{"request":{"method":"GET","url":"https://api.pets.com/v1/pets/2"},"response":{"status":200,"headers":{"Content-Type":"application/json"},"body":{"name":"rex","status":"sold","age":4.5}}}
+{"request":{"method":"GET","url":"https://api.pets.com/v1/pets/3"},"response":{"status":404,"headers":{"Content-Type":"application/problem+json"},"body":{"title":"not found"}}}
+`
+
+// undocumentedPath is traffic for a path the contract does not describe, it is not drift.
+const undocumentedPath = `{"request":{"method":"GET","url":"https://api.pets.com/v1/owners/3"},"response":{"status":200,"body":{}}}`
+
+func validateTraffic(t *testing.T, spec []byte, traffic string) *har.ValidationReport {
+	document, err := libopenapi.NewDocument(spec)
+	require.NoError(t, err)
+	model, errs := document.BuildV3Model()
+	require.Empty(t, errs)
+	harFile, err := capture.FromNDJSON([]byte(traffic))
+	require.NoError(t, err)
+	report, err := har.ValidateHAR(harFile, &model.Model, &shared.WiretapConfiguration{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError})),
+	})
+	require.NoError(t, err)
+	return report
+}
+
+func TestAnalyzer_Findings(t *testing.T) {
+	analyzer, err := NewAnalyzer([]byte(petContract))
+	require.NoError(t, err)
+	harFile, err := capture.FromNDJSON([]byte(driftTraffic + undocumentedPath))
+	require.NoError(t, err)
+	analyzer.ObserveHAR(harFile)
+
+	findings := analyzer.Findings(1)
+	require.Len(t, findings, 4)
+	byKind := make(map[string]*Finding)
+	for _, f := range findings {
+		byKind[f.Kind] = f
+	}
+
+	enum := byKind[UndocumentedEnumValue]
+	assert.Equal(t, "/components/schemas/Pet/properties/status", enum.Pointer)
+	assert.Equal(t, []any{"pending"}, enum.Values)
+	assert.Equal(t, "/components/schemas/Pet/properties/tag", byKind[NullableValue].Pointer)
+
+	property := byKind[UndocumentedProperty]
+	assert.Equal(t, "age", property.Name)
+	assert.Equal(t, 2, property.Count)
+	assert.Equal(t, []string{"GET /pets/{id}"}, property.Operations)
+
+	status := byKind[UndocumentedStatus]
+	assert.Equal(t, "/paths/~1pets~1{id}/get", status.Pointer)
+	assert.Equal(t, "404", status.Name)
+
+	// only repeated differences are suggested.
+	repeated := analyzer.Findings(2)
+	require.Len(t, repeated, 1)
+	assert.Equal(t, UndocumentedProperty, repeated[0].Kind)
+
+	// findings are copies, later observations do not change them.
+	analyzer.ObserveHAR(harFile)
+	assert.Equal(t, 2, property.Count)
+	for _, f := range analyzer.Findings(1) {
+		if f.Kind == UndocumentedProperty {
+			assert.Equal(t, 4, f.Count)
+		}
+	}
+	assert.Empty(t, analyzer.ExternalReferences())
+}
+
+func TestAnalyzer_ExternalReferences(t *testing.T) {
+	analyzer, err := NewAnalyzer([]byte(`openapi: 3.1.0
+paths:
+  /pets:
+    get:
+      responses:
+        200:
+          content:
+            application/json:
+              schema:
+                $ref: 'schemas/pet.yaml'
+        404:
+          $ref: '#/components/responses/NotFound'
+        500:
+          $ref: 'https://api.pets.com/errors.yaml#/Error'`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"schemas/pet.yaml", "https://api.pets.com/errors.yaml#/Error"},
+		analyzer.ExternalReferences())
+}
+
+func TestAnalyzer_Patch(t *testing.T) {
+	assert.Equal(t, 3, validateTraffic(t, []byte(petContract), driftTraffic).Invalid)
+
+	analyzer, err := NewAnalyzer([]byte(petContract))
+	require.NoError(t, err)
+	harFile, err := capture.FromNDJSON([]byte(driftTraffic))
+	require.NoError(t, err)
+	analyzer.ObserveHAR(harFile)
+
+	patch := analyzer.Patch(analyzer.Findings(1))
+	require.Len(t, patch, 4)
+	patched, err := shared.ApplyJSONPatch(analyzer.spec, patch)
+	require.NoError(t, err)
+	spec, err := json.Marshal(patched)
+	require.NoError(t, err)
+
+	// the patched contract describes the traffic.
+	report := validateTraffic(t, spec, driftTraffic)
+	assert.Equal(t, 3, report.Valid)
+	assert.Zero(t, report.Invalid)
+}
+
+func TestAnalyzer_Overlay(t *testing.T) {
+	contract := `openapi: 3.0.3
+info:
+  title: pets
+  version: 1.0.0
+paths:
+  /pets/{id}:
+    get:
+      responses:
+        '200':
+          description: a pet
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  name:
+                    type: string
+                  status:
+                    type: string
+                    enum: [available]`
+	analyzer, err := NewAnalyzer([]byte(contract))
+	require.NoError(t, err)
+	analyzer.Observe("GET", "/pets/1", "", nil, 200, "application/json",
+		[]byte(`{"name":null,"status":"sold","owner":{"name":"sam","phone":null}}`))
+
+	overlay := analyzer.Overlay(analyzer.Findings(1))
+	require.Len(t, overlay.Actions, 3)
+	targets := make(map[string]any)
+	for _, action := range overlay.Actions {
+		targets[action.Target] = action.Update
+	}
+	schema := "$.paths['/pets/{id}'].get.responses['200'].content['application/json'].schema"
+	assert.Equal(t, map[string]any{"nullable": true}, targets[schema+".properties.name"])
+	assert.Equal(t, "sold", targets[schema+".properties.status.enum"])
+	assert.Equal(t, map[string]any{"properties": map[string]any{"owner": map[string]any{
+		"type":     "object",
+		"required": []any{"name", "phone"},
+		"properties": map[string]any{
+			"name":  map[string]any{"type": "string"},
+			"phone": map[string]any{"nullable": true},
+		},
+	}}}, targets[schema])
+}
@@ -0,0 +1,75 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package drift
+
+import (
+	"log/slog"
+
+	"github.com/pb33f/ranch/model"
+	"github.com/pb33f/ranch/service"
+	"github.com/pb33f/wiretap/daemon"
+	"github.com/pb33f/wiretap/shared"
+	"github.com/pterm/pterm"
+)
+
+const (
+	DriftServiceChan       = "drift-service"
+	GetDriftReportRequest  = "get-drift-report"
+	DefaultDriftOutputFile = "wiretap-drift.json"
+)
+
+// Report is the contract drift observed so far, and the contract updates suggested for it.
+type Report struct {
+	Findings []*Finding                  `json:"findings"`
+	Patch    []shared.JSONPatchOperation `json:"patch"`
+}
+
+// DriftService compares every transaction proxied to the API with the contract, and writes the suggested contract
+// updates to the output file when wiretap shuts down. The drift observed so far can be requested at any time.
+type DriftService struct {
+	analyzer *Analyzer
+	config   *shared.WiretapDriftConfig
+	logger   *slog.Logger
+}
+
+// NewDriftService creates a drift service for a contract, and registers its analyzer as a traffic observer of the
+// wiretap service.
+func NewDriftService(wiretapService *daemon.WiretapService, spec []byte, config *shared.WiretapDriftConfig,
+	logger *slog.Logger) (*DriftService, error) {
+	analyzer, err := NewAnalyzer(spec)
+	if err != nil {
+		return nil, err
+	}
+	if refs := analyzer.ExternalReferences(); len(refs) > 0 {
+		logger.Warn("contract drift is not detected for schemas referenced from other files", "references", refs)
+	}
+	wiretapService.AddTrafficObserver(analyzer)
+	return &DriftService{analyzer: analyzer, config: config, logger: logger}, nil
+}
+
+func (ds *DriftService) HandleServiceRequest(request *model.Request, core service.FabricServiceCore) {
+	switch request.RequestCommand {
+	case GetDriftReportRequest:
+		findings := ds.analyzer.Findings(ds.config.MinOccurrences)
+		core.SendResponse(request, &Report{Findings: findings, Patch: ds.analyzer.Patch(findings)})
+	default:
+		core.HandleUnknownRequest(request)
+	}
+}
+
+// OnServerShutdown prints the drift observed, and writes the suggested contract updates to the output file.
+func (ds *DriftService) OnServerShutdown() {
+	PrintFindings(ds.analyzer.Findings(ds.config.MinOccurrences))
+	output := ds.config.Output
+	if output == "" {
+		output = DefaultDriftOutputFile
+	}
+	if err := ds.analyzer.WriteSuggestion(output, ds.config.Format, ds.config.MinOccurrences); err != nil {
+		ds.logger.Error("unable to write contract drift suggestion", "file", output, "error", err.Error())
+		return
+	}
+	pterm.Printf("Contract drift suggestion saved to: %s\n", pterm.LightMagenta(output))
+}
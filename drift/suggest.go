@@ -0,0 +1,262 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package drift
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pb33f/wiretap/learn"
+	"github.com/pb33f/wiretap/shared"
+	"github.com/pterm/pterm"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatJSONPatch = "json-patch"
+	FormatOverlay   = "overlay"
+)
+
+// Formats are the formats contract updates can be suggested in.
+var Formats = []string{FormatJSONPatch, FormatOverlay}
+
+var jsonPathName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Overlay is an OpenAPI Overlay (1.0) document.
+type Overlay struct {
+	Overlay string           `json:"overlay" yaml:"overlay"`
+	Info    *OverlayInfo     `json:"info" yaml:"info"`
+	Actions []*OverlayAction `json:"actions" yaml:"actions"`
+}
+
+type OverlayInfo struct {
+	Title   string `json:"title" yaml:"title"`
+	Version string `json:"version" yaml:"version"`
+}
+
+// OverlayAction updates the nodes of the contract the target (a JSONPath expression) selects. Objects are merged
+// with the update, and the update is appended to arrays.
+type OverlayAction struct {
+	Target      string `json:"target" yaml:"target"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Update      any    `json:"update" yaml:"update"`
+}
+
+// Patch suggests the JSON Patch (RFC 6902) operations that would make the contract describe the findings.
+func (a *Analyzer) Patch(findings []*Finding) []shared.JSONPatchOperation {
+	var operations []shared.JSONPatchOperation
+	addedProperties := make(map[string]bool)
+	for _, finding := range findings {
+		schema, _ := a.lookup(finding.Pointer).(map[string]any)
+		switch finding.Kind {
+		case UndocumentedProperty:
+			if _, found := schema["properties"]; !found && !addedProperties[finding.Pointer] {
+				operations = append(operations, shared.JSONPatchOperation{
+					Op: "add", Path: finding.Pointer + "/properties", Value: map[string]any{},
+				})
+				addedProperties[finding.Pointer] = true
+			}
+			operations = append(operations, shared.JSONPatchOperation{
+				Op: "add", Path: finding.Pointer + "/properties/" + pointerToken(finding.Name),
+				Value: a.schemaValue(finding.samples),
+			})
+		case UndocumentedStatus:
+			operations = append(operations, shared.JSONPatchOperation{
+				Op: "add", Path: finding.Pointer + "/responses/" + finding.Name, Value: a.responseValue(finding),
+			})
+		case UndocumentedEnumValue:
+			enum, _ := schema["enum"].([]any)
+			operations = append(operations, shared.JSONPatchOperation{
+				Op: "replace", Path: finding.Pointer + "/enum", Value: append(append([]any{}, enum...), finding.Values...),
+			})
+		case NullableValue:
+			if !a.openAPI31 {
+				operations = append(operations, shared.JSONPatchOperation{
+					Op: "add", Path: finding.Pointer + "/nullable", Value: true,
+				})
+				continue
+			}
+			operations = append(operations, shared.JSONPatchOperation{
+				Op: "replace", Path: finding.Pointer + "/type", Value: nullableType(schema["type"]),
+			})
+		}
+	}
+	return operations
+}
+
+// Overlay suggests the OpenAPI Overlay that would make the contract describe the findings.
+func (a *Analyzer) Overlay(findings []*Finding) *Overlay {
+	overlay := &Overlay{
+		Overlay: "1.0.0",
+		Info:    &OverlayInfo{Title: "wiretap contract drift", Version: "1.0.0"},
+		Actions: []*OverlayAction{},
+	}
+	for _, finding := range findings {
+		description := fmt.Sprintf("%s (observed %d %s)", finding.Message, finding.Count,
+			shared.Pluralize(finding.Count, "time", "times"))
+		schema, _ := a.lookup(finding.Pointer).(map[string]any)
+		switch finding.Kind {
+		case UndocumentedProperty:
+			overlay.Actions = append(overlay.Actions, &OverlayAction{
+				Target: jsonPath(finding.Pointer), Description: description,
+				Update: map[string]any{"properties": map[string]any{finding.Name: a.schemaValue(finding.samples)}},
+			})
+		case UndocumentedStatus:
+			overlay.Actions = append(overlay.Actions, &OverlayAction{
+				Target: jsonPath(finding.Pointer + "/responses"), Description: description,
+				Update: map[string]any{finding.Name: a.responseValue(finding)},
+			})
+		case UndocumentedEnumValue:
+			for _, value := range finding.Values {
+				overlay.Actions = append(overlay.Actions, &OverlayAction{
+					Target: jsonPath(finding.Pointer + "/enum"), Description: description, Update: value,
+				})
+			}
+		case NullableValue:
+			update := map[string]any{"nullable": true}
+			if a.openAPI31 {
+				update = map[string]any{"type": nullableType(schema["type"])}
+			}
+			overlay.Actions = append(overlay.Actions, &OverlayAction{
+				Target: jsonPath(finding.Pointer), Description: description, Update: update,
+			})
+		}
+	}
+	return overlay
+}
+
+// WriteSuggestion writes the suggested contract updates for the findings observed at least the minimum number of
+// times, as JSON when the file has a .json extension and as YAML otherwise.
+func (a *Analyzer) WriteSuggestion(file, format string, minOccurrences int) error {
+	findings := a.Findings(minOccurrences)
+	var suggestion any
+	switch format {
+	case "", FormatJSONPatch:
+		suggestion = a.Patch(findings)
+	case FormatOverlay:
+		suggestion = a.Overlay(findings)
+	default:
+		return fmt.Errorf("unknown drift format '%s', use one of: %s", format, strings.Join(Formats, ", "))
+	}
+	var out []byte
+	var err error
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		out, err = json.MarshalIndent(suggestion, "", "  ")
+	} else {
+		out, err = yaml.Marshal(suggestion)
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, out, 0644)
+}
+
+// PrintFindings prints the findings, with the operations they were observed for.
+func PrintFindings(findings []*Finding) {
+	if len(findings) == 0 {
+		pterm.Success.Println("No contract drift detected")
+		return
+	}
+	pterm.Info.Printf("Detected %d contract %s:\n", len(findings), shared.Pluralize(len(findings), "drift", "drifts"))
+	for _, finding := range findings {
+		pterm.Printf("🧭 %s %s (%d×, %s)\n", pterm.LightCyan(finding.Kind), finding.Message, finding.Count,
+			strings.Join(finding.Operations, ", "))
+	}
+	pterm.Println()
+}
+
+// schemaValue infers the schema of observed values, in the dialect of the contract.
+func (a *Analyzer) schemaValue(samples []any) any {
+	var schema any = map[string]any{}
+	if len(samples) > 0 {
+		out, _ := json.Marshal(learn.InferSchema(samples...))
+		_ = json.Unmarshal(out, &schema)
+	}
+	if !a.openAPI31 {
+		schema = toOpenAPI30(schema)
+	}
+	return schema
+}
+
+func (a *Analyzer) responseValue(finding *Finding) map[string]any {
+	response := map[string]any{"description": statusDescription(finding.Name)}
+	if len(finding.samples) > 0 {
+		mt := finding.mediaType
+		if mt == "" {
+			mt = "application/json"
+		}
+		response["content"] = map[string]any{mt: map[string]any{"schema": a.schemaValue(finding.samples)}}
+	}
+	return response
+}
+
+// toOpenAPI30 rewrites type lists, which OpenAPI 3.0 does not support. A type that can be null is nullable, and a
+// schema with several types has no type.
+func toOpenAPI30(value any) any {
+	schema, ok := value.(map[string]any)
+	if !ok {
+		return value
+	}
+	if schema["type"] == "null" {
+		delete(schema, "type")
+		schema["nullable"] = true
+	}
+	if types, isList := schema["type"].([]any); isList {
+		var remaining []any
+		for _, t := range types {
+			if t == "null" {
+				schema["nullable"] = true
+			} else {
+				remaining = append(remaining, t)
+			}
+		}
+		if len(remaining) == 1 {
+			schema["type"] = remaining[0]
+		} else {
+			delete(schema, "type")
+		}
+	}
+	if properties, isMap := schema["properties"].(map[string]any); isMap {
+		for key, property := range properties {
+			properties[key] = toOpenAPI30(property)
+		}
+	}
+	if items, found := schema["items"]; found {
+		schema["items"] = toOpenAPI30(items)
+	}
+	return schema
+}
+
+// nullableType adds null to the type of a schema.
+func nullableType(t any) []any {
+	switch v := t.(type) {
+	case []any:
+		return append(append([]any{}, v...), "null")
+	case nil:
+		return []any{"null"}
+	default:
+		return []any{v, "null"}
+	}
+}
+
+// jsonPath converts a JSON pointer into a JSONPath expression, for overlay targets.
+func jsonPath(pointer string) string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		if jsonPathName.MatchString(token) {
+			b.WriteString("." + token)
+		} else {
+			b.WriteString("['" + strings.ReplaceAll(token, "'", "\\'") + "']")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,245 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package har
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pb33f/harhar"
+	"github.com/pb33f/libopenapi-validator/errors"
+	"github.com/pb33f/libopenapi-validator/paths"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/wiretap/shared"
+)
+
+const (
+	NewlyFailing = "newly-failing"
+	NewlyPassing = "newly-passing"
+	StillFailing = "still-failing"
+	StillPassing = "still-passing"
+	// NotCompared entries were skipped, or could not be validated, against one of the contracts.
+	NotCompared = "not-compared"
+)
+
+// EntryComparison is the result of validating a single HAR entry against two versions of a contract.
+type EntryComparison struct {
+	Index     int    `json:"index"`
+	Method    string `json:"method"`
+	URL       string `json:"url"`
+	Operation string `json:"operation"`
+	Outcome   string `json:"outcome"`
+	Reason    string `json:"reason,omitempty"`
+	// Violations are the violations of the new contract, Introduced the violations of the new contract the old
+	// contract did not report, and Resolved the violations of the old contract the new contract no longer reports.
+	Violations []*errors.ValidationError `json:"violations,omitempty"`
+	Introduced []*errors.ValidationError `json:"introduced,omitempty"`
+	Resolved   []*errors.ValidationError `json:"resolved,omitempty"`
+}
+
+// OperationComparison counts the outcomes of the entries of an operation. An operation is newly failing when any
+// of its entries is, and newly passing when no entry still fails.
+type OperationComparison struct {
+	Operation    string `json:"operation"`
+	Outcome      string `json:"outcome"`
+	Entries      int    `json:"entries"`
+	NewlyFailing int    `json:"newlyFailing"`
+	NewlyPassing int    `json:"newlyPassing"`
+	StillFailing int    `json:"stillFailing"`
+	StillPassing int    `json:"stillPassing"`
+}
+
+// ComparisonReport is the result of validating a HAR file against an old and a new version of a contract.
+type ComparisonReport struct {
+	OldContract string `json:"oldContract,omitempty"`
+	NewContract string `json:"newContract,omitempty"`
+	// Changes and BreakingChanges are the changes between the contracts, as reported by what-changed.
+	Changes         int                    `json:"changes"`
+	BreakingChanges int                    `json:"breakingChanges"`
+	Operations      []*OperationComparison `json:"operations"`
+	Entries         []*EntryComparison     `json:"entries"`
+	Total           int                    `json:"total"`
+	NewlyFailing    int                    `json:"newlyFailing"`
+	NewlyPassing    int                    `json:"newlyPassing"`
+	StillFailing    int                    `json:"stillFailing"`
+	StillPassing    int                    `json:"stillPassing"`
+	NotCompared     int                    `json:"notCompared"`
+}
+
+// Regressions returns the operations that are newly failing.
+func (r *ComparisonReport) Regressions() []*OperationComparison {
+	var regressions []*OperationComparison
+	for _, op := range r.Operations {
+		if op.Outcome == NewlyFailing {
+			regressions = append(regressions, op)
+		}
+	}
+	return regressions
+}
+
+// WriteComparisonReport writes a comparison report to a file as JSON.
+func WriteComparisonReport(file string, report *ComparisonReport) error {
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, b, 0644)
+}
+
+// CompareContracts validates every entry of a HAR file against an old and a new version of a contract, with the
+// same filters as ValidateHAR, and reports the entries and operations that pass or fail differently. An entry that
+// fails against both contracts is newly failing when the new contract reports a violation the old one did not.
+// Operations are named by the path of the new contract the entry matches, or of the old contract when the new one
+// has no such path.
+func CompareContracts(har *harhar.HAR, oldDoc, newDoc *v3.Document,
+	configFile *shared.WiretapConfiguration) (*ComparisonReport, error) {
+
+	before, err := ValidateHAR(har, oldDoc, configFile)
+	if err != nil {
+		return nil, err
+	}
+	after, err := ValidateHAR(har, newDoc, configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ComparisonReport{Operations: []*OperationComparison{}, Entries: []*EntryComparison{}}
+	operations := make(map[string]*OperationComparison)
+	for i, old := range before.Entries {
+		current := after.Entries[i]
+		result := &EntryComparison{Index: i, Method: old.Method, URL: old.URL}
+		report.Entries = append(report.Entries, result)
+		report.Total++
+
+		if !isValidated(old) || !isValidated(current) {
+			result.Outcome, result.Reason = NotCompared, notComparedReason(old, current)
+			report.NotCompared++
+			continue
+		}
+		result.Operation = operationName(har.Log.Entries[i].Request, current.Path, newDoc, oldDoc)
+		result.Violations = append(current.RequestValidation, current.ResponseValidation...)
+		previous := append(old.RequestValidation, old.ResponseValidation...)
+
+		op := operations[result.Operation]
+		if op == nil {
+			op = &OperationComparison{Operation: result.Operation}
+			operations[result.Operation] = op
+			report.Operations = append(report.Operations, op)
+		}
+		op.Entries++
+
+		switch {
+		case old.Status == EntryValid && current.Status == EntryValid:
+			result.Outcome = StillPassing
+			op.StillPassing++
+			report.StillPassing++
+		case old.Status == EntryValid:
+			result.Outcome = NewlyFailing
+			result.Introduced = result.Violations
+			op.NewlyFailing++
+			report.NewlyFailing++
+		case current.Status == EntryValid:
+			result.Outcome = NewlyPassing
+			result.Resolved = previous
+			op.NewlyPassing++
+			report.NewlyPassing++
+		default:
+			result.Introduced = missingViolations(result.Violations, previous)
+			result.Resolved = missingViolations(previous, result.Violations)
+			if len(result.Introduced) > 0 {
+				result.Outcome = NewlyFailing
+				op.NewlyFailing++
+				report.NewlyFailing++
+			} else {
+				result.Outcome = StillFailing
+				op.StillFailing++
+				report.StillFailing++
+			}
+		}
+	}
+
+	for _, op := range report.Operations {
+		switch {
+		case op.NewlyFailing > 0:
+			op.Outcome = NewlyFailing
+		case op.StillFailing > 0:
+			op.Outcome = StillFailing
+		case op.NewlyPassing > 0:
+			op.Outcome = NewlyPassing
+		default:
+			op.Outcome = StillPassing
+		}
+	}
+	sort.SliceStable(report.Operations, func(i, j int) bool {
+		return report.Operations[i].Operation < report.Operations[j].Operation
+	})
+	return report, nil
+}
+
+// missingViolations returns the violations that are not in the other violations. Schema violations are compared
+// by each keyword that failed, so a violation is missing when any of its failures is.
+func missingViolations(violations, other []*errors.ValidationError) []*errors.ValidationError {
+	known := make(map[string]bool)
+	for _, violation := range other {
+		for _, key := range violationKeys(violation) {
+			known[key] = true
+		}
+	}
+	var missing []*errors.ValidationError
+	for _, violation := range violations {
+		for _, key := range violationKeys(violation) {
+			if !known[key] {
+				missing = append(missing, violation)
+				break
+			}
+		}
+	}
+	return missing
+}
+
+// violationKeys identifies a violation by its type and message, or by the keywords that failed and why, when it
+// is a schema violation.
+func violationKeys(violation *errors.ValidationError) []string {
+	id := violation.ValidationType + "/" + violation.ValidationSubType
+	if len(violation.SchemaValidationErrors) == 0 {
+		return []string{id + " " + violation.Message}
+	}
+	var keys []string
+	for _, failure := range violation.SchemaValidationErrors {
+		keys = append(keys, id+" "+failure.Location+" "+failure.Reason)
+	}
+	return keys
+}
+
+func isValidated(entry *EntryResult) bool {
+	return entry.Status == EntryValid || entry.Status == EntryInvalid
+}
+
+func notComparedReason(old, current *EntryResult) string {
+	if !isValidated(old) {
+		return "old contract: " + old.Reason
+	}
+	return "new contract: " + current.Reason
+}
+
+// operationName names the operation of an entry by its method and the path it matches in the first contract that
+// has one, or by the path of the request.
+func operationName(entry harhar.Request, path string, docs ...*v3.Document) string {
+	method := strings.ToUpper(entry.Method)
+	request, err := harhar.ConvertRequestIntoHttpRequest(entry)
+	if err != nil {
+		return method + " " + path
+	}
+	request.URL.Path = path
+	for _, doc := range docs {
+		if _, errs, template := paths.FindPath(request, doc); len(errs) == 0 && template != "" {
+			return method + " " + template
+		}
+	}
+	return method + " " + path
+}
@@ -0,0 +1,69 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package har
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pb33f/harhar"
+	"github.com/pb33f/libopenapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareContracts(t *testing.T) {
+	// the new contract requires an age instead of a name, and adds owners.
+	newSpec := strings.Replace(petSpec, "required: [name]", "required: [age]", 1) + `
+                  age:
+                    type: integer
+  /owners/{id}:
+    get:
+      responses:
+        "200":
+          description: an owner`
+	d, err := libopenapi.NewDocument([]byte(newSpec))
+	require.NoError(t, err)
+	newModel, errs := d.BuildV3Model()
+	require.Empty(t, errs)
+
+	harFile := &harhar.HAR{Log: harhar.Log{Entries: []harhar.Entry{
+		petEntry("https://api.pets.com/v1/pets/1", `{"name":"fido"}`),
+		petEntry("https://api.pets.com/v1/pets/2", `{"age":3}`),
+		petEntry("https://api.pets.com/v1/owners/5", `{}`),
+		petEntry("https://cdn.pets.com/logo.png", ""),
+		petEntry("https://api.pets.com/v1/pets/3", `{"name":"rex","age":4}`),
+		petEntry("https://api.pets.com/v1/pets/4", `{"name":4,"age":5}`),
+		petEntry("https://api.pets.com/v1/pets/5", `{"name":5}`),
+	}}}
+	report, err := CompareContracts(harFile, petModel(t), &newModel.Model, testConfig())
+	require.NoError(t, err)
+
+	var outcomes []string
+	for _, entry := range report.Entries {
+		outcomes = append(outcomes, entry.Outcome)
+	}
+	assert.Equal(t, []string{NewlyFailing, NewlyPassing, NewlyPassing, NotCompared, StillPassing, StillFailing,
+		NewlyFailing}, outcomes)
+	assert.Equal(t, "GET /pets/{id}", report.Entries[0].Operation)
+	assert.NotEmpty(t, report.Entries[0].Violations)
+	assert.NotEmpty(t, report.Entries[1].Resolved)
+	assert.Empty(t, report.Entries[5].Introduced)
+	assert.NotEmpty(t, report.Entries[6].Introduced)
+	assert.Empty(t, report.Entries[6].Resolved)
+	assert.Contains(t, report.Entries[3].Reason, "does not match a server")
+
+	require.Len(t, report.Operations, 2)
+	assert.Equal(t, &OperationComparison{Operation: "GET /owners/{id}", Outcome: NewlyPassing, Entries: 1,
+		NewlyPassing: 1}, report.Operations[0])
+	assert.Equal(t, &OperationComparison{Operation: "GET /pets/{id}", Outcome: NewlyFailing, Entries: 5,
+		NewlyFailing: 2, NewlyPassing: 1, StillFailing: 1, StillPassing: 1}, report.Operations[1])
+	assert.Equal(t, []*OperationComparison{report.Operations[1]}, report.Regressions())
+	assert.Equal(t, 2, report.NewlyFailing)
+	assert.Equal(t, 1, report.StillFailing)
+	assert.Equal(t, 2, report.NewlyPassing)
+	assert.Equal(t, 1, report.NotCompared)
+}
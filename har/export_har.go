@@ -0,0 +1,242 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package har
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pb33f/harhar"
+	"github.com/pb33f/libopenapi-validator/errors"
+	"github.com/pb33f/wiretap/daemon"
+	"github.com/pb33f/wiretap/shared"
+)
+
+// HARVersion is the version of the HAR format that is exported.
+const HARVersion = "1.2"
+
+// ExportFilter selects the transactions to export, every field that is set must match.
+type ExportFilter struct {
+	// Method is the HTTP method of the request.
+	Method string `json:"method,omitempty" mapstructure:"method"`
+	// Path is the path of the request, exact or a regex.
+	Path string `json:"path,omitempty" mapstructure:"path"`
+	// Status is the status code of the response, exact or a regex, e.g. `4..`.
+	Status string `json:"status,omitempty" mapstructure:"status"`
+	// Violations only exports transactions that failed request or response validation.
+	Violations bool `json:"violations,omitempty" mapstructure:"violations"`
+}
+
+// WiretapEntryExtension is the custom `_wiretap` field of an exported entry.
+type WiretapEntryExtension struct {
+	Id                 string                    `json:"id"`
+	ParentId           string                    `json:"parentId,omitempty"`
+	Callback           string                    `json:"callback,omitempty"`
+	RequestValidation  []*errors.ValidationError `json:"requestValidation,omitempty"`
+	ResponseValidation []*errors.ValidationError `json:"responseValidation,omitempty"`
+}
+
+// ExportEntry is a HAR entry, with the validation results of wiretap.
+type ExportEntry struct {
+	harhar.Entry
+	Wiretap *WiretapEntryExtension `json:"_wiretap,omitempty"`
+}
+
+// ExportLog is a HAR log of exported entries.
+type ExportLog struct {
+	harhar.Log
+	Entries []*ExportEntry `json:"entries"`
+}
+
+// ExportedHAR is the root of an exported HAR document.
+type ExportedHAR struct {
+	Log ExportLog `json:"log"`
+}
+
+// matches checks a transaction against the filter.
+func (f *ExportFilter) matches(transaction *daemon.HttpTransaction) bool {
+	if f == nil {
+		return true
+	}
+	if f.Method != "" && (transaction.Request == nil || !strings.EqualFold(transaction.Request.Method, f.Method)) {
+		return false
+	}
+	if f.Path != "" && (transaction.Request == nil || !shared.StringCompare(f.Path, transaction.Request.Path)) {
+		return false
+	}
+	if f.Status != "" && (transaction.Response == nil ||
+		!shared.StringCompare(f.Status, strconv.Itoa(transaction.Response.StatusCode))) {
+		return false
+	}
+	if f.Violations && len(transaction.RequestValidation) == 0 && len(transaction.ResponseValidation) == 0 {
+		return false
+	}
+	return true
+}
+
+// ExportHAR builds a HAR document from transactions captured by wiretap, in the order they are given. Transactions
+// without a request can't be described in a HAR, and are skipped.
+func ExportHAR(transactions []*daemon.HttpTransaction, filter *ExportFilter, version string) *ExportedHAR {
+	if version == "" {
+		version = "latest"
+	}
+	exported := &ExportedHAR{Log: ExportLog{
+		Log: harhar.Log{
+			Version: HARVersion,
+			Creator: harhar.Creator{Name: "wiretap", Version: version},
+		},
+		Entries: make([]*ExportEntry, 0, len(transactions)),
+	}}
+	for _, transaction := range transactions {
+		if transaction.Request == nil || !filter.matches(transaction) {
+			continue
+		}
+		exported.Log.Entries = append(exported.Log.Entries, exportEntry(transaction))
+	}
+	return exported
+}
+
+// WriteHAR writes an exported HAR document to a file.
+func WriteHAR(file string, exported *ExportedHAR) error {
+	b, err := json.MarshalIndent(exported, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, b, 0644)
+}
+
+func exportEntry(transaction *daemon.HttpTransaction) *ExportEntry {
+	req := transaction.Request
+	started := time.UnixMilli(req.Timestamp)
+
+	entry := &ExportEntry{
+		Entry: harhar.Entry{
+			Start:   started.Format(time.RFC3339Nano),
+			Request: exportRequest(req),
+			Timings: harhar.Timings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1},
+		},
+		Wiretap: &WiretapEntryExtension{
+			Id:                 transaction.Id,
+			ParentId:           transaction.ParentId,
+			Callback:           transaction.Callback,
+			RequestValidation:  transaction.RequestValidation,
+			ResponseValidation: transaction.ResponseValidation,
+		},
+	}
+
+	if transaction.Response != nil {
+		entry.Response = exportResponse(transaction.Response)
+		// wiretap sees the request go out and the response come back, everything in between is waiting.
+		if wait := transaction.Response.Timestamp - req.Timestamp; wait > 0 {
+			entry.Timings.Wait = float64(wait)
+			entry.Time = float64(wait)
+		}
+	} else {
+		entry.Response = harhar.Response{
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harhar.Cookie{},
+			Headers:     []harhar.NameValuePair{},
+			HeadersSize: -1,
+			BodySize:    -1,
+			Comment:     "no response was received",
+		}
+	}
+	return entry
+}
+
+func exportRequest(req *daemon.HttpRequest) harhar.Request {
+	request := harhar.Request{
+		Method:      req.Method,
+		URL:         req.URL,
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     exportCookies(req.Cookies),
+		Headers:     exportHeaders(req.Headers),
+		QueryParams: []harhar.NameValuePair{},
+		HeadersSize: -1,
+		BodySize:    len(req.Body),
+	}
+	if query, err := url.ParseQuery(req.Query); err == nil {
+		request.QueryParams = exportValues(query)
+	}
+	if req.Body != "" {
+		request.Body = harhar.BodyType{MIMEType: headerValue(req.Headers, "Content-Type"), Content: req.Body}
+	}
+	return request
+}
+
+func exportResponse(resp *daemon.HttpResponse) harhar.Response {
+	mimeType := headerValue(resp.Headers, "Content-Type")
+	if mimeType == "" {
+		mimeType = "x-unknown"
+	}
+	return harhar.Response{
+		StatusCode:  resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: "HTTP/1.1",
+		RedirectURL: headerValue(resp.Headers, "Location"),
+		Cookies:     exportCookies(resp.Cookies),
+		Headers:     exportHeaders(resp.Headers),
+		Body: harhar.BodyResponseType{
+			Size:     len(resp.Body),
+			MIMEType: mimeType,
+			Content:  resp.Body,
+		},
+		HeadersSize: -1,
+		BodySize:    len(resp.Body),
+	}
+}
+
+func headerValue(headers map[string]any, name string) string {
+	if value, ok := headers[http.CanonicalHeaderKey(name)]; ok {
+		return fmt.Sprint(value)
+	}
+	return ""
+}
+
+// exportHeaders converts captured headers into name value pairs, sorted by name so exports are stable.
+func exportHeaders(headers map[string]any) []harhar.NameValuePair {
+	pairs := make([]harhar.NameValuePair, 0, len(headers))
+	for name, value := range headers {
+		pairs = append(pairs, harhar.NameValuePair{Name: name, Value: fmt.Sprint(value)})
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Name < pairs[j].Name })
+	return pairs
+}
+
+func exportValues(values url.Values) []harhar.NameValuePair {
+	pairs := make([]harhar.NameValuePair, 0, len(values))
+	for name, list := range values {
+		for _, value := range list {
+			pairs = append(pairs, harhar.NameValuePair{Name: name, Value: value})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].Name < pairs[j].Name })
+	return pairs
+}
+
+func exportCookies(cookies map[string]*daemon.HttpCookie) []harhar.Cookie {
+	exported := make([]harhar.Cookie, 0, len(cookies))
+	for name, c := range cookies {
+		exported = append(exported, harhar.Cookie{
+			Name:     name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			Expires:  c.Expires,
+			Secure:   c.Secure,
+			HTTPOnly: c.HttpOnly,
+		})
+	}
+	sort.Slice(exported, func(i, j int) bool { return exported[i].Name < exported[j].Name })
+	return exported
+}
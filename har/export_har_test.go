@@ -0,0 +1,94 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package har
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pb33f/libopenapi-validator/errors"
+	"github.com/pb33f/wiretap/daemon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTransactions() []*daemon.HttpTransaction {
+	return []*daemon.HttpTransaction{
+		{
+			Id: "one",
+			Request: &daemon.HttpRequest{
+				Timestamp: 1700000000000,
+				URL:       "http://localhost:9090/pets?limit=10&tag=a&tag=b",
+				Method:    "POST",
+				Path:      "/pets",
+				Query:     "limit=10&tag=a&tag=b",
+				Headers:   map[string]any{"Content-Type": "application/json", "Accept": "*/*"},
+				Body:      `{"name":"fido"}`,
+				Cookies:   map[string]*daemon.HttpCookie{"session": {Value: "abc"}},
+			},
+			Response: &daemon.HttpResponse{
+				Timestamp:  1700000000042,
+				StatusCode: 201,
+				Headers:    map[string]any{"Content-Type": "application/json"},
+				Body:       `{"id":1}`,
+			},
+			ResponseValidation: []*errors.ValidationError{{Message: "missing property 'name'"}},
+		},
+		{
+			Id:       "two",
+			Request:  &daemon.HttpRequest{Timestamp: 1700000000100, URL: "http://localhost/pets/1", Method: "GET", Path: "/pets/1"},
+			Response: &daemon.HttpResponse{Timestamp: 1700000000110, StatusCode: 404},
+		},
+		{
+			Id:       "response-only",
+			Response: &daemon.HttpResponse{StatusCode: 200},
+		},
+	}
+}
+
+func TestExportHAR(t *testing.T) {
+	exported := ExportHAR(testTransactions(), nil, "")
+	require.Len(t, exported.Log.Entries, 2)
+	assert.Equal(t, HARVersion, exported.Log.Version)
+	assert.Equal(t, "wiretap", exported.Log.Creator.Name)
+
+	entry := exported.Log.Entries[0]
+	assert.Equal(t, "POST", entry.Request.Method)
+	assert.Len(t, entry.Request.QueryParams, 3)
+	assert.Equal(t, "application/json", entry.Request.Body.MIMEType)
+	assert.Equal(t, `{"name":"fido"}`, entry.Request.Body.Content)
+	assert.Equal(t, "session", entry.Request.Cookies[0].Name)
+	assert.Equal(t, "Accept", entry.Request.Headers[0].Name)
+	assert.Equal(t, 201, entry.Response.StatusCode)
+	assert.Equal(t, "Created", entry.Response.StatusText)
+	assert.Equal(t, `{"id":1}`, entry.Response.Body.Content)
+	assert.Equal(t, float64(42), entry.Time)
+	assert.Equal(t, float64(42), entry.Timings.Wait)
+	assert.Equal(t, "one", entry.Wiretap.Id)
+	assert.Len(t, entry.Wiretap.ResponseValidation, 1)
+
+	assert.Len(t, ExportHAR(testTransactions(), &ExportFilter{Violations: true}, "").Log.Entries, 1)
+	assert.Len(t, ExportHAR(testTransactions(), &ExportFilter{Status: "4.."}, "").Log.Entries, 1)
+	assert.Len(t, ExportHAR(testTransactions(), &ExportFilter{Method: "get", Path: "/pets/.*"}, "").Log.Entries, 1)
+	assert.Empty(t, ExportHAR(testTransactions(), &ExportFilter{Path: "/owners"}, "").Log.Entries)
+}
+
+func TestWriteHAR_RoundTrip(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "export.har")
+	require.NoError(t, WriteHAR(file, ExportHAR(testTransactions(), nil, "1.0.0")))
+
+	b, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"_wiretap"`)
+
+	// an exported HAR can be loaded back into wiretap.
+	harFile, err := BuildHAR(b)
+	require.NoError(t, err)
+	require.Len(t, harFile.Log.Entries, 2)
+	assert.Equal(t, "http://localhost/pets/1", harFile.Log.Entries[1].Request.URL)
+	assert.Equal(t, "1.0.0", harFile.Log.Creator.Version)
+}
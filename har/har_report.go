@@ -0,0 +1,266 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package har
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pb33f/wiretap/config"
+	"github.com/pb33f/wiretap/shared"
+)
+
+const (
+	ReportFormatJSON  = "json"
+	ReportFormatJUnit = "junit"
+	ReportFormatSARIF = "sarif"
+)
+
+// ReportFormats are the formats a HAR validation report can be written in.
+var ReportFormats = []string{ReportFormatJSON, ReportFormatJUnit, ReportFormatSARIF}
+
+// IsReportFormat checks if a format is one of the report formats, an empty format is JSON.
+func IsReportFormat(format string) bool {
+	if format == "" {
+		return true
+	}
+	for _, f := range ReportFormats {
+		if strings.EqualFold(f, format) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReportExtension returns the file extension of a report format.
+func ReportExtension(format string) string {
+	switch strings.ToLower(format) {
+	case ReportFormatJUnit:
+		return ".xml"
+	case ReportFormatSARIF:
+		return ".sarif"
+	}
+	return ".json"
+}
+
+// WriteValidationReport writes a HAR validation report to a file, in the format given.
+func WriteValidationReport(file, format string, report *ValidationReport) error {
+	b, err := RenderValidationReport(format, report)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, b, 0644)
+}
+
+// RenderValidationReport renders a HAR validation report as JSON, as a JUnit XML test suite with a test case per
+// entry, or as a SARIF log with a result per violation.
+func RenderValidationReport(format string, report *ValidationReport) ([]byte, error) {
+	switch strings.ToLower(format) {
+	case "", ReportFormatJSON:
+		return json.MarshalIndent(report, "", "  ")
+	case ReportFormatJUnit:
+		return renderJUnit(report)
+	case ReportFormatSARIF:
+		return renderSARIF(report)
+	}
+	return nil, fmt.Errorf("unknown report format '%s', use one of: %s", format,
+		strings.Join(ReportFormats, ", "))
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+func renderJUnit(report *ValidationReport) ([]byte, error) {
+	suite := junitTestSuite{
+		Name:     "HAR validation",
+		Tests:    report.Total,
+		Failures: report.Invalid,
+		Errors:   report.Errors,
+		Skipped:  report.Skipped,
+	}
+	if report.Contract != "" {
+		suite.Name = fmt.Sprintf("HAR validation against %s", report.Contract)
+	}
+	for _, entry := range report.Entries {
+		testCase := junitTestCase{Name: fmt.Sprintf("%s %s", entry.Method, entry.URL), ClassName: "wiretap.har"}
+		switch entry.Status {
+		case EntryInvalid:
+			var lines []string
+			for _, violation := range append(entry.RequestValidation, entry.ResponseValidation...) {
+				lines = append(lines, fmt.Sprintf("%s: %s", violation.Message, violation.Reason))
+			}
+			testCase.Failure = &junitMessage{Message: entry.Reason, Type: "contract-violation",
+				Text: strings.Join(lines, "\n")}
+		case EntryError:
+			testCase.Error = &junitMessage{Message: entry.Reason}
+		case EntrySkipped:
+			testCase.Skipped = &junitMessage{Message: entry.Reason}
+		}
+		var notices []string
+		for _, notice := range entry.Notices {
+			notices = append(notices, fmt.Sprintf("%s: %s: %s", notice.Severity, notice.Message, notice.Reason))
+		}
+		testCase.SystemOut = strings.Join(notices, "\n")
+		suite.Cases = append(suite.Cases, testCase)
+	}
+	b, err := xml.MarshalIndent(junitTestSuites{
+		Name:     "wiretap",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Errors:   suite.Errors,
+		Skipped:  suite.Skipped,
+		Suites:   []junitTestSuite{suite},
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), b...), nil
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	Id               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleId    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// sarifLevels are the SARIF levels of the severities of violations.
+var sarifLevels = map[string]string{
+	shared.SeverityError:   "error",
+	shared.SeverityWarning: "warning",
+	shared.SeverityInfo:    "note",
+}
+
+func renderSARIF(report *ValidationReport) ([]byte, error) {
+	rules := make(map[string]string)
+	results := []sarifResult{}
+	for _, entry := range report.Entries {
+		violations := make([]*shared.Notice, 0, len(entry.RequestValidation)+len(entry.ResponseValidation))
+		for _, violation := range append(entry.RequestValidation, entry.ResponseValidation...) {
+			violations = append(violations, &shared.Notice{Severity: shared.SeverityError, ValidationError: violation})
+		}
+		for _, violation := range append(violations, entry.Notices...) {
+			ruleId := config.ViolationId(violation.ValidationError)
+			if _, ok := rules[ruleId]; !ok {
+				rules[ruleId] = violation.Message
+			}
+			result := sarifResult{
+				RuleId:  ruleId,
+				Level:   sarifLevels[violation.Severity],
+				Message: sarifMessage{Text: fmt.Sprintf("%s %s: %s", entry.Method, entry.URL, violation.Message)},
+			}
+			if report.Contract != "" {
+				location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: report.Contract},
+				}}
+				if violation.SpecLine >= 1 {
+					location.PhysicalLocation.Region = &sarifRegion{StartLine: violation.SpecLine,
+						StartColumn: violation.SpecCol}
+				}
+				result.Locations = []sarifLocation{location}
+			}
+			results = append(results, result)
+		}
+	}
+
+	ruleIds := make([]string, 0, len(rules))
+	for id := range rules {
+		ruleIds = append(ruleIds, id)
+	}
+	sort.Strings(ruleIds)
+	driver := sarifDriver{Name: "wiretap", InformationURI: "https://pb33f.io/wiretap/", Rules: []sarifRule{}}
+	for _, id := range ruleIds {
+		driver.Rules = append(driver.Rules, sarifRule{Id: id, ShortDescription: sarifMessage{Text: rules[id]}})
+	}
+	return json.MarshalIndent(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}, "", "  ")
+}
@@ -7,12 +7,15 @@ package har
 
 import (
 	"github.com/google/uuid"
+	"github.com/mitchellh/mapstructure"
 	"github.com/pb33f/harhar"
 	"github.com/pb33f/ranch/bus"
 	"github.com/pb33f/ranch/model"
 	"github.com/pb33f/ranch/service"
+	"github.com/pb33f/wiretap/controls"
 	"github.com/pb33f/wiretap/daemon"
 	"github.com/pb33f/wiretap/shared"
+	"github.com/pterm/pterm"
 	"log/slog"
 	"time"
 )
@@ -20,12 +23,16 @@ import (
 const (
 	HARServiceChan     = "har-service"
 	StartTheHARRequest = "start-the-har"
+	ExportHARRequest   = "export-har"
+	ReplayHARRequest   = "replay-the-har"
 )
 
 type HARService struct {
 	harStore       bus.BusStore
+	controlsStore  bus.BusStore
 	logger         *slog.Logger
 	wiretapService *daemon.WiretapService
+	replay         Replayer
 }
 
 type ControlResponse struct {
@@ -33,11 +40,14 @@ type ControlResponse struct {
 }
 
 func NewHARService(wiretapService *daemon.WiretapService, logger *slog.Logger) *HARService {
-	harStore := bus.GetBus().GetStoreManager().CreateStore(HARServiceChan)
+	storeManager := bus.GetBus().GetStoreManager()
+	harStore := storeManager.CreateStore(HARServiceChan)
 	return &HARService{
 		harStore:       harStore,
+		controlsStore:  storeManager.CreateStore(controls.ControlServiceChan),
 		logger:         logger,
 		wiretapService: wiretapService,
+		replay:         wiretapService.ReplayRequest,
 	}
 }
 
@@ -45,6 +55,10 @@ func (hs *HARService) HandleServiceRequest(request *model.Request, core service.
 	switch request.RequestCommand {
 	case StartTheHARRequest:
 		hs.startTheHAR(request)
+	case ExportHARRequest:
+		hs.exportHAR(request, core)
+	case ReplayHARRequest:
+		hs.replayHAR(request, core)
 	default:
 		core.HandleUnknownRequest(request)
 	}
@@ -76,3 +90,93 @@ func (hs *HARService) startTheHAR(request *model.Request) {
 		}
 	}
 }
+
+// harFile returns the HAR file that has been loaded, or nil.
+func (hs *HARService) harFile() *harhar.HAR {
+	if hs.harStore == nil {
+		return nil
+	}
+	if harFile, ok := hs.harStore.GetValue(shared.HARKey).(*harhar.HAR); ok {
+		return harFile
+	}
+	return nil
+}
+
+// replayHAR replays the loaded HAR file against the API and responds with the report. The payload can override
+// the replay configuration.
+func (hs *HARService) replayHAR(request *model.Request, core service.FabricServiceCore) {
+	harFile := hs.harFile()
+	if harFile == nil {
+		core.SendErrorResponse(request, 400, "no HAR file has been loaded")
+		return
+	}
+	replayConfig := shared.WiretapHARReplayConfig{}
+	if config := hs.config(); config != nil && config.HARReplay != nil {
+		replayConfig = *config.HARReplay
+	}
+	if dl, ok := request.Payload.(map[string]interface{}); ok {
+		_ = mapstructure.Decode(dl, &replayConfig)
+	}
+	core.SendResponse(request, ReplayHAR(harFile, hs.replay, &replayConfig))
+}
+
+// ReplayLoadedHAR replays the loaded HAR file against the API, if replay is enabled, then prints the report and
+// writes it to the report file, if one is configured. The report is nil when nothing was replayed.
+func (hs *HARService) ReplayLoadedHAR() *ReplayReport {
+	config := hs.config()
+	harFile := hs.harFile()
+	if config == nil || config.HARReplay == nil || !config.HARReplay.Enabled || harFile == nil {
+		return nil
+	}
+	report := ReplayHAR(harFile, hs.replay, config.HARReplay)
+	PrintReplayReport(report)
+	if config.HARReplay.Report != "" {
+		if err := WriteReplayReport(config.HARReplay.Report, report); err != nil {
+			hs.logger.Error("unable to write HAR replay report", "file", config.HARReplay.Report, "error", err.Error())
+		} else {
+			pterm.Printf("HAR replay report saved to: %s\n", pterm.LightMagenta(config.HARReplay.Report))
+		}
+	}
+	return report
+}
+
+// config returns the current wiretap configuration.
+func (hs *HARService) config() *shared.WiretapConfiguration {
+	if hs.controlsStore == nil {
+		return nil
+	}
+	if config, ok := hs.controlsStore.GetValue(shared.ConfigKey).(*shared.WiretapConfiguration); ok {
+		return config
+	}
+	return nil
+}
+
+func (hs *HARService) version() string {
+	if config := hs.config(); config != nil {
+		return config.Version
+	}
+	return ""
+}
+
+// exportHAR responds with the transactions captured so far as a HAR document, optionally filtered.
+func (hs *HARService) exportHAR(request *model.Request, core service.FabricServiceCore) {
+	var filter ExportFilter
+	if dl, ok := request.Payload.(map[string]interface{}); ok {
+		_ = mapstructure.Decode(dl, &filter)
+	}
+	core.SendResponse(request, ExportHAR(hs.wiretapService.Transactions(), &filter, hs.version()))
+}
+
+// OnServerShutdown writes every transaction captured to the HAR export file, if one is configured.
+func (hs *HARService) OnServerShutdown() {
+	config := hs.config()
+	if config == nil || config.HARExport == "" {
+		return
+	}
+	exported := ExportHAR(hs.wiretapService.Transactions(), nil, config.Version)
+	if err := WriteHAR(config.HARExport, exported); err != nil {
+		hs.logger.Error("unable to export HAR file", "file", config.HARExport, "error", err.Error())
+		return
+	}
+	hs.logger.Info("exported HAR file", "file", config.HARExport, "entries", len(exported.Log.Entries))
+}
@@ -0,0 +1,51 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package har
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pb33f/harhar"
+	"github.com/pb33f/ranch/bus"
+	"github.com/pb33f/ranch/model"
+	"github.com/pb33f/wiretap/controls"
+	"github.com/pb33f/wiretap/daemon"
+	"github.com/pb33f/wiretap/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHARService_ReplayLoadedHAR(t *testing.T) {
+	harFile := &harhar.HAR{Log: harhar.Log{Entries: []harhar.Entry{
+		{Request: harhar.Request{Method: "GET", URL: "http://localhost/pets/1"}, Response: recordedResponse(200, `{"id":1}`)},
+	}}}
+	config := testConfig()
+	config.HARReplay = &shared.WiretapHARReplayConfig{Enabled: true,
+		Report: filepath.Join(t.TempDir(), "replay.json")}
+
+	storeManager := bus.GetBus().GetStoreManager()
+	storeManager.CreateStore(controls.ControlServiceChan).Put(shared.ConfigKey, config, nil)
+	storeManager.CreateStore(HARServiceChan).Put(shared.HARKey, harFile, nil)
+
+	hs := NewHARService(nil, config.Logger)
+	var replayed []string
+	hs.replay = func(request *model.Request) *daemon.ReplayResult {
+		replayed = append(replayed, request.HttpRequest.URL.Path)
+		return &daemon.ReplayResult{StatusCode: 200, Body: []byte(`{"id":2}`)}
+	}
+
+	report := hs.ReplayLoadedHAR()
+	require.NotNil(t, report)
+	assert.Equal(t, []string{"/pets/1"}, replayed)
+	assert.True(t, report.Failed(""))
+	_, err := os.Stat(config.HARReplay.Report)
+	assert.NoError(t, err)
+
+	config.HARReplay.Enabled = false
+	assert.Nil(t, hs.ReplayLoadedHAR())
+}
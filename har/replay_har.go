@@ -0,0 +1,320 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package har
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pb33f/harhar"
+	"github.com/pb33f/libopenapi-validator/errors"
+	"github.com/pb33f/ranch/model"
+	"github.com/pb33f/wiretap/daemon"
+	"github.com/pb33f/wiretap/shared"
+	"github.com/pterm/pterm"
+)
+
+const (
+	RegressionStatus = "status"
+	RegressionHeader = "header"
+	RegressionBody   = "body"
+)
+
+// volatileHeaders change between calls to the same API, they are never compared.
+var volatileHeaders = []string{
+	"Age", "Connection", "Content-Encoding", "Content-Length", "Date", "Etag", "Expires", "Keep-Alive",
+	"Last-Modified", "Server", "Set-Cookie", "Transfer-Encoding", "X-Request-Id",
+}
+
+// Regression is a difference between the response recorded in a HAR file and the response the API returns now.
+type Regression struct {
+	Type string `json:"type"`
+	// Path is the name of the header, or the JSON pointer of the body value that changed.
+	Path     string `json:"path,omitempty"`
+	Expected any    `json:"expected,omitempty"`
+	Actual   any    `json:"actual,omitempty"`
+	Message  string `json:"message"`
+}
+
+// ReplayEntry is the result of replaying a single HAR entry.
+type ReplayEntry struct {
+	Method             string                    `json:"method"`
+	URL                string                    `json:"url"`
+	RecordedStatus     int                       `json:"recordedStatus"`
+	StatusCode         int                       `json:"statusCode,omitempty"`
+	Regressions        []*Regression             `json:"regressions,omitempty"`
+	RequestValidation  []*errors.ValidationError `json:"requestValidation,omitempty"`
+	ResponseValidation []*errors.ValidationError `json:"responseValidation,omitempty"`
+	Error              string                    `json:"error,omitempty"`
+}
+
+// Passed checks if the entry replayed without an error, a regression or a contract violation.
+func (e *ReplayEntry) Passed() bool {
+	return e.Error == "" && len(e.Regressions) == 0 && len(e.RequestValidation) == 0 &&
+		len(e.ResponseValidation) == 0
+}
+
+// ReplayReport is the result of replaying a HAR file against the API.
+type ReplayReport struct {
+	Entries     []*ReplayEntry `json:"entries"`
+	Total       int            `json:"total"`
+	Passed      int            `json:"passed"`
+	Regressions int            `json:"regressions"`
+	Violations  int            `json:"violations"`
+	Failures    int            `json:"failures"`
+}
+
+// Failed checks if the replay should fail a build. Regressions and requests that could not be replayed always fail
+// it, contract violations only when they are at least as severe as the fail-on threshold, if there is one.
+func (r *ReplayReport) Failed(failOn string) bool {
+	if r.Regressions > 0 || r.Failures > 0 {
+		return true
+	}
+	return r.Violations > 0 && failOn != "" && shared.SeverityAtLeast(shared.SeverityError, failOn)
+}
+
+// Replayer sends a request to the API and returns the response, daemon.WiretapService.ReplayRequest is a Replayer.
+type Replayer func(request *model.Request) *daemon.ReplayResult
+
+// ReplayHAR re-sends every request of a HAR file with the replayer, and compares each response with the recorded
+// response. Entries count as regressions, violations and failures independently, an entry can be all three.
+func ReplayHAR(harFile *harhar.HAR, replay Replayer, config *shared.WiretapHARReplayConfig) *ReplayReport {
+	if config == nil {
+		config = &shared.WiretapHARReplayConfig{}
+	}
+	report := &ReplayReport{Entries: []*ReplayEntry{}}
+	if harFile == nil {
+		return report
+	}
+	for _, recorded := range harFile.Log.Entries {
+		entry := &ReplayEntry{
+			Method:         recorded.Request.Method,
+			URL:            recorded.Request.URL,
+			RecordedStatus: recorded.Response.StatusCode,
+		}
+		report.Entries = append(report.Entries, entry)
+		report.Total++
+
+		httpRequest, err := harhar.ConvertRequestIntoHttpRequest(recorded.Request)
+		if err != nil {
+			entry.Error = fmt.Sprintf("unable to convert request: %s", err.Error())
+			report.Failures++
+			continue
+		}
+		id, _ := uuid.NewUUID()
+		result := replay(&model.Request{Id: &id, HttpRequest: httpRequest})
+
+		entry.RequestValidation = result.RequestValidation
+		entry.ResponseValidation = result.ResponseValidation
+		if result.Error != nil {
+			entry.Error = result.Error.Error()
+			report.Failures++
+		} else {
+			entry.StatusCode = result.StatusCode
+			entry.Regressions = CompareResponse(recorded.Response, result, config)
+		}
+
+		if len(entry.Regressions) > 0 {
+			report.Regressions++
+		}
+		if len(entry.RequestValidation) > 0 || len(entry.ResponseValidation) > 0 {
+			report.Violations++
+		}
+		if entry.Passed() {
+			report.Passed++
+		}
+	}
+	return report
+}
+
+// CompareResponse compares a recorded response with the response of a replayed request: the status code, the
+// recorded headers and the body. JSON bodies are compared value by value, other bodies as text. A body that was not
+// recorded is not compared.
+func CompareResponse(recorded harhar.Response, result *daemon.ReplayResult,
+	config *shared.WiretapHARReplayConfig) []*Regression {
+
+	if config == nil {
+		config = &shared.WiretapHARReplayConfig{}
+	}
+	var regressions []*Regression
+	if recorded.StatusCode != result.StatusCode {
+		regressions = append(regressions, &Regression{
+			Type:     RegressionStatus,
+			Expected: recorded.StatusCode,
+			Actual:   result.StatusCode,
+			Message:  fmt.Sprintf("status code changed from %d to %d", recorded.StatusCode, result.StatusCode),
+		})
+	}
+	regressions = append(regressions, compareHeaders(recorded.Headers, result.Header, config.IgnoreHeaders)...)
+	return append(regressions, compareBody(recorded.Body, result, config.IgnorePaths)...)
+}
+
+func compareHeaders(recorded []harhar.NameValuePair, actual http.Header, ignore []string) []*Regression {
+	expected := http.Header{}
+	for _, pair := range recorded {
+		if !strings.HasPrefix(pair.Name, ":") {
+			expected.Add(pair.Name, pair.Value)
+		}
+	}
+	ignored := func(name string) bool {
+		// CORS headers are added by wiretap, recordings made through wiretap carry them.
+		if strings.HasPrefix(name, "Access-Control-") {
+			return true
+		}
+		for _, list := range [][]string{volatileHeaders, ignore} {
+			for _, header := range list {
+				if strings.EqualFold(header, name) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	var regressions []*Regression
+	for name, values := range expected {
+		if ignored(name) {
+			continue
+		}
+		want := strings.Join(values, ", ")
+		got := strings.Join(actual.Values(name), ", ")
+		switch {
+		case got == "":
+			regressions = append(regressions, &Regression{Type: RegressionHeader, Path: name, Expected: want,
+				Message: fmt.Sprintf("header '%s' is missing", name)})
+		case got != want:
+			regressions = append(regressions, &Regression{Type: RegressionHeader, Path: name, Expected: want,
+				Actual: got, Message: fmt.Sprintf("header '%s' changed from '%s' to '%s'", name, want, got)})
+		}
+	}
+	sort.Slice(regressions, func(i, j int) bool { return regressions[i].Path < regressions[j].Path })
+	return regressions
+}
+
+func compareBody(recorded harhar.BodyResponseType, result *daemon.ReplayResult, ignore []string) []*Regression {
+	if recorded.Content == "" {
+		return nil
+	}
+	expected := []byte(recorded.Content)
+	if strings.EqualFold(recorded.Encoding, "base64") {
+		decoded, err := base64.StdEncoding.DecodeString(recorded.Content)
+		if err != nil {
+			return nil
+		}
+		expected = decoded
+	}
+	actual := result.Body
+	if strings.EqualFold(result.Header.Get("Content-Encoding"), "gzip") {
+		if reader, err := gzip.NewReader(bytes.NewReader(actual)); err == nil {
+			if decoded, rErr := io.ReadAll(reader); rErr == nil {
+				actual = decoded
+			}
+		}
+	}
+
+	var expectedJSON, actualJSON any
+	if json.Unmarshal(expected, &expectedJSON) != nil || json.Unmarshal(actual, &actualJSON) != nil {
+		if strings.TrimSpace(string(expected)) != strings.TrimSpace(string(actual)) {
+			return []*Regression{{Type: RegressionBody, Message: "response body changed"}}
+		}
+		return nil
+	}
+
+	var regressions []*Regression
+	for _, difference := range shared.DiffJSON(expectedJSON, actualJSON) {
+		if ignoredPath(ignore, difference.Path) {
+			continue
+		}
+		regression := &Regression{Type: RegressionBody, Path: difference.Path, Expected: difference.Expected,
+			Actual: difference.Actual}
+		path := difference.Path
+		if path == "" {
+			path = "/"
+		}
+		switch difference.Change {
+		case shared.JSONValueAdded:
+			regression.Message = fmt.Sprintf("'%s' was added", path)
+		case shared.JSONValueRemoved:
+			regression.Message = fmt.Sprintf("'%s' was removed", path)
+		default:
+			regression.Message = fmt.Sprintf("'%s' changed from %s to %s", path,
+				jsonString(difference.Expected), jsonString(difference.Actual))
+		}
+		regressions = append(regressions, regression)
+	}
+	return regressions
+}
+
+func ignoredPath(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if shared.PointerMatches(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonString(value any) string {
+	b, _ := json.Marshal(value)
+	return string(b)
+}
+
+// WriteReplayReport writes a replay report to a file as JSON.
+func WriteReplayReport(file string, report *ReplayReport) error {
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, b, 0644)
+}
+
+// PrintReplayReport prints the regressions and violations of a replay report, followed by a summary.
+func PrintReplayReport(report *ReplayReport) {
+	for _, entry := range report.Entries {
+		if entry.Passed() {
+			continue
+		}
+		var items []pterm.BulletListItem
+		items = append(items, pterm.BulletListItem{
+			Level: 0, Text: pterm.Sprintf("%s %s", pterm.LightCyan(entry.Method), entry.URL),
+		})
+		if entry.Error != "" {
+			items = append(items, pterm.BulletListItem{Level: 1, Text: pterm.LightRed(entry.Error)})
+		}
+		for _, regression := range entry.Regressions {
+			items = append(items, pterm.BulletListItem{
+				Level: 1, Text: pterm.Sprintf("Regression: %s", pterm.LightYellow(regression.Message)),
+			})
+		}
+		for _, violation := range append(entry.RequestValidation, entry.ResponseValidation...) {
+			items = append(items, pterm.BulletListItem{
+				Level: 1, Text: pterm.Sprintf("Violation: %s", pterm.LightRed(violation.Message)),
+			})
+		}
+		_ = pterm.DefaultBulletList.WithItems(items).Render()
+	}
+
+	pterm.Println()
+	if report.Passed == report.Total {
+		pterm.Success.Printf("HAR replay passed, %d %s replayed without regressions or violations\n",
+			report.Total, shared.Pluralize(report.Total, "request", "requests"))
+		return
+	}
+	pterm.Error.Printf("HAR replay found %d %s with regressions, %d with contract violations and %d %s, "+
+		"out of %d %s\n",
+		report.Regressions, shared.Pluralize(report.Regressions, "request", "requests"),
+		report.Violations, report.Failures, shared.Pluralize(report.Failures, "failure", "failures"),
+		report.Total, shared.Pluralize(report.Total, "request", "requests"))
+}
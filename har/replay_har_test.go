@@ -0,0 +1,126 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package har
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pb33f/harhar"
+	"github.com/pb33f/libopenapi-validator/errors"
+	"github.com/pb33f/ranch/model"
+	"github.com/pb33f/wiretap/daemon"
+	"github.com/pb33f/wiretap/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func recordedResponse(status int, body string, headers ...harhar.NameValuePair) harhar.Response {
+	return harhar.Response{
+		StatusCode: status,
+		Headers:    headers,
+		Body:       harhar.BodyResponseType{MIMEType: "application/json", Content: body},
+	}
+}
+
+func TestCompareResponse(t *testing.T) {
+	recorded := recordedResponse(200, `{"id":1,"name":"fido","meta":{"requestId":"a"},"toys":[{"name":"bone","at":1}]}`,
+		harhar.NameValuePair{Name: "Content-Type", Value: "application/json"},
+		harhar.NameValuePair{Name: "Date", Value: "yesterday"},
+		harhar.NameValuePair{Name: "X-Rate-Limit", Value: "100"},
+		harhar.NameValuePair{Name: "X-Version", Value: "1"})
+
+	result := &daemon.ReplayResult{
+		StatusCode: 201,
+		Header:     http.Header{"Content-Type": {"application/json"}, "Date": {"today"}, "X-Version": {"2"}},
+		Body:       []byte(`{"id":1,"name":"rex","meta":{"requestId":"b"},"toys":[{"name":"bone","at":2}],"age":3}`),
+	}
+	regressions := CompareResponse(recorded, result, &shared.WiretapHARReplayConfig{
+		IgnorePaths: []string{"/meta", "/toys/*/at"},
+	})
+
+	var messages []string
+	for _, r := range regressions {
+		messages = append(messages, r.Message)
+	}
+	assert.Equal(t, []string{
+		"status code changed from 200 to 201",
+		"header 'X-Rate-Limit' is missing",
+		"header 'X-Version' changed from '1' to '2'",
+		"'/age' was added",
+		`'/name' changed from "fido" to "rex"`,
+	}, messages)
+
+	regressions = CompareResponse(recorded, result, &shared.WiretapHARReplayConfig{
+		IgnoreHeaders: []string{"x-rate-limit", "X-Version"},
+		IgnorePaths:   []string{"/meta", "/toys", "/name", "/age"},
+	})
+	require.Len(t, regressions, 1)
+	assert.Equal(t, RegressionStatus, regressions[0].Type)
+}
+
+func TestCompareResponse_Text(t *testing.T) {
+	recorded := recordedResponse(200, "<pet/>")
+	regressions := CompareResponse(recorded, &daemon.ReplayResult{StatusCode: 200, Body: []byte("<pet/>\n")}, nil)
+	assert.Empty(t, regressions)
+
+	regressions = CompareResponse(recorded, &daemon.ReplayResult{StatusCode: 200, Body: []byte("<cat/>")}, nil)
+	require.Len(t, regressions, 1)
+	assert.Equal(t, RegressionBody, regressions[0].Type)
+
+	// a body that was not recorded is not compared.
+	regressions = CompareResponse(recordedResponse(200, ""), &daemon.ReplayResult{StatusCode: 200,
+		Body: []byte("{}")}, nil)
+	assert.Empty(t, regressions)
+}
+
+func TestReplayHAR(t *testing.T) {
+	harFile := &harhar.HAR{Log: harhar.Log{Entries: []harhar.Entry{
+		{Request: harhar.Request{Method: "GET", URL: "http://localhost/pets/1"}, Response: recordedResponse(200, `{"id":1}`)},
+		{Request: harhar.Request{Method: "GET", URL: "http://localhost/pets/2"}, Response: recordedResponse(200, `{"id":2}`)},
+		{Request: harhar.Request{Method: "DELETE", URL: "http://localhost/pets/3"}, Response: recordedResponse(204, "")},
+		{Request: harhar.Request{Method: "GET", URL: "http://localhost/owners"}, Response: recordedResponse(200, "")},
+	}}}
+
+	var replayed []string
+	report := ReplayHAR(harFile, func(request *model.Request) *daemon.ReplayResult {
+		replayed = append(replayed, request.HttpRequest.URL.Path)
+		switch request.HttpRequest.URL.Path {
+		case "/pets/1":
+			return &daemon.ReplayResult{StatusCode: 200, Body: []byte(`{"id":1}`)}
+		case "/pets/2":
+			return &daemon.ReplayResult{StatusCode: 200, Body: []byte(`{"id":"2"}`)}
+		case "/pets/3":
+			return &daemon.ReplayResult{StatusCode: 204,
+				ResponseValidation: []*errors.ValidationError{{Message: "DELETE operation not found"}}}
+		}
+		return &daemon.ReplayResult{Error: fmt.Errorf("connection refused")}
+	}, nil)
+
+	assert.Equal(t, []string{"/pets/1", "/pets/2", "/pets/3", "/owners"}, replayed)
+	assert.Equal(t, 4, report.Total)
+	assert.Equal(t, 1, report.Passed)
+	assert.Equal(t, 1, report.Regressions)
+	assert.Equal(t, 1, report.Violations)
+	assert.Equal(t, 1, report.Failures)
+	assert.Equal(t, "/id", report.Entries[1].Regressions[0].Path)
+	assert.Equal(t, "connection refused", report.Entries[3].Error)
+	assert.True(t, report.Failed(""))
+
+	violations := &ReplayReport{Total: 1, Violations: 1}
+	assert.False(t, violations.Failed(""))
+	assert.True(t, violations.Failed(shared.SeverityWarning))
+	assert.False(t, (&ReplayReport{Total: 1, Passed: 1}).Failed(shared.SeverityInfo))
+
+	file := filepath.Join(t.TempDir(), "replay.json")
+	require.NoError(t, WriteReplayReport(file, report))
+	b, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"regressions": 1`)
+}
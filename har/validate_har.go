@@ -4,13 +4,26 @@
 package har
 
 import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/gobwas/glob"
 	"github.com/pb33f/harhar"
 	"github.com/pb33f/libopenapi-validator/errors"
 	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/wiretap/config"
+	"github.com/pb33f/wiretap/shadow"
 	"github.com/pb33f/wiretap/shared"
 	"github.com/pb33f/wiretap/validation"
-	"github.com/pterm/pterm"
-	"strings"
+)
+
+const (
+	EntryValid   = "valid"
+	EntryInvalid = "invalid"
+	EntrySkipped = "skipped"
+	EntryError   = "error"
 )
 
 type Transaction struct {
@@ -18,50 +31,289 @@ type Transaction struct {
 	Response *harhar.Response
 }
 
-func ValidateHAR(har *harhar.HAR, doc *v3.Document, configFile *shared.WiretapConfiguration) []*errors.ValidationError {
+// EntryResult is the result of validating a single HAR entry.
+type EntryResult struct {
+	Index  int    `json:"index"`
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	// Path is the path that was validated, after the allow list prefix has been stripped.
+	Path               string                    `json:"path,omitempty"`
+	Status             string                    `json:"status"`
+	Reason             string                    `json:"reason,omitempty"`
+	RequestValidation  []*errors.ValidationError `json:"requestValidation,omitempty"`
+	ResponseValidation []*errors.ValidationError `json:"responseValidation,omitempty"`
+	// Notices are the violations validation rules gave a lower severity than error, they do not invalidate the entry.
+	Notices []*shared.Notice `json:"notices,omitempty"`
+}
+
+// ValidationReport is the result of validating a HAR file against an OpenAPI specification.
+type ValidationReport struct {
+	Contract string         `json:"contract,omitempty"`
+	Entries  []*EntryResult `json:"entries"`
+	Total    int            `json:"total"`
+	Valid    int            `json:"valid"`
+	Invalid  int            `json:"invalid"`
+	Skipped  int            `json:"skipped"`
+	Errors   int            `json:"errors"`
+	Warnings int            `json:"warnings"`
+	Info     int            `json:"info"`
+	// ShadowAPI is the traffic of the validated entries the specification does not describe.
+	ShadowAPI []*shadow.Finding `json:"shadowApi,omitempty"`
+}
+
+// Violations returns every request and response validation error of the report.
+func (r *ValidationReport) Violations() []*errors.ValidationError {
+	var violations []*errors.ValidationError
+	for _, entry := range r.Entries {
+		violations = append(violations, entry.RequestValidation...)
+		violations = append(violations, entry.ResponseValidation...)
+	}
+	return violations
+}
+
+// Notices returns every notice of the report.
+func (r *ValidationReport) Notices() []*shared.Notice {
+	var notices []*shared.Notice
+	for _, entry := range r.Entries {
+		notices = append(notices, entry.Notices...)
+	}
+	return notices
+}
 
-	var validationErrors []*errors.ValidationError
+// Exceeds checks if the report has a violation at least as severe as a threshold, such as a warning.
+func (r *ValidationReport) Exceeds(threshold string) bool {
+	if r.Invalid > 0 && shared.SeverityAtLeast(shared.SeverityError, threshold) {
+		return true
+	}
+	for _, notice := range r.Notices() {
+		if shared.SeverityAtLeast(notice.Severity, threshold) {
+			return true
+		}
+	}
+	return false
+}
 
+// ValidateHAR validates every entry of a HAR file against an OpenAPI specification. An entry is validated when:
+//   - its path starts with a prefix of the allow list, the prefix is stripped before validating, or
+//   - its URL matches one of the servers of the specification. When the specification has no absolute servers,
+//     entries matching the include globs are validated, or when there are neither include globs nor an allow list,
+//     entries on the most common host of the HAR file,
+//
+// and it matches the include globs (if any) and none of the exclude globs. Globs that contain `://` are matched
+// against the URL, other globs against the path. Entries that can't be converted are reported as errors, the rest
+// of the file is still validated. Violations are given a severity by the validation rules of the configuration,
+// only errors invalidate an entry. Undocumented paths, methods, status codes and content types are also aggregated
+// into the shadow API section of the report.
+func ValidateHAR(har *harhar.HAR, doc *v3.Document, configFile *shared.WiretapConfiguration) (*ValidationReport, error) {
+	include, err := compileGlobs(configFile.HARInclude)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := compileGlobs(configFile.HARExclude)
+	if err != nil {
+		return nil, err
+	}
+	servers := compileServers(doc)
+	if len(servers.absolute) == 0 {
+		switch {
+		case len(include) > 0:
+			// the include globs choose the entries to validate.
+			servers.any = true
+		case len(configFile.HARPathAllowList) == 0:
+			servers.host = mostCommonHost(har)
+		}
+	}
 	validator := validation.NewHttpValidator(doc)
+	detector := shadow.NewDetector(doc)
 
-	for _, entry := range har.Log.Entries {
+	report := &ValidationReport{Contract: configFile.Contract, Entries: []*EntryResult{}}
+	for i, entry := range har.Log.Entries {
+		result := &EntryResult{Index: i, Method: entry.Request.Method, URL: entry.Request.URL}
+		report.Entries = append(report.Entries, result)
+		report.Total++
+
+		httpRequest, cErr := harhar.ConvertRequestIntoHttpRequest(entry.Request)
+		if cErr != nil {
+			result.Status, result.Reason = EntryError, fmt.Sprintf("unable to convert request: %s", cErr.Error())
+			report.Errors++
+			continue
+		}
+		location := httpRequest.URL.Scheme + "://" + httpRequest.URL.Host + httpRequest.URL.Path
+
+		if reason := filterEntry(include, exclude, location, httpRequest.URL.Path); reason != "" {
+			result.Status, result.Reason = EntrySkipped, reason
+			report.Skipped++
+			continue
+		}
+
+		path, allowed := stripAllowListPrefix(configFile.HARPathAllowList, httpRequest.URL.Path)
+		if !allowed && !servers.matches(httpRequest.URL) {
+			result.Status, result.Reason = EntrySkipped, servers.reason()
+			report.Skipped++
+			continue
+		}
+		httpRequest.URL.Path = path
+		result.Path = path
+
+		_, requestErrors := validator.ValidateHttpRequest(httpRequest)
+		httpResponse := harhar.ConvertResponseIntoHttpResponse(entry.Response)
+		_, responseErrors := validator.ValidateHttpResponse(httpRequest, httpResponse)
+
+		var requestNotices, responseNotices []*shared.Notice
+		result.RequestValidation, requestNotices = config.ClassifyViolations(requestErrors, result.Method, path, configFile)
+		result.ResponseValidation, responseNotices = config.ClassifyViolations(responseErrors, result.Method, path,
+			configFile)
+		result.Notices = append(requestNotices, responseNotices...)
+		detector.Observe(httpRequest, entry.Response.StatusCode, entry.Response.Body.MIMEType,
+			entry.Response.Body.Content != "")
+		for _, notice := range result.Notices {
+			if notice.Severity == shared.SeverityWarning {
+				report.Warnings++
+			} else {
+				report.Info++
+			}
+		}
+
+		if len(result.RequestValidation) > 0 || len(result.ResponseValidation) > 0 {
+			result.Status = EntryInvalid
+			result.Reason = fmt.Sprintf("%d %s",
+				len(result.RequestValidation)+len(result.ResponseValidation),
+				shared.Pluralize(len(result.RequestValidation)+len(result.ResponseValidation),
+					"violation", "violations"))
+			report.Invalid++
+		} else {
+			result.Status = EntryValid
+			report.Valid++
+			configFile.Logger.Debug("[HAR] valid request and response", "path", path)
+		}
+	}
+	report.ShadowAPI = detector.Findings()
+	return report, nil
+}
 
-		httpRequest, err := harhar.ConvertRequestIntoHttpRequest(entry.Request)
+// compiledGlob is a HAR include or exclude glob, with the pattern it was compiled from.
+type compiledGlob struct {
+	pattern string
+	glob    glob.Glob
+}
 
+func compileGlobs(patterns []string) ([]compiledGlob, error) {
+	compiled := make([]compiledGlob, 0, len(patterns))
+	for _, pattern := range patterns {
+		g, err := glob.Compile(pattern)
 		if err != nil {
-			pterm.Error.Printf("error converting request: %s", err.Error())
-			return nil
-		}
-
-		if configFile.HARPathAllowList != nil {
-			for _, allow := range configFile.HARPathAllowList {
-
-				if strings.HasPrefix(httpRequest.URL.Path, allow) {
-
-					path := strings.Replace(httpRequest.URL.Path, allow, "", 1)
-					httpRequest.URL.Path = path
-
-					validRequest, requestValidationErrors := validator.ValidateHttpRequest(httpRequest)
-					if !validRequest {
-						validationErrors = append(validationErrors, requestValidationErrors...)
-					} else {
-						configFile.Logger.Debug("[HAR] valid request", "path", httpRequest.URL.Path)
-					}
-
-					httpResponse := harhar.ConvertResponseIntoHttpResponse(entry.Response)
-					validResponse, responseValidationErrors := validator.ValidateHttpResponse(httpRequest, httpResponse)
-					if !validResponse {
-						validationErrors = append(validationErrors, responseValidationErrors...)
-					} else {
-						configFile.Logger.Debug("[HAR] valid response", "path", httpRequest.URL.Path)
-					}
-					break
-				}
-				pterm.Debug.Printf("[HAR] skipping request: %s\n", httpRequest.URL.Path)
+			return nil, fmt.Errorf("invalid HAR glob '%s': %w", pattern, err)
+		}
+		compiled = append(compiled, compiledGlob{pattern: pattern, glob: g})
+	}
+	return compiled, nil
+}
+
+func (g compiledGlob) matches(location, path string) bool {
+	if strings.Contains(g.pattern, "://") {
+		return g.glob.Match(location)
+	}
+	return g.glob.Match(path)
+}
+
+// filterEntry returns the reason an entry is filtered out by the include and exclude globs, or an empty string.
+func filterEntry(include, exclude []compiledGlob, location, path string) string {
+	if len(include) > 0 {
+		included := false
+		for _, g := range include {
+			if g.matches(location, path) {
+				included = true
+				break
 			}
 		}
+		if !included {
+			return "not included by a HAR include glob"
+		}
+	}
+	for _, g := range exclude {
+		if g.matches(location, path) {
+			return fmt.Sprintf("excluded by HAR exclude glob '%s'", g.pattern)
+		}
+	}
+	return ""
+}
+
+// stripAllowListPrefix strips the first prefix of the allow list the path starts with.
+func stripAllowListPrefix(allowList []string, path string) (string, bool) {
+	for _, allow := range allowList {
+		if strings.HasPrefix(path, allow) {
+			return strings.Replace(path, allow, "", 1), true
+		}
+	}
+	return path, false
+}
+
+// serverMatchers match request URLs against the servers of a specification. Server variables match any value of
+// a single segment. Without absolute servers, URLs match when they are on the fallback host, or any URL matches
+// when any is set.
+type serverMatchers struct {
+	absolute []*regexp.Regexp
+	host     string
+	any      bool
+}
+
+var serverVariable = regexp.MustCompile(`\\\{[^}]*\}`)
+
+func compileServers(doc *v3.Document) *serverMatchers {
+	matchers := &serverMatchers{}
+	if doc == nil {
+		return matchers
+	}
+	for _, server := range doc.Servers {
+		if !strings.Contains(server.URL, "://") {
+			// relative servers are on the same host as the specification, the validator strips their path.
+			continue
+		}
+		pattern := serverVariable.ReplaceAllString(regexp.QuoteMeta(strings.TrimSuffix(server.URL, "/")), `[^/]*`)
+		matchers.absolute = append(matchers.absolute, regexp.MustCompile(`(?i)^`+pattern+`(/|$)`))
+	}
+	return matchers
+}
+
+// matches checks if a URL is served by one of the absolute servers, or the fallback host when there are none.
+func (m *serverMatchers) matches(u *url.URL) bool {
+	if len(m.absolute) == 0 {
+		return m.any || (m.host != "" && strings.EqualFold(u.Host, m.host))
+	}
+	location := u.Scheme + "://" + u.Host + u.Path
+	for _, server := range m.absolute {
+		if server.MatchString(location) {
+			return true
+		}
 	}
+	return false
+}
 
-	return validationErrors
+// reason explains why a URL does not match.
+func (m *serverMatchers) reason() string {
+	switch {
+	case len(m.absolute) > 0:
+		return "URL does not match a server of the specification, or a prefix of the allow list"
+	case m.host != "":
+		return fmt.Sprintf("URL is not on %s, the most common host of the HAR file (the specification has no "+
+			"absolute servers)", m.host)
+	}
+	return "URL does not match a prefix of the allow list (the specification has no absolute servers)"
+}
 
+// mostCommonHost returns the host most entries of a HAR file were sent to, the first one found wins a tie.
+func mostCommonHost(har *harhar.HAR) string {
+	counts := make(map[string]int)
+	host := ""
+	for _, entry := range har.Log.Entries {
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		counts[u.Host]++
+		if counts[u.Host] > counts[host] {
+			host = u.Host
+		}
+	}
+	return host
 }
@@ -0,0 +1,246 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package har
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pb33f/harhar"
+	"github.com/pb33f/libopenapi"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/wiretap/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var petSpec = `openapi: 3.1.0
+info:
+  title: pets
+  version: 1.0.0
+servers:
+  - url: https://{env}.pets.com/v1
+    variables:
+      env:
+        default: api
+paths:
+  /pets/{id}:
+    get:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        "200":
+          description: a pet
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [name]
+                properties:
+                  name:
+                    type: string`
+
+func petModel(t *testing.T) *v3.Document {
+	d, err := libopenapi.NewDocument([]byte(petSpec))
+	require.NoError(t, err)
+	m, errs := d.BuildV3Model()
+	require.Empty(t, errs)
+	return &m.Model
+}
+
+func petEntry(url, body string) harhar.Entry {
+	return harhar.Entry{
+		Request: harhar.Request{Method: "GET", URL: url},
+		Response: harhar.Response{
+			StatusCode: 200,
+			Cookies:    []harhar.Cookie{},
+			Body:       harhar.BodyResponseType{MIMEType: "application/json", Content: body},
+		},
+	}
+}
+
+func testConfig() *shared.WiretapConfiguration {
+	return &shared.WiretapConfiguration{
+		Contract: "pets.yaml",
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError})),
+	}
+}
+
+func TestValidateHAR(t *testing.T) {
+	harFile := &harhar.HAR{Log: harhar.Log{Entries: []harhar.Entry{
+		petEntry("https://api.pets.com/v1/pets/1", `{"name":"fido"}`),
+		petEntry("https://staging.pets.com/v1/pets/2", `{"age":3}`),
+		petEntry("https://cdn.pets.com/logo.png", ""),
+		petEntry("https://api.pets.com/v1/pets/3", `{"name":"rex"}`),
+		petEntry("https://proxy.local/legacy/pets/4", `{"name":"ada"}`),
+		{Request: harhar.Request{Method: "GET", URL: "http://bad host/"}},
+	}}}
+	config := testConfig()
+	config.HARExclude = []string{"/v1/pets/3"}
+	config.HARPathAllowList = []string{"/legacy"}
+
+	report, err := ValidateHAR(harFile, petModel(t), config)
+	require.NoError(t, err)
+
+	var statuses []string
+	for _, entry := range report.Entries {
+		statuses = append(statuses, entry.Status)
+	}
+	assert.Equal(t, []string{EntryValid, EntryInvalid, EntrySkipped, EntrySkipped, EntryValid, EntryError}, statuses)
+	assert.Equal(t, 6, report.Total)
+	assert.Equal(t, 2, report.Valid)
+	assert.Equal(t, 1, report.Invalid)
+	assert.Equal(t, 2, report.Skipped)
+	assert.Equal(t, 1, report.Errors)
+	assert.Contains(t, report.Entries[2].Reason, "does not match a server")
+	assert.Equal(t, "excluded by HAR exclude glob '/v1/pets/3'", report.Entries[3].Reason)
+	assert.Equal(t, "/pets/4", report.Entries[4].Path)
+	assert.NotEmpty(t, report.Violations())
+
+	config.HARInclude = []string{"https://staging.*"}
+	report, err = ValidateHAR(harFile, petModel(t), config)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Invalid)
+	assert.Zero(t, report.Valid)
+
+	config.HARInclude = []string{"[unclosed"}
+	_, err = ValidateHAR(harFile, petModel(t), config)
+	assert.ErrorContains(t, err, "invalid HAR glob")
+}
+
+func TestValidateHAR_RelativeServers(t *testing.T) {
+	d, err := libopenapi.NewDocument([]byte(strings.Replace(petSpec, "https://{env}.pets.com/v1", "/v1", 1)))
+	require.NoError(t, err)
+	m, errs := d.BuildV3Model()
+	require.Empty(t, errs)
+
+	harFile := &harhar.HAR{Log: harhar.Log{Entries: []harhar.Entry{
+		petEntry("https://api.pets.com/v1/pets/1", `{"name":"fido"}`),
+		petEntry("https://cdn.pets.com/logo.png", ""),
+		petEntry("https://api.pets.com/v1/pets/2", `{"age":3}`),
+		petEntry("https://analytics.io/collect", ""),
+	}}}
+
+	// without include globs or an allow list, only the most common host is validated.
+	report, err := ValidateHAR(harFile, &m.Model, testConfig())
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Valid)
+	assert.Equal(t, 1, report.Invalid)
+	assert.Equal(t, 2, report.Skipped)
+	assert.Contains(t, report.Entries[1].Reason, "not on api.pets.com")
+
+	// the allow list chooses the entries to validate.
+	config := testConfig()
+	config.HARPathAllowList = []string{"/v1/pets/1"}
+	report, err = ValidateHAR(harFile, &m.Model, config)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Skipped)
+
+	// as do the include globs.
+	config = testConfig()
+	config.HARInclude = []string{"/v1/pets/*"}
+	report, err = ValidateHAR(harFile, &m.Model, config)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Valid)
+	assert.Equal(t, 1, report.Invalid)
+	assert.Equal(t, 2, report.Skipped)
+}
+
+func TestRenderValidationReport(t *testing.T) {
+	harFile := &harhar.HAR{Log: harhar.Log{Entries: []harhar.Entry{
+		petEntry("https://api.pets.com/v1/pets/1", `{"name":"fido"}`),
+		petEntry("https://api.pets.com/v1/pets/2", `{"age":3}`),
+		petEntry("https://cdn.pets.com/logo.png", ""),
+	}}}
+	report, err := ValidateHAR(harFile, petModel(t), testConfig())
+	require.NoError(t, err)
+
+	b, err := RenderValidationReport("", report)
+	require.NoError(t, err)
+	var decoded ValidationReport
+	require.NoError(t, json.Unmarshal(b, &decoded))
+	assert.Equal(t, 1, decoded.Invalid)
+
+	b, err = RenderValidationReport(ReportFormatJUnit, report)
+	require.NoError(t, err)
+	junit := string(b)
+	assert.True(t, strings.HasPrefix(junit, "<?xml"))
+	assert.Contains(t, junit, `<testsuite name="HAR validation against pets.yaml" tests="3" failures="1" errors="0" skipped="1">`)
+	assert.Contains(t, junit, `<failure message="1 violation" type="contract-violation">`)
+
+	b, err = RenderValidationReport(ReportFormatSARIF, report)
+	require.NoError(t, err)
+	var sarif map[string]any
+	require.NoError(t, json.Unmarshal(b, &sarif))
+	assert.Equal(t, "2.1.0", sarif["version"])
+	results := sarif["runs"].([]any)[0].(map[string]any)["results"].([]any)
+	require.Len(t, results, 1)
+	assert.Contains(t, results[0].(map[string]any)["message"].(map[string]any)["text"], "GET https://api.pets.com/v1/pets/2")
+
+	_, err = RenderValidationReport("csv", report)
+	assert.ErrorContains(t, err, "unknown report format 'csv'")
+	assert.True(t, IsReportFormat("SARIF"))
+	assert.False(t, IsReportFormat("csv"))
+	assert.Equal(t, ".json", ReportExtension(""))
+	assert.Equal(t, ".xml", ReportExtension("JUnit"))
+	assert.Equal(t, ".sarif", ReportExtension(ReportFormatSARIF))
+}
+
+func TestValidateHAR_ValidationRules(t *testing.T) {
+	harFile := &harhar.HAR{Log: harhar.Log{Entries: []harhar.Entry{
+		petEntry("https://api.pets.com/v1/pets/1", `{"age":3}`),
+		petEntry("https://api.pets.com/v1/pets/abc", `{"name":"rex"}`),
+	}}}
+	config := testConfig()
+	config.ValidationRules = []*shared.WiretapValidationRule{
+		{Violation: "response/schema/required", Severity: shared.SeverityWarning},
+		{Violation: "parameter/*", Severity: shared.SeverityOff},
+	}
+	require.NoError(t, config.CompileValidationRules())
+
+	report, err := ValidateHAR(harFile, petModel(t), config)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Valid)
+	assert.Zero(t, report.Invalid)
+	assert.Equal(t, 1, report.Warnings)
+	require.Len(t, report.Entries[0].Notices, 1)
+	assert.Equal(t, "response", report.Entries[0].Notices[0].ValidationType)
+	assert.Empty(t, report.Entries[1].Notices)
+
+	assert.False(t, report.Exceeds(shared.SeverityError))
+	assert.True(t, report.Exceeds(shared.SeverityWarning))
+	assert.True(t, report.Exceeds(shared.SeverityInfo))
+
+	b, err := RenderValidationReport(ReportFormatSARIF, report)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"level": "warning"`)
+	b, err = RenderValidationReport(ReportFormatJUnit, report)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "<system-out>warning: 200 response body")
+}
+
+func TestValidateHAR_ShadowAPI(t *testing.T) {
+	harFile := &harhar.HAR{Log: harhar.Log{Entries: []harhar.Entry{
+		petEntry("https://api.pets.com/v1/pets/1", `{"name":"fido"}`),
+		petEntry("https://api.pets.com/v1/owners/1", `{}`),
+		petEntry("https://api.pets.com/v1/owners/2", `{}`),
+		petEntry("https://cdn.pets.com/logo.png", ""),
+	}}}
+	report, err := ValidateHAR(harFile, petModel(t), testConfig())
+	require.NoError(t, err)
+
+	// skipped entries are not checked.
+	require.Len(t, report.ShadowAPI, 1)
+	assert.Equal(t, "/owners/{id}", report.ShadowAPI[0].Path)
+	assert.Equal(t, 2, report.ShadowAPI[0].Count)
+}
@@ -0,0 +1,67 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package learn
+
+// Document is a learned OpenAPI 3.1 document, only the parts that can be learned from traffic are described.
+type Document struct {
+	OpenAPI    string              `json:"openapi" yaml:"openapi"`
+	Info       *Info               `json:"info" yaml:"info"`
+	Servers    []*Server           `json:"servers,omitempty" yaml:"servers,omitempty"`
+	Paths      map[string]PathItem `json:"paths" yaml:"paths"`
+	Components *Components         `json:"components,omitempty" yaml:"components,omitempty"`
+}
+
+type Info struct {
+	Title   string `json:"title" yaml:"title"`
+	Version string `json:"version" yaml:"version"`
+}
+
+type Server struct {
+	URL string `json:"url" yaml:"url"`
+}
+
+// PathItem holds the operations of a path, keyed by their lowercase method.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationId string                `json:"operationId" yaml:"operationId"`
+	Parameters  []*Parameter          `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses" yaml:"responses"`
+	Security    []map[string][]string `json:"security,omitempty" yaml:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name" yaml:"name"`
+	In       string  `json:"in" yaml:"in"`
+	Required bool    `json:"required,omitempty" yaml:"required,omitempty"`
+	Schema   *Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty" yaml:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content" yaml:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description" yaml:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
+}
+
+type Components struct {
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty" yaml:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type   string `json:"type" yaml:"type"`
+	Scheme string `json:"scheme,omitempty" yaml:"scheme,omitempty"`
+	In     string `json:"in,omitempty" yaml:"in,omitempty"`
+	Name   string `json:"name,omitempty" yaml:"name,omitempty"`
+}
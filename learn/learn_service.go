@@ -0,0 +1,86 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package learn
+
+import (
+	"log/slog"
+
+	"github.com/pb33f/ranch/bus"
+	"github.com/pb33f/ranch/model"
+	"github.com/pb33f/ranch/service"
+	"github.com/pb33f/wiretap/controls"
+	"github.com/pb33f/wiretap/daemon"
+	"github.com/pb33f/wiretap/shared"
+	"github.com/pterm/pterm"
+)
+
+const (
+	LearnServiceChan       = "learn-service"
+	GetLearnedSpecRequest  = "get-learned-spec"
+	DefaultLearnOutputFile = "wiretap-learned.yaml"
+)
+
+// LearnService learns an OpenAPI document from every transaction proxied to the API, and writes it to the output
+// file when wiretap shuts down. The document learned so far can be requested at any time.
+type LearnService struct {
+	controlsStore bus.BusStore
+	learner       *Learner
+	logger        *slog.Logger
+}
+
+// NewLearnService creates a learn service, learns from the loaded HAR file, if there is one, and registers its
+// learner as a traffic observer of the wiretap service.
+func NewLearnService(wiretapService *daemon.WiretapService, config *shared.WiretapConfiguration,
+	logger *slog.Logger) *LearnService {
+	learner := NewLearner(config.Learn)
+	if config.RedirectURL != "" {
+		learner.Servers = []string{config.RedirectURL}
+	}
+	if config.HARFile != nil {
+		learned, skipped := learner.LearnHAR(config.HARFile)
+		logger.Info("learned from HAR file", "entries", learned, "skipped", skipped)
+	}
+	wiretapService.AddTrafficObserver(learner)
+	return &LearnService{
+		controlsStore: bus.GetBus().GetStoreManager().CreateStore(controls.ControlServiceChan),
+		learner:       learner,
+		logger:        logger,
+	}
+}
+
+func (ls *LearnService) HandleServiceRequest(request *model.Request, core service.FabricServiceCore) {
+	switch request.RequestCommand {
+	case GetLearnedSpecRequest:
+		core.SendResponse(request, ls.learner.Document())
+	default:
+		core.HandleUnknownRequest(request)
+	}
+}
+
+// OnServerShutdown writes the learned document to the output file.
+func (ls *LearnService) OnServerShutdown() {
+	output := DefaultLearnOutputFile
+	if config := ls.config(); config != nil && config.Learn != nil && config.Learn.Output != "" {
+		output = config.Learn.Output
+	}
+	if err := ls.learner.WriteDocument(output); err != nil {
+		ls.logger.Error("unable to write learned OpenAPI document", "file", output, "error", err.Error())
+		return
+	}
+	pterm.Printf("📝 Learned %d %s, OpenAPI document saved to: %s\n", ls.learner.Operations(),
+		shared.Pluralize(ls.learner.Operations(), "operation", "operations"), pterm.LightMagenta(output))
+}
+
+// config returns the current wiretap configuration.
+func (ls *LearnService) config() *shared.WiretapConfiguration {
+	if ls.controlsStore == nil {
+		return nil
+	}
+	if config, ok := ls.controlsStore.GetValue(shared.ConfigKey).(*shared.WiretapConfiguration); ok {
+		return config
+	}
+	return nil
+}
@@ -0,0 +1,460 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+// Package learn drafts an OpenAPI 3.1 document from observed traffic, for services that have no contract yet.
+package learn
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pb33f/harhar"
+	"github.com/pb33f/wiretap/daemon"
+	"github.com/pb33f/wiretap/shared"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultTitle is the title of a learned document, when none is configured.
+	DefaultTitle = "Learned API"
+	// DefaultVersion is the version of a learned document, when none is configured.
+	DefaultVersion = "0.0.1"
+)
+
+// ignoredHeaders are request headers set by clients, proxies and browsers, they are not parameters of the API.
+var ignoredHeaders = []string{
+	"Accept", "Accept-Charset", "Accept-Encoding", "Accept-Language", "Authorization", "Cache-Control", "Connection",
+	"Content-Length", "Content-Type", "Cookie", "Dnt", "Host", "If-Match", "If-Modified-Since", "If-None-Match",
+	"Keep-Alive", "Origin", "Postman-Token", "Pragma", "Proxy-Authorization", "Referer", "Te", "Upgrade",
+	"Upgrade-Insecure-Requests", "User-Agent", "Via", "X-Api-Key", "Api-Key", "X-Forwarded-For", "X-Forwarded-Host",
+	"X-Forwarded-Port", "X-Forwarded-Proto", "X-Real-Ip", "X-Request-Id", "Traceparent", "Tracestate",
+}
+
+// operation is everything observed for a method on a path template.
+type operation struct {
+	method     string
+	path       string
+	count      int
+	pathParams map[string]*shape
+	pathOrder  []string
+	query      map[string]*parameter
+	headers    map[string]*parameter
+	bodies     map[string]*shape
+	withBody   int
+	responses  map[int]map[string]*shape
+	security   map[string]int
+}
+
+// parameter is a query parameter or header, and how many requests it was observed in.
+type parameter struct {
+	name  string
+	shape *shape
+	seen  int
+}
+
+// Learner builds an OpenAPI document from every transaction it observes. It is safe for concurrent use, and can
+// be registered as a traffic observer of the wiretap service.
+type Learner struct {
+	// Title and Version are the info of the document.
+	Title   string
+	Version string
+	// Servers are the servers of the document. When none are set, the origins of observed absolute URLs are used.
+	Servers []string
+
+	lock       sync.Mutex
+	operations map[string]*operation
+	origins    map[string]bool
+}
+
+// NewLearner creates a learner for a configuration, the configuration can be nil.
+func NewLearner(config *shared.WiretapLearnConfig) *Learner {
+	learner := &Learner{
+		Title:      DefaultTitle,
+		Version:    DefaultVersion,
+		operations: make(map[string]*operation),
+		origins:    make(map[string]bool),
+	}
+	if config != nil {
+		if config.Title != "" {
+			learner.Title = config.Title
+		}
+		if config.Version != "" {
+			learner.Version = config.Version
+		}
+	}
+	return learner
+}
+
+// ObserveTransaction learns from a transaction proxied to the API.
+func (l *Learner) ObserveTransaction(transaction *daemon.ObservedTransaction) {
+	l.Observe(transaction.Request.Method, transaction.Request.URL, transaction.Request.Header,
+		transaction.RequestBody, transaction.StatusCode, transaction.ResponseHeader, transaction.ResponseBody)
+}
+
+// LearnHAR learns from every entry of a HAR document, entries that cannot be read are skipped and counted.
+func (l *Learner) LearnHAR(harFile *harhar.HAR) (learned int, skipped int) {
+	for _, entry := range harFile.Log.Entries {
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil || entry.Response.StatusCode == 0 {
+			skipped++
+			continue
+		}
+		requestHeader := http.Header{}
+		for _, h := range entry.Request.Headers {
+			requestHeader.Add(h.Name, h.Value)
+		}
+		if entry.Request.Body.MIMEType != "" && requestHeader.Get("Content-Type") == "" {
+			requestHeader.Set("Content-Type", entry.Request.Body.MIMEType)
+		}
+		responseHeader := http.Header{}
+		for _, h := range entry.Response.Headers {
+			responseHeader.Add(h.Name, h.Value)
+		}
+		if entry.Response.Body.MIMEType != "" && responseHeader.Get("Content-Type") == "" {
+			responseHeader.Set("Content-Type", entry.Response.Body.MIMEType)
+		}
+		responseBody := []byte(entry.Response.Body.Content)
+		if strings.EqualFold(entry.Response.Body.Encoding, "base64") {
+			if responseBody, err = base64.StdEncoding.DecodeString(entry.Response.Body.Content); err != nil {
+				skipped++
+				continue
+			}
+		}
+		l.Observe(entry.Request.Method, u, requestHeader, []byte(entry.Request.Body.Content),
+			entry.Response.StatusCode, responseHeader, responseBody)
+		learned++
+	}
+	return learned, skipped
+}
+
+// Observe learns from a request and its response.
+func (l *Learner) Observe(method string, u *url.URL, requestHeader http.Header, requestBody []byte,
+	status int, responseHeader http.Header, responseBody []byte) {
+
+	method = strings.ToUpper(method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	template, pathParams := templatePath(path)
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if u.Scheme != "" && u.Host != "" {
+		l.origins[u.Scheme+"://"+u.Host] = true
+	}
+
+	key := method + " " + template
+	op, found := l.operations[key]
+	if !found {
+		op = &operation{
+			method:     method,
+			path:       template,
+			pathParams: make(map[string]*shape),
+			query:      make(map[string]*parameter),
+			headers:    make(map[string]*parameter),
+			bodies:     make(map[string]*shape),
+			responses:  make(map[int]map[string]*shape),
+			security:   make(map[string]int),
+		}
+		for _, p := range pathParams {
+			op.pathOrder = append(op.pathOrder, p.name)
+		}
+		l.operations[key] = op
+	}
+	op.count++
+
+	for _, p := range pathParams {
+		op.pathParams[p.name] = mergeShapes(op.pathParams[p.name], scalarShape(p.value))
+	}
+
+	for name, values := range u.Query() {
+		var s *shape
+		if len(values) > 1 {
+			s = &shape{types: map[string]bool{"array": true}}
+			for _, v := range values {
+				s.items = mergeShapes(s.items, scalarShape(v))
+			}
+		} else {
+			s = scalarShape(values[0])
+		}
+		observeParameter(op.query, name, s)
+	}
+
+	for name, values := range requestHeader {
+		name = http.CanonicalHeaderKey(name)
+		if containsFold(ignoredHeaders, name) || strings.HasPrefix(name, "Sec-") || len(values) == 0 {
+			continue
+		}
+		observeParameter(op.headers, name, stringShape(values[0]))
+	}
+	if scheme := securityScheme(requestHeader); scheme != "" {
+		op.security[scheme]++
+	}
+
+	if len(requestBody) > 0 {
+		mediaType, s := bodyShape(requestHeader.Get("Content-Type"), requestBody)
+		op.bodies[mediaType] = mergeShapes(op.bodies[mediaType], s)
+		op.withBody++
+	}
+
+	content, found := op.responses[status]
+	if !found {
+		content = make(map[string]*shape)
+		op.responses[status] = content
+	}
+	if strings.EqualFold(responseHeader.Get("Content-Encoding"), "gzip") {
+		responseBody = gunzip(responseBody)
+	}
+	if len(responseBody) > 0 {
+		mediaType, s := bodyShape(responseHeader.Get("Content-Type"), responseBody)
+		content[mediaType] = mergeShapes(content[mediaType], s)
+	}
+}
+
+// gunzip decompresses a body, a body that cannot be decompressed is not learned from.
+func gunzip(body []byte) []byte {
+	reader, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil
+	}
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		return nil
+	}
+	return decoded
+}
+
+func observeParameter(parameters map[string]*parameter, name string, s *shape) {
+	p, found := parameters[name]
+	if !found {
+		p = &parameter{name: name}
+		parameters[name] = p
+	}
+	p.shape = mergeShapes(p.shape, s)
+	p.seen++
+}
+
+// bodyShape returns the media type and shape of a body. JSON and form bodies are described by their content,
+// anything else is a string.
+func bodyShape(contentType string, body []byte) (string, *shape) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "" {
+		mediaType = "application/octet-stream"
+		if json.Valid(body) {
+			mediaType = "application/json"
+		}
+	}
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var value any
+		if json.Unmarshal(body, &value) == nil {
+			return mediaType, inferShape(value)
+		}
+	case mediaType == "application/x-www-form-urlencoded":
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			form := make(map[string]any)
+			for key := range values {
+				form[key] = values.Get(key)
+			}
+			return mediaType, inferShape(form)
+		}
+	}
+	return mediaType, &shape{types: map[string]bool{"string": true}}
+}
+
+// securityScheme returns the name of the security scheme a request used, if any.
+func securityScheme(header http.Header) string {
+	authorization := strings.ToLower(header.Get("Authorization"))
+	switch {
+	case strings.HasPrefix(authorization, "bearer "):
+		return "bearerAuth"
+	case strings.HasPrefix(authorization, "basic "):
+		return "basicAuth"
+	case header.Get("X-Api-Key") != "":
+		return "apiKeyAuth"
+	}
+	return ""
+}
+
+// securitySchemes are the schemes a request can be observed to use.
+var securitySchemes = map[string]*SecurityScheme{
+	"bearerAuth": {Type: "http", Scheme: "bearer"},
+	"basicAuth":  {Type: "http", Scheme: "basic"},
+	"apiKeyAuth": {Type: "apiKey", In: "header", Name: "X-Api-Key"},
+}
+
+// Operations returns the number of operations learned so far.
+func (l *Learner) Operations() int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return len(l.operations)
+}
+
+// Document returns the OpenAPI 3.1 document learned so far. Parameters, properties and request bodies are
+// required when every request had them.
+func (l *Learner) Document() *Document {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	doc := &Document{
+		OpenAPI: "3.1.0",
+		Info:    &Info{Title: l.Title, Version: l.Version},
+		Paths:   make(map[string]PathItem),
+	}
+	servers := l.Servers
+	if len(servers) == 0 {
+		for origin := range l.origins {
+			servers = append(servers, origin)
+		}
+		sort.Strings(servers)
+	}
+	for _, server := range servers {
+		doc.Servers = append(doc.Servers, &Server{URL: server})
+	}
+
+	keys := make([]string, 0, len(l.operations))
+	for key := range l.operations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	usedSchemes := make(map[string]bool)
+	for _, key := range keys {
+		op := l.operations[key]
+		item, found := doc.Paths[op.path]
+		if !found {
+			item = make(PathItem)
+			doc.Paths[op.path] = item
+		}
+		item[strings.ToLower(op.method)] = op.render(usedSchemes)
+	}
+
+	if len(usedSchemes) > 0 {
+		doc.Components = &Components{SecuritySchemes: make(map[string]*SecurityScheme)}
+		for name := range usedSchemes {
+			doc.Components.SecuritySchemes[name] = securitySchemes[name]
+		}
+	}
+	return doc
+}
+
+func (op *operation) render(usedSchemes map[string]bool) *Operation {
+	rendered := &Operation{
+		OperationId: operationId(op.method, op.path),
+		Responses:   make(map[string]*Response),
+	}
+	for _, name := range op.pathOrder {
+		rendered.Parameters = append(rendered.Parameters, &Parameter{
+			Name: name, In: "path", Required: true, Schema: op.pathParams[name].schema(),
+		})
+	}
+	for _, in := range []string{"query", "header"} {
+		parameters := op.query
+		if in == "header" {
+			parameters = op.headers
+		}
+		names := make([]string, 0, len(parameters))
+		for name := range parameters {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			p := parameters[name]
+			rendered.Parameters = append(rendered.Parameters, &Parameter{
+				Name: name, In: in, Required: p.seen == op.count, Schema: p.shape.schema(),
+			})
+		}
+	}
+
+	if len(op.bodies) > 0 {
+		rendered.RequestBody = &RequestBody{
+			Required: op.withBody == op.count,
+			Content:  renderContent(op.bodies),
+		}
+	}
+
+	for status, content := range op.responses {
+		description := http.StatusText(status)
+		if description == "" {
+			description = fmt.Sprintf("status %d", status)
+		}
+		rendered.Responses[strconv.Itoa(status)] = &Response{
+			Description: description,
+			Content:     renderContent(content),
+		}
+	}
+
+	var schemes []string
+	for name := range op.security {
+		schemes = append(schemes, name)
+	}
+	sort.Strings(schemes)
+	anonymous := op.count
+	for _, name := range schemes {
+		usedSchemes[name] = true
+		rendered.Security = append(rendered.Security, map[string][]string{name: {}})
+		anonymous -= op.security[name]
+	}
+	// some requests were made without credentials, so they are optional.
+	if len(schemes) > 0 && anonymous > 0 {
+		rendered.Security = append(rendered.Security, map[string][]string{})
+	}
+	return rendered
+}
+
+func renderContent(shapes map[string]*shape) map[string]*MediaType {
+	if len(shapes) == 0 {
+		return nil
+	}
+	content := make(map[string]*MediaType)
+	for mediaType, s := range shapes {
+		content[mediaType] = &MediaType{Schema: s.schema()}
+	}
+	return content
+}
+
+// WriteDocument writes the document learned so far, as JSON when the file has a .json extension and as YAML
+// otherwise.
+func (l *Learner) WriteDocument(file string) error {
+	doc := l.Document()
+	var out []byte
+	var err error
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		out, err = json.MarshalIndent(doc, "", "  ")
+	} else {
+		out, err = yaml.Marshal(doc)
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, out, 0644)
+}
+
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package learn
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/wiretap/capture"
+	"github.com/pb33f/wiretap/daemon"
+	"github.com/pb33f/wiretap/har"
+	"github.com/pb33f/wiretap/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const petTraffic = `{"request":{"method":"GET","url":"https://api.pets.com/pets/1?limit=10","headers":{"Authorization":"Bearer a","X-Tenant":"acme"}},"response":{"status":200,"headers":{"Content-Type":"application/json"},"body":{"id":1,"name":"fido","born":"2020-01-02T03:04:05Z","tag":null}}}
+{"request":{"method":"GET","url":"https://api.pets.com/pets/2","headers":{"X-Tenant":"acme"}},"response":{"status":200,"headers":{"Content-Type":"application/json"},"body":{"id":2,"name":"rex","tag":"good","weight":4.5}}}
+{"request":{"method":"GET","url":"https://api.pets.com/pets/3","headers":{"X-Tenant":"acme"}},"response":{"status":404,"headers":{"Content-Type":"application/problem+json"},"body":{"title":"not found"}}}
+{"request":{"method":"POST","url":"https://api.pets.com/owners/4bd1c4a2-79a4-4e0f-9d3c-1b4e0c0f3a61/pets","headers":{"Content-Type":"application/json"},"body":{"name":"tom","tags":["cat"]}},"response":{"status":201,"headers":{"Content-Type":"application/json"},"body":{"id":3}}}
+`
+
+func TestTemplatePath(t *testing.T) {
+	path, params := templatePath("/owners/4bd1c4a2-79a4-4e0f-9d3c-1b4e0c0f3a61/categories/12/pets")
+	assert.Equal(t, "/owners/{ownerId}/categories/{categoryId}/pets", path)
+	require.Len(t, params, 2)
+	assert.Equal(t, "12", params[1].value)
+
+	path, _ = templatePath("/1/2")
+	assert.Equal(t, "/{id}/{id2}", path)
+	assert.Equal(t, "getOwnersByOwnerIdPets", operationId("GET", "/owners/{ownerId}/pets"))
+}
+
+func TestMergeShapes(t *testing.T) {
+	s := inferShape(map[string]any{"a": float64(1), "b": "x", "c": nil})
+	s = mergeShapes(s, inferShape(map[string]any{"a": 1.5, "c": "2024-01-01"}))
+	schema := s.schema()
+
+	assert.Equal(t, "object", schema.Type)
+	assert.Equal(t, []string{"a", "c"}, schema.Required)
+	assert.Equal(t, "number", schema.Properties["a"].Type)
+	assert.Equal(t, []string{"null", "string"}, schema.Properties["c"].Type)
+	assert.Equal(t, "date", schema.Properties["c"].Format)
+}
+
+func TestLearner_Document(t *testing.T) {
+	harFile, err := capture.FromNDJSON([]byte(petTraffic))
+	require.NoError(t, err)
+
+	learner := NewLearner(&shared.WiretapLearnConfig{Title: "pets"})
+	learned, skipped := learner.LearnHAR(harFile)
+	assert.Equal(t, 4, learned)
+	assert.Zero(t, skipped)
+	assert.Equal(t, 2, learner.Operations())
+
+	doc := learner.Document()
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+	assert.Equal(t, "pets", doc.Info.Title)
+	assert.Equal(t, "https://api.pets.com", doc.Servers[0].URL)
+
+	get := doc.Paths["/pets/{petId}"]["get"]
+	require.NotNil(t, get)
+	assert.Equal(t, "getPetsByPetId", get.OperationId)
+	require.Len(t, get.Parameters, 3)
+	assert.Equal(t, &Parameter{Name: "petId", In: "path", Required: true, Schema: &Schema{Type: "integer"}},
+		get.Parameters[0])
+	assert.Equal(t, &Parameter{Name: "limit", In: "query", Schema: &Schema{Type: "integer"}}, get.Parameters[1])
+	assert.Equal(t, &Parameter{Name: "X-Tenant", In: "header", Required: true, Schema: &Schema{Type: "string"}},
+		get.Parameters[2])
+	assert.Equal(t, []map[string][]string{{"bearerAuth": {}}, {}}, get.Security)
+
+	pet := get.Responses["200"].Content["application/json"].Schema
+	assert.Equal(t, []string{"id", "name", "tag"}, pet.Required)
+	assert.Equal(t, "date-time", pet.Properties["born"].Format)
+	assert.Equal(t, []string{"null", "string"}, pet.Properties["tag"].Type)
+	assert.Contains(t, get.Responses["404"].Content, "application/problem+json")
+
+	post := doc.Paths["/owners/{ownerId}/pets"]["post"]
+	require.NotNil(t, post)
+	assert.Equal(t, "uuid", post.Parameters[0].Schema.Format)
+	assert.True(t, post.RequestBody.Required)
+	assert.Equal(t, "string", post.RequestBody.Content["application/json"].Schema.Properties["tags"].Items.Type)
+	assert.Equal(t, "bearer", doc.Components.SecuritySchemes["bearerAuth"].Scheme)
+
+	// the learned document is valid, and the traffic it was learned from complies with it.
+	file := filepath.Join(t.TempDir(), "learned.yaml")
+	require.NoError(t, learner.WriteDocument(file))
+	spec, err := os.ReadFile(file)
+	require.NoError(t, err)
+	document, err := libopenapi.NewDocument(spec)
+	require.NoError(t, err)
+	model, errs := document.BuildV3Model()
+	require.Empty(t, errs)
+
+	report, err := har.ValidateHAR(harFile, &model.Model, &shared.WiretapConfiguration{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError})),
+	})
+	require.NoError(t, err)
+	for _, entry := range report.Entries {
+		assert.Equal(t, har.EntryValid, entry.Status, "%s %s: %v %v", entry.Method, entry.URL,
+			entry.RequestValidation, entry.ResponseValidation)
+	}
+}
+
+func TestNewLearnService_HAR(t *testing.T) {
+	harFile, err := capture.FromNDJSON([]byte(petTraffic))
+	require.NoError(t, err)
+
+	config := &shared.WiretapConfiguration{Learn: &shared.WiretapLearnConfig{Enabled: true}, HARFile: harFile}
+	ls := NewLearnService(&daemon.WiretapService{}, config,
+		slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError})))
+	assert.Equal(t, 2, ls.learner.Operations())
+}
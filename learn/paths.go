@@ -0,0 +1,98 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package learn
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	numericSegment  = regexp.MustCompile(`^\d+$`)
+	nonAlphaNumeric = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+)
+
+// pathParameter is a segment of an observed path that identifies a resource, with the value observed.
+type pathParameter struct {
+	name  string
+	value string
+}
+
+// templatePath collapses the segments of a path that identify a resource (numbers and UUIDs) into parameters, named
+// after the segment before them, such as `/pets/{petId}` for `/pets/12`.
+func templatePath(path string) (string, []*pathParameter) {
+	segments := strings.Split(path, "/")
+	var parameters []*pathParameter
+	names := make(map[string]int)
+	for i, segment := range segments {
+		if !numericSegment.MatchString(segment) && !uuidPattern.MatchString(segment) {
+			continue
+		}
+		name := "id"
+		if i > 0 && segments[i-1] != "" && !strings.HasPrefix(segments[i-1], "{") {
+			name = camelCase(singular(segments[i-1])) + "Id"
+		}
+		names[name]++
+		if names[name] > 1 {
+			name = fmt.Sprintf("%s%d", name, names[name])
+		}
+		parameters = append(parameters, &pathParameter{name: name, value: segment})
+		segments[i] = "{" + name + "}"
+	}
+	return strings.Join(segments, "/"), parameters
+}
+
+// singular returns the singular of a plural resource name, such as `category` for `categories`.
+func singular(word string) string {
+	lower := strings.ToLower(word)
+	switch {
+	case strings.HasSuffix(lower, "ies") && len(word) > 3:
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(lower, "sses"), strings.HasSuffix(lower, "xes"), strings.HasSuffix(lower, "ches"),
+		strings.HasSuffix(lower, "shes"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(lower, "s") && !strings.HasSuffix(lower, "ss") && len(word) > 1:
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+// camelCase joins the words of a name, such as `petOwner` for `pet-owner`.
+func camelCase(name string) string {
+	words := nonAlphaNumeric.Split(name, -1)
+	var b strings.Builder
+	for _, word := range words {
+		if word == "" {
+			continue
+		}
+		if b.Len() == 0 {
+			b.WriteString(strings.ToLower(word[:1]) + word[1:])
+		} else {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+// operationId names an operation after its method and path, such as `getPetsByPetId` for `GET /pets/{petId}`.
+func operationId(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" {
+			continue
+		}
+		if strings.HasPrefix(segment, "{") {
+			segment = "by-" + strings.Trim(segment, "{}")
+		}
+		word := camelCase(segment)
+		if word != "" {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,190 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package learn
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Schema is a JSON schema inferred from observed values. The type is a single type, or a list of types when values
+// of more than one type were observed (such as a string that can be null).
+type Schema struct {
+	Type       any                `json:"type,omitempty" yaml:"type,omitempty"`
+	Format     string             `json:"format,omitempty" yaml:"format,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty" yaml:"required,omitempty"`
+	Items      *Schema            `json:"items,omitempty" yaml:"items,omitempty"`
+}
+
+// shape is the schema of every value observed at the same place, it is merged with each new observation.
+type shape struct {
+	types      map[string]bool
+	format     string
+	properties map[string]*shape
+	// seen counts how many objects each property was observed in, objects counts the objects observed.
+	seen    map[string]int
+	objects int
+	items   *shape
+}
+
+// inferShape returns the shape of a decoded JSON value.
+func inferShape(value any) *shape {
+	s := &shape{types: make(map[string]bool)}
+	switch v := value.(type) {
+	case nil:
+		s.types["null"] = true
+	case bool:
+		s.types["boolean"] = true
+	case float64:
+		if v == float64(int64(v)) {
+			s.types["integer"] = true
+		} else {
+			s.types["number"] = true
+		}
+	case string:
+		s.types["string"] = true
+		s.format = stringFormat(v)
+	case []any:
+		s.types["array"] = true
+		for _, item := range v {
+			s.items = mergeShapes(s.items, inferShape(item))
+		}
+	case map[string]any:
+		s.types["object"] = true
+		s.objects = 1
+		s.properties = make(map[string]*shape)
+		s.seen = make(map[string]int)
+		for key, property := range v {
+			s.properties[key] = inferShape(property)
+			s.seen[key] = 1
+		}
+	}
+	return s
+}
+
+// scalarShape returns the shape of a value observed as text, such as a query or path parameter.
+func scalarShape(value string) *shape {
+	s := &shape{types: make(map[string]bool)}
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		s.types["integer"] = true
+	} else if _, err = strconv.ParseFloat(value, 64); err == nil {
+		s.types["number"] = true
+	} else if value == "true" || value == "false" {
+		s.types["boolean"] = true
+	} else {
+		s.types["string"] = true
+		s.format = stringFormat(value)
+	}
+	return s
+}
+
+// stringShape returns the shape of a value that is always text, such as a header.
+func stringShape(value string) *shape {
+	return &shape{types: map[string]bool{"string": true}, format: stringFormat(value)}
+}
+
+// stringFormat recognises formats that can be told apart from any other string.
+func stringFormat(value string) string {
+	if uuidPattern.MatchString(value) {
+		return "uuid"
+	}
+	if _, err := time.Parse(time.RFC3339, value); err == nil {
+		return "date-time"
+	}
+	if _, err := time.Parse(time.DateOnly, value); err == nil {
+		return "date"
+	}
+	return ""
+}
+
+// mergeShapes combines the shapes of two values observed at the same place. Types are combined, formats are kept
+// only when they agree, and object properties are only required when every object had them.
+func mergeShapes(a, b *shape) *shape {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	hadString := a.types["string"]
+	for t := range b.types {
+		a.types[t] = true
+	}
+	if a.types["number"] && a.types["integer"] {
+		delete(a.types, "integer")
+	}
+	switch {
+	case !hadString:
+		a.format = b.format
+	case b.types["string"] && a.format != b.format:
+		a.format = ""
+	}
+
+	if b.properties != nil {
+		if a.properties == nil {
+			a.properties = make(map[string]*shape)
+			a.seen = make(map[string]int)
+		}
+		for key, property := range b.properties {
+			a.properties[key] = mergeShapes(a.properties[key], property)
+			a.seen[key] += b.seen[key]
+		}
+	}
+	a.objects += b.objects
+	a.items = mergeShapes(a.items, b.items)
+	return a
+}
+
+// schema renders a shape as a JSON schema.
+func (s *shape) schema() *Schema {
+	if s == nil {
+		return nil
+	}
+	schema := &Schema{}
+	var types []string
+	for t := range s.types {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	switch len(types) {
+	case 0:
+	case 1:
+		schema.Type = types[0]
+	default:
+		schema.Type = types
+	}
+	if s.types["string"] {
+		schema.Format = s.format
+	}
+	if len(s.properties) > 0 {
+		schema.Properties = make(map[string]*Schema)
+		for key, property := range s.properties {
+			schema.Properties[key] = property.schema()
+			if s.seen[key] == s.objects {
+				schema.Required = append(schema.Required, key)
+			}
+		}
+		sort.Strings(schema.Required)
+	}
+	if s.types["array"] {
+		schema.Items = s.items.schema()
+	}
+	return schema
+}
+
+// InferSchema returns the schema of decoded JSON values observed at the same place.
+func InferSchema(values ...any) *Schema {
+	var merged *shape
+	for _, value := range values {
+		merged = mergeShapes(merged, inferShape(value))
+	}
+	return merged.schema()
+}
@@ -0,0 +1,232 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: AGPL
+
+package mock
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	libopenapierrs "github.com/pb33f/libopenapi-validator/errors"
+	"github.com/pb33f/libopenapi-validator/helpers"
+	"github.com/pb33f/libopenapi-validator/paths"
+	"github.com/pb33f/libopenapi-validator/schema_validation"
+	"github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// OutboundCall is a request wiretap makes on behalf of the API, as described by a callback or webhook.
+type OutboundCall struct {
+	Name      string
+	Webhook   bool
+	Request   *http.Request
+	Operation *v3.Operation
+}
+
+// BuildCallbacks builds the requests described by the callbacks of the operation that handled the request. Callback
+// URLs are runtime expressions, resolved against the request and the mocked response. If an expression cannot be
+// resolved, the fallback target is used instead, if there is one.
+func (rme *ResponseMockEngine) BuildCallbacks(request *http.Request, response *MockResponse,
+	fallbackTarget string) ([]*OutboundCall, []error) {
+
+	if rme.doc == nil {
+		return nil, nil
+	}
+	pathItem, _, pathTemplate := paths.FindPath(request, rme.doc)
+	operation := rme.findOperation(request, pathItem)
+	if operation == nil || operation.Callbacks == nil || operation.Callbacks.Len() == 0 {
+		return nil, nil
+	}
+
+	var requestBody []byte
+	if request.Body != nil && request.Body != http.NoBody {
+		requestBody, _ = io.ReadAll(request.Body)
+		_ = request.Body.Close()
+		request.Body = io.NopCloser(bytes.NewBuffer(requestBody))
+	}
+
+	rc := &runtimeContext{
+		request:     request,
+		requestBody: requestBody,
+		pathParams:  extractPathParams(pathTemplate, request.URL.Path),
+	}
+	if response != nil {
+		rc.statusCode = response.StatusCode
+		rc.responseHeaders = response.Headers
+		rc.responseBody = response.Body
+	}
+
+	var calls []*OutboundCall
+	var errs []error
+	for cbPairs := operation.Callbacks.First(); cbPairs != nil; cbPairs = cbPairs.Next() {
+		if cbPairs.Value() == nil || cbPairs.Value().Expression == nil {
+			continue
+		}
+		for exPairs := cbPairs.Value().Expression.First(); exPairs != nil; exPairs = exPairs.Next() {
+			target, err := rc.expand(exPairs.Key())
+			if err != nil || !isAbsoluteURL(target) {
+				if fallbackTarget == "" {
+					if err == nil {
+						err = fmt.Errorf("callback URL '%s' is not an absolute URL", target)
+					}
+					errs = append(errs, fmt.Errorf("unable to resolve callback '%s': %w", cbPairs.Key(), err))
+					continue
+				}
+				target = fallbackTarget
+			}
+			built, bErrs := rme.buildOutboundCalls(cbPairs.Key(), target, exPairs.Value(), false)
+			calls = append(calls, built...)
+			errs = append(errs, bErrs...)
+		}
+	}
+	return calls, errs
+}
+
+// BuildWebhook builds the requests described by a named webhook, to be sent to the target URL.
+func (rme *ResponseMockEngine) BuildWebhook(name, target string) ([]*OutboundCall, error) {
+	if rme.doc == nil || rme.doc.Webhooks == nil {
+		return nil, fmt.Errorf("the specification does not define any webhooks")
+	}
+	pathItem := rme.doc.Webhooks.GetOrZero(name)
+	if pathItem == nil {
+		return nil, fmt.Errorf("webhook '%s' is not defined in the specification", name)
+	}
+	if !isAbsoluteURL(target) {
+		return nil, fmt.Errorf("webhook '%s' target '%s' is not an absolute URL", name, target)
+	}
+	calls, errs := rme.buildOutboundCalls(name, target, pathItem, true)
+	if len(errs) > 0 {
+		return calls, errs[0]
+	}
+	return calls, nil
+}
+
+func isAbsoluteURL(target string) bool {
+	u, err := url.Parse(target)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+// buildOutboundCalls creates a request, with a generated body, for every operation of a callback or webhook path item.
+func (rme *ResponseMockEngine) buildOutboundCalls(name, target string, pathItem *v3.PathItem,
+	webhook bool) ([]*OutboundCall, []error) {
+
+	if pathItem == nil {
+		return nil, nil
+	}
+	var calls []*OutboundCall
+	var errs []error
+	for opPairs := pathItem.GetOperations().First(); opPairs != nil; opPairs = opPairs.Next() {
+		op := opPairs.Value()
+		var body []byte
+		var contentType string
+		if op.RequestBody != nil && op.RequestBody.Content != nil && op.RequestBody.Content.Len() > 0 {
+			contentType = "application/json"
+			mt := op.RequestBody.Content.GetOrZero(contentType)
+			if mt == nil {
+				first := op.RequestBody.Content.First()
+				contentType, mt = first.Key(), first.Value()
+			}
+			generated, err := rme.mockEngine.GenerateMock(mt, "")
+			if err != nil {
+				errs = append(errs, fmt.Errorf("unable to generate body for '%s': %w", name, err))
+				continue
+			}
+			body = generated
+		}
+
+		req, err := http.NewRequest(strings.ToUpper(opPairs.Key()), target, bytes.NewReader(body))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to build request for '%s': %w", name, err))
+			continue
+		}
+		if contentType != "" {
+			req.Header.Set(helpers.ContentTypeHeader, contentType)
+		}
+		calls = append(calls, &OutboundCall{
+			Name:      name,
+			Webhook:   webhook,
+			Request:   req,
+			Operation: op,
+		})
+	}
+	return calls, errs
+}
+
+// ValidateOutboundResponse checks the response returned by the target of a callback or webhook against the
+// responses described for it.
+func (rme *ResponseMockEngine) ValidateOutboundResponse(call *OutboundCall,
+	response *http.Response) []*libopenapierrs.ValidationError {
+
+	newError := func(message, reason string) *libopenapierrs.ValidationError {
+		return &libopenapierrs.ValidationError{
+			ValidationType:    helpers.ResponseBodyValidation,
+			ValidationSubType: "callback",
+			Message:           message,
+			Reason:            reason,
+			RequestPath:       call.Request.URL.Path,
+			RequestMethod:     call.Request.Method,
+			SpecPath:          call.Name,
+		}
+	}
+
+	op := call.Operation
+	if op == nil || op.Responses == nil {
+		return nil
+	}
+	code := strconv.Itoa(response.StatusCode)
+	resp := op.Responses.Codes.GetOrZero(code)
+	if resp == nil {
+		resp = op.Responses.Codes.GetOrZero(code[:1] + "XX")
+	}
+	if resp == nil {
+		resp = op.Responses.Default
+	}
+	if resp == nil {
+		return []*libopenapierrs.ValidationError{newError(
+			fmt.Sprintf("%s '%s' returned an undefined status code %d", call.kind(), call.Name, response.StatusCode),
+			fmt.Sprintf("The response code %d is not defined for %s '%s'", response.StatusCode, call.kind(), call.Name),
+		)}
+	}
+	if resp.Content == nil || resp.Content.Len() == 0 {
+		return nil
+	}
+
+	contentType, _, _ := helpers.ExtractContentType(response.Header.Get(helpers.ContentTypeHeader))
+	mt := resp.Content.GetOrZero(contentType)
+	if mt == nil {
+		return []*libopenapierrs.ValidationError{newError(
+			fmt.Sprintf("%s '%s' returned an undefined content type '%s'", call.kind(), call.Name, contentType),
+			fmt.Sprintf("The content type '%s' is not defined for the %d response of %s '%s'",
+				contentType, response.StatusCode, call.kind(), call.Name),
+		)}
+	}
+	if mt.Schema == nil || !strings.Contains(contentType, "json") {
+		return nil
+	}
+
+	var body []byte
+	if response.Body != nil {
+		body, _ = io.ReadAll(response.Body)
+		_ = response.Body.Close()
+		response.Body = io.NopCloser(bytes.NewBuffer(body))
+	}
+	_, errs := schema_validation.NewSchemaValidator().ValidateSchemaBytes(mt.Schema.Schema(), body)
+	for _, e := range errs {
+		e.ValidationSubType = "callback"
+		e.RequestPath = call.Request.URL.Path
+		e.RequestMethod = call.Request.Method
+		e.SpecPath = call.Name
+	}
+	return errs
+}
+
+func (oc *OutboundCall) kind() string {
+	if oc.Webhook {
+		return "webhook"
+	}
+	return "callback"
+}
@@ -0,0 +1,216 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: AGPL
+
+package mock
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi-validator/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var callbackSpec = `openapi: 3.1.0
+info:
+  title: Test
+  version: 0.1.0
+paths:
+  /subscriptions/{id}:
+    post:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: string
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                callbackUrl:
+                  type: string
+      responses:
+        '201':
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  token:
+                    type: string
+                    example: abc
+      callbacks:
+        onEvent:
+          '{$request.body#/callbackUrl}/events/{$request.path.id}?token={$response.body#/token}':
+            post:
+              requestBody:
+                content:
+                  application/json:
+                    schema:
+                      type: object
+                      properties:
+                        event:
+                          type: string
+                          example: created
+              responses:
+                '200':
+                  content:
+                    application/json:
+                      schema:
+                        type: object
+                        required: [ok]
+                        properties:
+                          ok:
+                            type: boolean
+webhooks:
+  newPet:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                name:
+                  type: string
+                  example: fido
+      responses:
+        '2XX':
+          description: OK`
+
+func newCallbackEngine(t *testing.T) *ResponseMockEngine {
+	d, err := libopenapi.NewDocument([]byte(callbackSpec))
+	require.NoError(t, err)
+	doc, _ := d.BuildV3Model()
+	return NewMockEngine(&doc.Model, false, true)
+}
+
+func TestNewMockEngine_BuildCallbacks(t *testing.T) {
+	me := newCallbackEngine(t)
+
+	request, _ := http.NewRequest(http.MethodPost, "https://api.pb33f.io/subscriptions/sub-1",
+		bytes.NewBufferString(`{"callbackUrl":"http://localhost:8080/hooks"}`))
+	request.Header.Set(helpers.ContentTypeHeader, "application/json")
+
+	resp, err := me.GenerateMockResponse(request, nil)
+	require.NoError(t, err)
+
+	calls, errs := me.BuildCallbacks(request, resp, "")
+	assert.Empty(t, errs)
+	require.Len(t, calls, 1)
+	assert.Equal(t, "onEvent", calls[0].Name)
+	assert.False(t, calls[0].Webhook)
+	assert.Equal(t, http.MethodPost, calls[0].Request.Method)
+	assert.Equal(t, "http://localhost:8080/hooks/events/sub-1?token=abc", calls[0].Request.URL.String())
+	body, _ := io.ReadAll(calls[0].Request.Body)
+	assert.Equal(t, `{"event":"created"}`, string(body))
+
+	// the request body is still readable.
+	body, _ = io.ReadAll(request.Body)
+	assert.Equal(t, `{"callbackUrl":"http://localhost:8080/hooks"}`, string(body))
+}
+
+func TestNewMockEngine_BuildCallbacks_Fallback(t *testing.T) {
+	me := newCallbackEngine(t)
+
+	request, _ := http.NewRequest(http.MethodPost, "https://api.pb33f.io/subscriptions/sub-1",
+		bytes.NewBufferString(`{}`))
+
+	calls, errs := me.BuildCallbacks(request, nil, "")
+	assert.Empty(t, calls)
+	require.Len(t, errs, 1)
+	assert.ErrorContains(t, errs[0], "unable to resolve callback 'onEvent'")
+
+	request.Body = io.NopCloser(bytes.NewBufferString(`{}`))
+	calls, errs = me.BuildCallbacks(request, nil, "http://localhost:9999/fallback")
+	assert.Empty(t, errs)
+	require.Len(t, calls, 1)
+	assert.Equal(t, "http://localhost:9999/fallback", calls[0].Request.URL.String())
+}
+
+func TestNewMockEngine_BuildWebhook(t *testing.T) {
+	me := newCallbackEngine(t)
+
+	calls, err := me.BuildWebhook("newPet", "http://localhost:8080/pets")
+	assert.NoError(t, err)
+	require.Len(t, calls, 1)
+	assert.True(t, calls[0].Webhook)
+	body, _ := io.ReadAll(calls[0].Request.Body)
+	assert.Equal(t, `{"name":"fido"}`, string(body))
+
+	_, err = me.BuildWebhook("missing", "http://localhost:8080/pets")
+	assert.ErrorContains(t, err, "not defined")
+
+	_, err = me.BuildWebhook("newPet", "/relative")
+	assert.ErrorContains(t, err, "not an absolute URL")
+
+	// any 2XX is fine, anything else is not defined.
+	resp := &http.Response{StatusCode: 204, Header: http.Header{}}
+	assert.Empty(t, me.ValidateOutboundResponse(calls[0], resp))
+	resp.StatusCode = 500
+	errs := me.ValidateOutboundResponse(calls[0], resp)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "webhook 'newPet' returned an undefined status code 500", errs[0].Message)
+}
+
+func TestNewMockEngine_ValidateOutboundResponse(t *testing.T) {
+	me := newCallbackEngine(t)
+
+	request, _ := http.NewRequest(http.MethodPost, "https://api.pb33f.io/subscriptions/sub-1",
+		bytes.NewBufferString(`{"callbackUrl":"http://localhost:8080/hooks"}`))
+	calls, _ := me.BuildCallbacks(request, &MockResponse{StatusCode: 201, Body: []byte(`{"token":"abc"}`)}, "")
+	require.Len(t, calls, 1)
+
+	newResponse := func(body string) *http.Response {
+		return &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{helpers.ContentTypeHeader: []string{"application/json"}},
+			Body:       io.NopCloser(bytes.NewBufferString(body)),
+		}
+	}
+	assert.Empty(t, me.ValidateOutboundResponse(calls[0], newResponse(`{"ok":true}`)))
+
+	errs := me.ValidateOutboundResponse(calls[0], newResponse(`{"nope":true}`))
+	assert.NotEmpty(t, errs)
+	assert.Equal(t, "callback", errs[0].ValidationSubType)
+}
+
+func TestRuntimeContext_Expand(t *testing.T) {
+	request, _ := http.NewRequest(http.MethodGet, "https://api.pb33f.io/pets/1?name=fido", nil)
+	request.Header.Set("X-Hook", "http://localhost/hook")
+	rc := &runtimeContext{
+		request:     request,
+		requestBody: []byte(`{"a":{"b/c":[1,{"d":"e"}]}}`),
+		pathParams:  map[string]string{"id": "1"},
+		statusCode:  201,
+	}
+
+	v, err := rc.expand("$request.header.X-Hook")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost/hook", v)
+
+	v, err = rc.expand("{$method}:{$statusCode}:{$request.query.name}:{$request.path.id}")
+	assert.NoError(t, err)
+	assert.Equal(t, "GET:201:fido:1", v)
+
+	v, err = rc.expand("{$request.body#/a/b~1c/1/d}")
+	assert.NoError(t, err)
+	assert.Equal(t, "e", v)
+
+	v, err = rc.expand("{$request.body#/a/b~1c}")
+	assert.NoError(t, err)
+	assert.Equal(t, `[1,{"d":"e"}]`, v)
+
+	_, err = rc.expand("{$request.body#/missing}")
+	assert.Error(t, err)
+
+	_, err = rc.expand("{$request.query.name")
+	assert.ErrorContains(t, err, "unterminated")
+}
@@ -0,0 +1,192 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: AGPL
+
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	"github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// EchoExtension is the operation extension that turns request echo on or off for a single operation, overriding
+// the engine wide setting.
+const EchoExtension = "x-wiretap-echo"
+
+// SetEcho turns request echo on or off for every operation that does not set the `x-wiretap-echo` extension.
+// When on, path parameters, query parameters and top level request body fields are copied into same-named
+// top level properties of the mocked response.
+func (rme *ResponseMockEngine) SetEcho(echo bool) {
+	rme.echo = echo
+}
+
+// shouldEcho checks the operation extension first, before falling back to the engine setting.
+func (rme *ResponseMockEngine) shouldEcho(operation *v3.Operation) bool {
+	if operation != nil && operation.Extensions != nil {
+		if node := operation.Extensions.GetOrZero(EchoExtension); node != nil {
+			if echo, err := strconv.ParseBool(node.Value); err == nil {
+				return echo
+			}
+		}
+	}
+	return rme.echo
+}
+
+// extractPathParams matches a path template (e.g. `/pets/{id}`) against the end of a request path, so any
+// server base path is ignored, and returns the value of each templated segment.
+func extractPathParams(template, path string) map[string]string {
+	params := make(map[string]string)
+	if template == "" {
+		return params
+	}
+	tSegs := strings.Split(strings.Trim(template, "/"), "/")
+	pSegs := strings.Split(strings.Trim(path, "/"), "/")
+	if len(pSegs) < len(tSegs) {
+		return params
+	}
+	pSegs = pSegs[len(pSegs)-len(tSegs):]
+	for i, seg := range tSegs {
+		open := strings.Index(seg, "{")
+		closing := strings.LastIndex(seg, "}")
+		if open < 0 || closing < open {
+			continue
+		}
+		prefix, suffix := seg[:open], seg[closing+1:]
+		value := pSegs[i]
+		if !strings.HasPrefix(value, prefix) || !strings.HasSuffix(value, suffix) ||
+			len(value) < len(prefix)+len(suffix) {
+			continue
+		}
+		params[seg[open+1:closing]] = value[len(prefix) : len(value)-len(suffix)]
+	}
+	return params
+}
+
+// collectEchoValues gathers the values that can be echoed from the request. Path parameters are the most specific,
+// so win over query parameters, which win over body fields.
+func collectEchoValues(request *http.Request, pathTemplate string) map[string]any {
+	values := make(map[string]any)
+
+	if request.Body != nil && request.Body != http.NoBody {
+		b, _ := io.ReadAll(request.Body)
+		_ = request.Body.Close()
+		request.Body = io.NopCloser(bytes.NewBuffer(b))
+
+		if body, ok := decodeObject(b); ok {
+			for _, k := range body.keys {
+				values[k] = decodeEchoValue(body.get(k))
+			}
+		}
+	}
+	for k, v := range request.URL.Query() {
+		if len(v) > 0 {
+			values[k] = v[0]
+		}
+	}
+	for k, v := range extractPathParams(pathTemplate, request.URL.Path) {
+		values[k] = v
+	}
+	return values
+}
+
+// decodeEchoValue decodes a scalar body field, objects and arrays are kept raw so they are echoed in the order
+// they were sent.
+func decodeEchoValue(raw json.RawMessage) any {
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return raw
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var value any
+	_ = decoder.Decode(&value)
+	return value
+}
+
+// echoRequest copies request values into the same-named top level properties of a generated mock object.
+// Values read from the path or query are converted to the type of the property they are copied into; values that
+// cannot be converted are skipped. Properties are patched in place, so the mock keeps its key order.
+func (rme *ResponseMockEngine) echoRequest(request *http.Request, pathTemplate string,
+	mt *v3.MediaType, mock []byte) []byte {
+
+	if mt == nil || mt.Schema == nil || len(mock) == 0 {
+		return mock
+	}
+	schema := mt.Schema.Schema()
+	if schema == nil || schema.Properties == nil {
+		return mock
+	}
+
+	obj, ok := decodeObject(mock)
+	if !ok {
+		return mock
+	}
+
+	values := collectEchoValues(request, pathTemplate)
+	var changed bool
+	// walk the schema, so properties the mock did not generate are appended in the order they are defined.
+	for pair := schema.Properties.First(); pair != nil; pair = pair.Next() {
+		v, present := values[pair.Key()]
+		propSchema := pair.Value().Schema()
+		if !present || propSchema == nil {
+			continue
+		}
+		if converted, ok := coerceEchoValue(v, propSchema); ok {
+			obj.set(pair.Key(), converted)
+			changed = true
+		}
+	}
+	if !changed {
+		return mock
+	}
+	return rme.render(obj)
+}
+
+func coerceEchoValue(value any, schema *base.Schema) (any, bool) {
+	if len(schema.Type) == 0 {
+		return value, true
+	}
+	switch v := value.(type) {
+	case string:
+		switch {
+		case slices.Contains(schema.Type, "string"):
+			return v, true
+		case slices.Contains(schema.Type, "integer"):
+			if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return i, true
+			}
+		case slices.Contains(schema.Type, "number"):
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return f, true
+			}
+		case slices.Contains(schema.Type, "boolean"):
+			if b, err := strconv.ParseBool(v); err == nil {
+				return b, true
+			}
+		}
+	case json.Number:
+		if slices.Contains(schema.Type, "number") ||
+			(slices.Contains(schema.Type, "integer") && !strings.ContainsAny(v.String(), ".eE")) {
+			return v, true
+		}
+		if slices.Contains(schema.Type, "string") {
+			return v.String(), true
+		}
+	case bool:
+		return v, slices.Contains(schema.Type, "boolean")
+	case json.RawMessage:
+		if bytes.TrimSpace(v)[0] == '{' {
+			return v, slices.Contains(schema.Type, "object")
+		}
+		return v, slices.Contains(schema.Type, "array")
+	case nil:
+		return nil, slices.Contains(schema.Type, "null")
+	}
+	return nil, false
+}
@@ -0,0 +1,195 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: AGPL
+
+package mock
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi-validator/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var echoSpec = `openapi: 3.1.0
+info:
+  title: Test
+  version: 0.1.0
+servers:
+  - url: https://api.pb33f.io/v1
+paths:
+  /pets/{id}:
+    get:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+        - name: verbose
+          in: query
+          schema:
+            type: boolean
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  id:
+                    type: integer
+                    example: 1
+                  name:
+                    type: string
+                    example: fido
+                  verbose:
+                    type: boolean
+                    example: false
+  /pets:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                name:
+                  type: string
+                tags:
+                  type: array
+                  items:
+                    type: string
+      responses:
+        '201':
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  id:
+                    type: integer
+                    example: 1
+                  name:
+                    type: string
+                    example: fido
+                  tags:
+                    type: array
+                    items:
+                      type: string
+                      example: good
+  /owners/{id}:
+    put:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  name:
+                    type: string
+                    example: sam
+                  id:
+                    type: integer
+                    example: 1
+                  address:
+                    type: object
+                    properties:
+                      zip:
+                        type: string
+                        example: "12345"
+  /pets/{id}/quiet:
+    get:
+      x-wiretap-echo: false
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  id:
+                    type: integer
+                    example: 1`
+
+func newEchoEngine(t *testing.T) *ResponseMockEngine {
+	d, err := libopenapi.NewDocument([]byte(echoSpec))
+	require.NoError(t, err)
+	doc, _ := d.BuildV3Model()
+	return NewMockEngine(&doc.Model, false, true)
+}
+
+func TestNewMockEngine_Echo(t *testing.T) {
+	me := newEchoEngine(t)
+	me.SetEcho(true)
+
+	request, _ := http.NewRequest(http.MethodGet, "https://api.pb33f.io/v1/pets/42?verbose=true", nil)
+	b, status, err := me.GenerateResponse(request)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, `{"id":42,"name":"fido","verbose":true}`, string(b))
+
+	request, _ = http.NewRequest(http.MethodPost, "https://api.pb33f.io/v1/pets",
+		bytes.NewBufferString(`{"name":"rex","tags":["loud"]}`))
+	request.Header.Set(helpers.ContentTypeHeader, "application/json")
+	b, status, err = me.GenerateResponse(request)
+	assert.NoError(t, err)
+	assert.Equal(t, 201, status)
+	assert.Equal(t, `{"id":1,"name":"rex","tags":["loud"]}`, string(b))
+
+	// the operation extension turns echo off.
+	request, _ = http.NewRequest(http.MethodGet, "https://api.pb33f.io/v1/pets/42/quiet", nil)
+	b, _, _ = me.GenerateResponse(request)
+	assert.Equal(t, `{"id":1}`, string(b))
+}
+
+func TestNewMockEngine_Echo_KeepsKeyOrder(t *testing.T) {
+	me := newEchoEngine(t)
+	me.SetEcho(true)
+
+	request, _ := http.NewRequest(http.MethodPut, "https://api.pb33f.io/v1/owners/42",
+		bytes.NewBufferString(`{"address":{"zip":"90210","city":"beverly hills"}}`))
+	request.Header.Set(helpers.ContentTypeHeader, "application/json")
+	b, status, err := me.GenerateResponse(request)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, status)
+	// the generated mock is patched in place, and the echoed object keeps the order it was sent in.
+	assert.Equal(t, `{"address":{"zip":"90210","city":"beverly hills"},"id":42,"name":"sam"}`, string(b))
+}
+
+func TestNewMockEngine_Echo_Disabled(t *testing.T) {
+	me := newEchoEngine(t)
+
+	request, _ := http.NewRequest(http.MethodGet, "https://api.pb33f.io/v1/pets/42", nil)
+	b, _, err := me.GenerateResponse(request)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"id":1,"name":"fido","verbose":false}`, string(b))
+}
+
+func TestExtractPathParams(t *testing.T) {
+	assert.Equal(t, map[string]string{"id": "42", "file": "report"},
+		extractPathParams("/pets/{id}/files/{file}.json", "/base/pets/42/files/report.json"))
+	assert.Empty(t, extractPathParams("/pets/{id}/files", "/pets"))
+	assert.Empty(t, extractPathParams("", "/pets/1"))
+}
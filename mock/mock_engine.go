@@ -24,6 +24,10 @@ type ResponseMockEngine struct {
 	validator  validation.HttpValidator
 	mockEngine *renderer.MockGenerator
 	pretty     bool
+	echo       bool
+	jwks       []*JSONWebKey
+	// collectionSize is the size of the imaginary collection paginated responses page through.
+	collectionSize int
 }
 
 func NewMockEngine(document *v3.Document, pretty, useAllPropertyExamples bool) *ResponseMockEngine {
@@ -37,15 +41,40 @@ func NewMockEngine(document *v3.Document, pretty, useAllPropertyExamples bool) *
 	}
 
 	return &ResponseMockEngine{
-		doc:        document,
-		validator:  validation.NewHttpValidator(document),
-		mockEngine: me,
-		pretty:     pretty,
+		doc:            document,
+		validator:      validation.NewHttpValidator(document),
+		mockEngine:     me,
+		pretty:         pretty,
+		collectionSize: defaultCollectionSize,
 	}
 }
 
+// MockResponse is a generated mock, along with any headers the mock engine wants sent with it.
+type MockResponse struct {
+	Body       []byte
+	StatusCode int
+	Headers    http.Header
+}
+
 func (rme *ResponseMockEngine) GenerateResponse(request *http.Request) ([]byte, int, error) {
-	return rme.runWorkflow(request)
+	b, c, _, err := rme.runWorkflow(request, nil)
+	return b, c, err
+}
+
+// GenerateScenarioResponse generates a mock response for the request, using the status code and named example
+// configured by a mock scenario instead of the `Prefer` and `wiretap-status-code` headers.
+func (rme *ResponseMockEngine) GenerateScenarioResponse(request *http.Request,
+	scenario *shared.WiretapMockScenario) ([]byte, int, error) {
+	b, c, _, err := rme.runWorkflow(request, scenario)
+	return b, c, err
+}
+
+// GenerateMockResponse generates a mock response for the request and scenario (which may be nil), including
+// any headers generated for the mock, such as pagination `Link` headers.
+func (rme *ResponseMockEngine) GenerateMockResponse(request *http.Request,
+	scenario *shared.WiretapMockScenario) (*MockResponse, error) {
+	b, c, h, err := rme.runWorkflow(request, scenario)
+	return &MockResponse{Body: b, StatusCode: c, Headers: h}, err
 }
 
 func (rme *ResponseMockEngine) ValidateSecurity(request *http.Request, operation *v3.Operation) error {
@@ -55,7 +84,8 @@ func (rme *ResponseMockEngine) ValidateSecurity(request *http.Request, operation
 		return nil
 	}
 
-	mustApply := make(map[string][]string)
+	// requirements are the schemes of each alternative requirement, with the scopes that requirement needs.
+	var requirements [][]*securityCheck
 
 	// operation security
 	if len(operation.Security) > 0 {
@@ -66,90 +96,156 @@ func (rme *ResponseMockEngine) ValidateSecurity(request *http.Request, operation
 				return nil
 			}
 
+			var checks []*securityCheck
 			for securityPairs := securityRequirement.Requirements.First(); securityPairs != nil; securityPairs = securityPairs.Next() {
-				key := securityPairs.Key()
-				scopes := securityPairs.Value()
-				mustApply[key] = scopes
+				checks = append(checks, &securityCheck{scheme: securityPairs.Key(), scopes: securityPairs.Value()})
 			}
+			requirements = append(requirements, checks)
 		}
 	}
 
 	// global security if no local security found.
-	if len(mustApply) <= 0 && len(rme.doc.Security) > 0 {
+	if len(requirements) <= 0 && len(rme.doc.Security) > 0 {
 		for _, securityRequirement := range rme.doc.Security {
 			// if an empty requirement is found, we can skip it, it's optional.
 			if securityRequirement.Requirements.Len() <= 0 && securityRequirement.ContainsEmptyRequirement {
 				return nil
 			}
+			var checks []*securityCheck
 			for securityPairs := securityRequirement.Requirements.First(); securityPairs != nil; securityPairs = securityPairs.Next() {
-				key := securityPairs.Key()
-				scopes := securityPairs.Value()
-				mustApply[key] = scopes
+				checks = append(checks, &securityCheck{scheme: securityPairs.Key(), scopes: securityPairs.Value()})
+			}
+			if len(checks) > 0 {
+				requirements = append(requirements, checks)
 			}
 		}
 	}
 
 	// check if we have any security requirements to apply.
-	if len(mustApply) > 0 {
-		// locate the security schemes components from the document.
-
+	if len(requirements) > 0 {
 		var failures []error
 		compared := 0
 
-		for scope, _ := range mustApply {
-
-			securityComponent := rme.doc.Components.SecuritySchemes.GetOrZero(scope)
-			if securityComponent != nil {
-
-				// check if we have a security scheme that matches the type.
-				switch securityComponent.Type {
-				case "http":
-					// check if we have a bearer scheme.
-					if securityComponent.Scheme == "bearer" || securityComponent.Scheme == "basic" {
-						compared++
-						// check if we have a bearer token.
-						if request.Header.Get("Authorization") == "" {
-							failures = append(failures, fmt.Errorf("%s authentication failed: bearer token not found, "+
-								"no `Authorization` header found in request", securityComponent.Scheme))
-						}
-					}
-
-				case "apiKey":
-					// check if the api key is being used in the header
-					if securityComponent.In == "header" {
-						compared++
-						// check if we have a bearer token.
-						if request.Header.Get(securityComponent.Name) == "" {
-							failures = append(failures, fmt.Errorf("apiKey not found, no `%s` header found in request",
-								securityComponent.Name))
-						}
-					}
-					if securityComponent.In == "query" {
-						compared++
-						if request.URL.Query().Get(securityComponent.Name) == "" {
-							failures = append(failures, fmt.Errorf("apiKey not found, no `%s` query parameter found in request",
-								securityComponent.Name))
-						}
-					}
-					if securityComponent.In == "cookie" {
-						compared++
-						cookie, _ := request.Cookie(securityComponent.Name)
-						if cookie == nil {
-							failures = append(failures, fmt.Errorf("apiKey not found, no `%s` cookie found in request",
-								securityComponent.Name))
-						}
-					}
+		// a scheme is checked once for each set of scopes it is required with.
+		checked := make(map[string]*securityCheck)
+		for _, requirement := range requirements {
+			for _, check := range requirement {
+				if previous, ok := checked[check.key()]; ok {
+					check.failure = previous.failure
+					continue
+				}
+				checked[check.key()] = check
+				applied, err := rme.validateScheme(request, check.scheme, check.scopes)
+				if !applied {
+					continue
+				}
+				compared++
+				if err != nil {
+					check.failure = err
+					failures = append(failures, err)
 				}
 			}
 		}
+
 		// only one needs to pass
 		if len(failures) == compared {
+			if !lacksOnlyScopes(requirements) {
+				// credentials are missing or invalid, so the request is unauthorized rather than forbidden.
+				for i, failure := range failures {
+					var scopeErr *ScopeError
+					if errors.As(failure, &scopeErr) {
+						failures[i] = errors.New(failure.Error())
+					}
+				}
+			}
 			return errors.Join(failures...)
 		}
 	}
 	return nil
 }
 
+// securityCheck is a scheme of a security requirement, the scopes the requirement needs and why the scheme failed.
+type securityCheck struct {
+	scheme  string
+	scopes  []string
+	failure error
+}
+
+func (sc *securityCheck) key() string {
+	return sc.scheme + " " + strings.Join(sc.scopes, " ")
+}
+
+// validateScheme validates the credentials of a request against a security scheme, and the scopes it is required
+// with. Schemes that cannot be checked in mock mode are not applied.
+func (rme *ResponseMockEngine) validateScheme(request *http.Request, name string, scopes []string) (bool, error) {
+	securityComponent := rme.doc.Components.SecuritySchemes.GetOrZero(name)
+	if securityComponent == nil {
+		return false, nil
+	}
+
+	// check if we have a security scheme that matches the type.
+	switch securityComponent.Type {
+	case "http":
+		switch strings.ToLower(securityComponent.Scheme) {
+		case "bearer":
+			return true, rme.validateBearer(request, securityComponent, name, scopes)
+		case "basic":
+			return true, rme.validateBasic(request)
+		}
+
+	case "oauth2", "openIdConnect":
+		return true, rme.validateBearer(request, securityComponent, name, scopes)
+
+	case "mutualTLS":
+		return true, rme.validateMutualTLS(request)
+
+	case "apiKey":
+		// check if the api key is being used in the header
+		switch securityComponent.In {
+		case "header":
+			if request.Header.Get(securityComponent.Name) == "" {
+				return true, fmt.Errorf("apiKey not found, no `%s` header found in request", securityComponent.Name)
+			}
+			return true, nil
+		case "query":
+			if request.URL.Query().Get(securityComponent.Name) == "" {
+				return true, fmt.Errorf("apiKey not found, no `%s` query parameter found in request",
+					securityComponent.Name)
+			}
+			return true, nil
+		case "cookie":
+			if cookie, _ := request.Cookie(securityComponent.Name); cookie == nil {
+				return true, fmt.Errorf("apiKey not found, no `%s` cookie found in request", securityComponent.Name)
+			}
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// lacksOnlyScopes checks if a security requirement was only failed because its credentials did not grant the
+// required scopes, every scheme of the requirement passed or returned a ScopeError.
+func lacksOnlyScopes(requirements [][]*securityCheck) bool {
+	for _, requirement := range requirements {
+		scoped, authenticated := false, true
+		for _, check := range requirement {
+			if check.failure == nil {
+				continue
+			}
+			var scopeErr *ScopeError
+			if !errors.As(check.failure, &scopeErr) {
+				authenticated = false
+				break
+			}
+			scoped = true
+		}
+		if scoped && authenticated {
+			return true
+		}
+	}
+	return false
+}
+
 func (rme *ResponseMockEngine) extractMediaTypeHeader(request *http.Request) string {
 	// extract the content type header from the request.
 	contentType := request.Header.Get(helpers.ContentTypeHeader)
@@ -239,7 +335,7 @@ func (rme *ResponseMockEngine) extractPreferred(request *http.Request) string {
 	return request.Header.Get(helpers.Preferred)
 }
 
-func (rme *ResponseMockEngine) runWorkflow(request *http.Request) ([]byte, int, error) {
+func (rme *ResponseMockEngine) runWorkflow(request *http.Request, scenario *shared.WiretapMockScenario) ([]byte, int, http.Header, error) {
 
 	// get path, not valid? return 404
 	path, err := rme.findPath(request)
@@ -250,7 +346,7 @@ func (rme *ResponseMockEngine) runWorkflow(request *http.Request) ([]byte, int,
 			fmt.Sprintf("Unable to locate the path '%s' with the method '%s'. %s",
 				request.URL.Path, request.Method, err.Error()),
 			"not_found",
-		), 404, err
+		), 404, nil, err
 
 	}
 
@@ -260,27 +356,35 @@ func (rme *ResponseMockEngine) runWorkflow(request *http.Request) ([]byte, int,
 	// check the request is valid against security requirements.
 	err = rme.ValidateSecurity(request, operation)
 	if err != nil {
-		mt, _ := rme.findBestMediaTypeMatch(operation, request, []string{"401"})
+		// valid credentials without the required scopes are forbidden, rather than unauthorized. ValidateSecurity
+		// only returns a ScopeError when no requirement failed for missing or invalid credentials.
+		code, title, detail := 401, "Unauthorized (401)", "you are not authorized to access this resource"
+		var scopeErr *ScopeError
+		if errors.As(err, &scopeErr) {
+			code, title, detail = 403, "Forbidden (403)", "your credentials do not grant the required scopes"
+		}
+		codeString := strconv.Itoa(code)
+		mt, _ := rme.findBestMediaTypeMatch(operation, request, []string{codeString})
 		if mt != nil {
 			mock, mockErr := rme.mockEngine.GenerateMock(mt, rme.extractPreferred(request))
 			if mockErr != nil {
 				return rme.buildError(
 					500,
-					"Unable to build mock (401)",
-					fmt.Sprintf("Errors occurred while generating an error 401 mock response: %s",
-						errors.Join(err, mockErr)),
+					fmt.Sprintf("Unable to build mock (%s)", codeString),
+					fmt.Sprintf("Errors occurred while generating an error %s mock response: %s",
+						codeString, errors.Join(err, mockErr)),
 					"build_mock_error",
-				), 500, mockErr
+				), 500, nil, mockErr
 			}
-			return mock, 401, err
+			return mock, code, nil, err
 		} else {
 			return rme.buildError(
-				401,
-				"Unauthorized (401)",
-				fmt.Sprintf("Unable to call '%s' on '%s', you are not authorized to access this resource",
-					request.Method, request.URL.Path),
+				code,
+				title,
+				fmt.Sprintf("Unable to call '%s' on '%s', %s",
+					request.Method, request.URL.Path, detail),
 				"build_mock_error",
-			), 401, err
+			), code, nil, err
 		}
 	}
 
@@ -297,7 +401,7 @@ func (rme *ResponseMockEngine) runWorkflow(request *http.Request) ([]byte, int,
 					"'422' or '400' response for this operation. Check payload for validation errors.",
 				"validation_failed_and_spec_insufficient_error",
 				validationErrors,
-			), 500, rme.packErrors(validationErrors)
+			), 500, nil, rme.packErrors(validationErrors)
 		}
 		return rme.buildErrorWithPayload(
 			422,
@@ -305,17 +409,29 @@ func (rme *ResponseMockEngine) runWorkflow(request *http.Request) ([]byte, int,
 			"The request failed validation, Check payload for validation errors.",
 			"validation_failed_error",
 			validationErrors,
-		), 422, rme.packErrors(validationErrors)
+		), 422, nil, rme.packErrors(validationErrors)
 
 	}
 
 	preferred := rme.extractPreferred(request)
+	if scenario != nil && scenario.Example != "" {
+		preferred = scenario.Example
+	}
 
 	var lo string
 	var mt *v3.MediaType
 	var noMT bool = true
 
-	if preferred != "" {
+	if scenario != nil && scenario.StatusCode > 0 {
+		// a scenario status code selects the response described for that code, if the contract has one.
+		lo = strconv.Itoa(scenario.StatusCode)
+		mt, noMT = rme.findBestMediaTypeMatch(operation, request, []string{lo})
+		if !noMT && mt == nil {
+			return nil, scenario.StatusCode, nil, nil
+		}
+	}
+
+	if noMT && preferred != "" {
 		// If an explicit preferred header is present, let it have a chance to take precedence
 		// This allows a developer to cause a 3xx, 4xx, or 5xx mocked response by passing
 		// the appropriate example header value.
@@ -330,7 +446,7 @@ func (rme *ResponseMockEngine) runWorkflow(request *http.Request) ([]byte, int,
 
 	c, _ := strconv.Atoi(lo)
 	if c == http.StatusNoContent {
-		return nil, c, nil
+		return nil, c, nil, nil
 	}
 
 	if mt == nil && noMT {
@@ -340,7 +456,7 @@ func (rme *ResponseMockEngine) runWorkflow(request *http.Request) ([]byte, int,
 			"Media type not supported",
 			fmt.Sprintf("The media type requested '%s' is not supported by this operation", mtString),
 			"build_mock_error",
-		), 415, nil
+		), 415, nil, nil
 	}
 
 	mock, mockErr := rme.mockEngine.GenerateMock(mt, preferred)
@@ -351,7 +467,7 @@ func (rme *ResponseMockEngine) runWorkflow(request *http.Request) ([]byte, int,
 			fmt.Sprintf("Errors occurred while generating an error 422 mock response: %s",
 				errors.Join(err, mockErr)),
 			"build_mock_error",
-		), 422, mockErr
+		), 422, nil, mockErr
 	}
 
 	if len(mock) == 0 {
@@ -361,7 +477,12 @@ func (rme *ResponseMockEngine) runWorkflow(request *http.Request) ([]byte, int,
 			fmt.Sprintf("Nothing was generated for the request '%s' with the method '%s'. Response is empty",
 				request.URL.Path, request.Method),
 			"empty",
-		), 200, err
+		), 200, nil, err
+	}
+
+	// a scenario status code always wins over the code the response was found under.
+	if scenario != nil && scenario.StatusCode > 0 {
+		c = scenario.StatusCode
 	}
 
 	// check for wiretap-status-code in header and override the code, regardless of what was found in the spec.
@@ -369,7 +490,19 @@ func (rme *ResponseMockEngine) runWorkflow(request *http.Request) ([]byte, int,
 		c, _ = strconv.Atoi(statusCode)
 	}
 
-	return mock, c, nil
+	// copy request values into the response and size lists to the page requested, if configured.
+	var headers http.Header
+	if c >= 200 && c < 300 {
+		if rme.shouldEcho(operation) {
+			_, _, pathTemplate := paths.FindPath(request, rme.doc)
+			mock = rme.echoRequest(request, pathTemplate, mt, mock)
+		}
+		if pp := findPaginationParams(path, operation); pp != nil && !hasNamedExample(mt, preferred) {
+			mock, headers = rme.paginate(request, pp, mt, mock)
+		}
+	}
+
+	return mock, c, headers, nil
 }
 
 func (rme *ResponseMockEngine) findMediaTypeContainingNamedExample(
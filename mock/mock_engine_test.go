@@ -5,14 +5,23 @@ package mock
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/pb33f/libopenapi"
 	"github.com/pb33f/libopenapi-validator/helpers"
 	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/wiretap/shared"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -489,7 +498,7 @@ components:
 
 	err := me.ValidateSecurity(request, operation)
 	assert.Error(t, err)
-	assert.Equal(t, "basic authentication failed: bearer token not found, "+
+	assert.Equal(t, "basic authentication failed: basic credentials not found, "+
 		"no `Authorization` header found in request", err.Error())
 }
 
@@ -532,7 +541,7 @@ components:
 	buf := bytes.NewBuffer([]byte(payload))
 	request, _ := http.NewRequest(http.MethodPost, "https://api.pb33f.io/auth", buf)
 	request.Header.Set(helpers.ContentTypeHeader, "application/json")
-	request.Header.Set(helpers.AuthorizationHeader, "the science man")
+	request.Header.Set(helpers.AuthorizationHeader, "Basic "+base64.StdEncoding.EncodeToString([]byte("testUser:testPass")))
 
 	b, status, err := me.GenerateResponse(request)
 	assert.NoError(t, err)
@@ -1528,3 +1537,286 @@ components:
 	assert.Equal(t, http.StatusOK, status)
 	assert.Equal(t, `{"id":123,"name":"John Doe"}`, string(b))
 }
+
+func TestNewMockEngine_GenerateScenarioResponse(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /pets:
+    get:
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  type: string
+              examples:
+                fullList:
+                  value: ["dog", "cat"]
+                emptyList:
+                  value: []
+        '500':
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  message:
+                    type: string
+                    example: it broke`
+
+	d, _ := libopenapi.NewDocument([]byte(spec))
+	doc, _ := d.BuildV3Model()
+
+	me := NewMockEngine(&doc.Model, false, true)
+
+	request, _ := http.NewRequest(http.MethodGet, "https://api.pb33f.io/pets", nil)
+
+	b, status, err := me.GenerateScenarioResponse(request, &shared.WiretapMockScenario{
+		Name:    "empty",
+		Example: "emptyList",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, `[]`, string(b))
+
+	b, status, err = me.GenerateScenarioResponse(request, &shared.WiretapMockScenario{
+		Name:       "server-error",
+		StatusCode: 500,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 500, status)
+	assert.Equal(t, `{"message":"it broke"}`, string(b))
+
+	// a status code that is not in the contract keeps the success body, but overrides the code.
+	b, status, err = me.GenerateScenarioResponse(request, &shared.WiretapMockScenario{
+		Name:       "teapot",
+		StatusCode: 418,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 418, status)
+	assert.Equal(t, `["dog","cat"]`, string(b))
+
+	// no scenario behaves like GenerateResponse
+	b, status, err = me.GenerateScenarioResponse(request, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, `["dog","cat"]`, string(b))
+}
+
+func signTestJWT(claims map[string]any, secret []byte) string {
+	header, _ := json.Marshal(map[string]any{"alg": "HS256", "typ": "JWT", "kid": "test"})
+	body, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(body)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestNewMockEngine_ValidateSecurity_SchemeTypes(t *testing.T) {
+
+	spec := `openapi: 3.1.0
+info:
+  title: Test
+  version: 0.1.0
+paths:
+  /jwt:
+    get:
+      security:
+        - jwtAuth: []
+      responses:
+        '200':
+          description: OK
+  /basic:
+    get:
+      security:
+        - basicAuth: []
+      responses:
+        '200':
+          description: OK
+  /oauth:
+    get:
+      security:
+        - oauth: [pets:read, pets:write]
+      responses:
+        '200':
+          description: OK
+        '403':
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  message:
+                    type: string
+                    example: forbidden
+  /mtls:
+    get:
+      security:
+        - mtls: []
+      responses:
+        '200':
+          description: OK
+  /tenant:
+    get:
+      security:
+        - oauth: [pets:write]
+          tenantKey: []
+      responses:
+        '200':
+          description: OK
+  /either:
+    get:
+      security:
+        - oauth: [pets:read]
+        - oauth: [pets:admin]
+      responses:
+        '200':
+          description: OK
+components:
+  securitySchemes:
+    tenantKey:
+      type: apiKey
+      in: header
+      name: X-Tenant-Key
+    jwtAuth:
+      type: http
+      scheme: bearer
+      bearerFormat: JWT
+    basicAuth:
+      type: http
+      scheme: basic
+    oauth:
+      type: oauth2
+      flows:
+        clientCredentials:
+          tokenUrl: https://api.pb33f.io/token
+          scopes:
+            pets:read: read pets
+            pets:write: write pets
+    mtls:
+      type: mutualTLS`
+
+	d, _ := libopenapi.NewDocument([]byte(spec))
+	doc, _ := d.BuildV3Model()
+
+	me := NewMockEngine(&doc.Model, false, true)
+	secret := []byte("wiretap")
+
+	validate := func(url, auth string) error {
+		request, _ := http.NewRequest(http.MethodGet, url, nil)
+		if auth != "" {
+			request.Header.Set(helpers.AuthorizationHeader, auth)
+		}
+		path, _ := me.findPath(request)
+		return me.ValidateSecurity(request, me.findOperation(request, path))
+	}
+
+	// bearer JWT
+	assert.NoError(t, validate("https://api.pb33f.io/jwt", "Bearer "+signTestJWT(map[string]any{"sub": "1"}, secret)))
+	assert.ErrorContains(t, validate("https://api.pb33f.io/jwt", "Bearer 1234"), "not a JWT")
+	assert.ErrorContains(t, validate("https://api.pb33f.io/jwt", "Token 1234"), "does not use the `Bearer` scheme")
+	assert.ErrorContains(t, validate("https://api.pb33f.io/jwt",
+		"Bearer "+signTestJWT(map[string]any{"exp": time.Now().Add(-time.Hour).Unix()}, secret)), "JWT expired")
+	assert.ErrorContains(t, validate("https://api.pb33f.io/jwt",
+		"Bearer "+signTestJWT(map[string]any{"nbf": time.Now().Add(time.Hour).Unix()}, secret)), "not valid before")
+
+	// basic
+	assert.NoError(t, validate("https://api.pb33f.io/basic",
+		"Basic "+base64.StdEncoding.EncodeToString([]byte("princess:b33f"))))
+	assert.ErrorContains(t, validate("https://api.pb33f.io/basic", "Basic not-base64!"), "not valid base64")
+	assert.ErrorContains(t, validate("https://api.pb33f.io/basic",
+		"Basic "+base64.StdEncoding.EncodeToString([]byte("princess"))), "username:password")
+
+	// oauth2 scopes
+	assert.NoError(t, validate("https://api.pb33f.io/oauth",
+		"Bearer "+signTestJWT(map[string]any{"scope": "pets:read pets:write"}, secret)))
+	err := validate("https://api.pb33f.io/oauth",
+		"Bearer "+signTestJWT(map[string]any{"scp": []string{"pets:read"}}, secret))
+	var scopeErr *ScopeError
+	assert.ErrorAs(t, err, &scopeErr)
+	assert.Equal(t, []string{"pets:write"}, scopeErr.Missing)
+	assert.ErrorContains(t, validate("https://api.pb33f.io/oauth", ""), "no `Authorization` header found")
+
+	// mutual TLS
+	assert.ErrorContains(t, validate("https://api.pb33f.io/mtls", ""), "no client certificate presented")
+	request, _ := http.NewRequest(http.MethodGet, "https://api.pb33f.io/mtls", nil)
+	request.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{}}}
+	path, _ := me.findPath(request)
+	assert.NoError(t, me.ValidateSecurity(request, me.findOperation(request, path)))
+
+	// missing scopes are forbidden, not unauthorized.
+	request, _ = http.NewRequest(http.MethodGet, "https://api.pb33f.io/oauth", nil)
+	request.Header.Set(helpers.AuthorizationHeader, "Bearer "+signTestJWT(map[string]any{"scope": "pets:read"}, secret))
+	b, status, err := me.GenerateResponse(request)
+	assert.Error(t, err)
+	assert.Equal(t, 403, status)
+	assert.Equal(t, `{"message":"forbidden"}`, string(b))
+
+	request.Header.Del(helpers.AuthorizationHeader)
+	_, status, err = me.GenerateResponse(request)
+	assert.Error(t, err)
+	assert.Equal(t, 401, status)
+
+	// a requirement that is also missing credentials is unauthorized, even if the token lacks scopes.
+	request, _ = http.NewRequest(http.MethodGet, "https://api.pb33f.io/tenant", nil)
+	request.Header.Set(helpers.AuthorizationHeader, "Bearer "+signTestJWT(map[string]any{"scope": "pets:read"}, secret))
+	_, status, err = me.GenerateResponse(request)
+	assert.Error(t, err)
+	assert.Equal(t, 401, status)
+
+	assert.ErrorContains(t, validate("https://api.pb33f.io/basic", ""), "basic credentials not found")
+
+	// a scheme required with different scopes by alternative requirements passes when any of them is granted.
+	assert.NoError(t, validate("https://api.pb33f.io/either",
+		"Bearer "+signTestJWT(map[string]any{"scope": "pets:read"}, secret)))
+	assert.NoError(t, validate("https://api.pb33f.io/either",
+		"Bearer "+signTestJWT(map[string]any{"scope": "pets:admin"}, secret)))
+	err = validate("https://api.pb33f.io/either", "Bearer "+signTestJWT(map[string]any{"scope": "pets:write"}, secret))
+	assert.ErrorAs(t, err, &scopeErr)
+}
+
+func TestNewMockEngine_ValidateSecurity_JWKS(t *testing.T) {
+
+	spec := `openapi: 3.1.0
+info:
+  title: Test
+  version: 0.1.0
+security:
+  - jwtAuth: []
+paths:
+  /test:
+    get:
+      responses:
+        '200':
+          description: OK
+components:
+  securitySchemes:
+    jwtAuth:
+      type: http
+      scheme: bearer
+      bearerFormat: JWT`
+
+	d, _ := libopenapi.NewDocument([]byte(spec))
+	doc, _ := d.BuildV3Model()
+
+	me := NewMockEngine(&doc.Model, false, true)
+
+	jwks := filepath.Join(t.TempDir(), "jwks.json")
+	_ = os.WriteFile(jwks, []byte(`{"keys":[{"kty":"oct","kid":"test","alg":"HS256","k":"`+
+		base64.RawURLEncoding.EncodeToString([]byte("wiretap"))+`"}]}`), 0644)
+	require.NoError(t, me.LoadJWKS(jwks))
+
+	request, _ := http.NewRequest(http.MethodGet, "https://api.pb33f.io/test", nil)
+	path, _ := me.findPath(request)
+	operation := me.findOperation(request, path)
+
+	request.Header.Set(helpers.AuthorizationHeader, "Bearer "+signTestJWT(map[string]any{"sub": "1"}, []byte("wiretap")))
+	assert.NoError(t, me.ValidateSecurity(request, operation))
+
+	request.Header.Set(helpers.AuthorizationHeader, "Bearer "+signTestJWT(map[string]any{"sub": "1"}, []byte("nope")))
+	assert.ErrorContains(t, me.ValidateSecurity(request, operation), "signature verification failed")
+
+	assert.Error(t, me.LoadJWKS(filepath.Join(t.TempDir(), "missing.json")))
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: AGPL
+
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// jsonObject is a JSON object with its keys kept in the order they were generated in, so a mock can be patched
+// and re-rendered without its properties being sorted. Values are kept raw, so nested objects keep their order too.
+type jsonObject struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+// decodeObject decodes a JSON object, returns false if the data is not an object.
+func decodeObject(data []byte) (*jsonObject, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, false
+	}
+	obj := &jsonObject{values: make(map[string]json.RawMessage)}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, false
+		}
+		key, _ := token.(string)
+		var value json.RawMessage
+		if err = decoder.Decode(&value); err != nil {
+			return nil, false
+		}
+		if _, present := obj.values[key]; !present {
+			obj.keys = append(obj.keys, key)
+		}
+		obj.values[key] = value
+	}
+	if token, err := decoder.Token(); err != nil || token != json.Delim('}') {
+		return nil, false
+	}
+	return obj, true
+}
+
+func (o *jsonObject) has(key string) bool {
+	_, present := o.values[key]
+	return present
+}
+
+func (o *jsonObject) get(key string) json.RawMessage {
+	return o.values[key]
+}
+
+// set replaces the value of a key in place, or appends the key if the object does not have it yet.
+func (o *jsonObject) set(key string, value any) {
+	raw, ok := value.(json.RawMessage)
+	if !ok {
+		var err error
+		if raw, err = json.Marshal(value); err != nil {
+			return
+		}
+	}
+	if !o.has(key) {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = raw
+}
+
+// MarshalJSON renders the object with its keys in order.
+func (o *jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(o.values[key])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,33 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: AGPL
+
+package mock
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeObject(t *testing.T) {
+	obj, ok := decodeObject([]byte(`{"zebra":1,"apple":{"y":true,"x":false},"mango":[3,2,1]}`))
+	require.True(t, ok)
+	assert.Equal(t, []string{"zebra", "apple", "mango"}, obj.keys)
+
+	obj.set("apple", "patched")
+	obj.set("banana", 2)
+	b, err := json.Marshal(obj)
+	require.NoError(t, err)
+	assert.Equal(t, `{"zebra":1,"apple":"patched","mango":[3,2,1],"banana":2}`, string(b))
+
+	b, err = json.MarshalIndent(obj, "", "  ")
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "\n  \"zebra\": 1,\n")
+
+	_, ok = decodeObject([]byte(`["not", "an", "object"]`))
+	assert.False(t, ok)
+	_, ok = decodeObject([]byte(`{"broken":`))
+	assert.False(t, ok)
+}
@@ -0,0 +1,386 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: AGPL
+
+package mock
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	"github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// the default size of the imaginary collection being paged through, and the page size used when the request and
+// contract do not specify one.
+const (
+	defaultCollectionSize = 100
+	defaultPageSize       = 10
+	cursorPrefix          = "wiretap:"
+)
+
+// pagination parameter names, compared after being lower-cased and stripped of `_` and `-`.
+var (
+	limitParams  = []string{"limit", "perpage", "pagesize", "size", "maxresults", "top", "first"}
+	offsetParams = []string{"offset", "skip", "start"}
+	pageParams   = []string{"page", "pagenumber", "pageindex"}
+	cursorParams = []string{"cursor", "after", "pagetoken", "nexttoken", "continuationtoken", "startingafter"}
+	// ambiguousParams are commonly used for other things than paging, such as an image size or a start date, so
+	// they are only pagination parameters when their schema is an integer.
+	ambiguousParams = []string{"size", "top", "first", "start"}
+
+	listProperties = []string{"data", "items", "results", "content", "records", "values", "entries", "list"}
+	nextProperties = []string{"next", "nextcursor", "nextpagetoken", "nexttoken", "nextpage", "endcursor"}
+	prevProperties = []string{"prev", "previous", "prevcursor", "previouscursor", "prevpagetoken",
+		"previouspagetoken", "prevpage", "previouspage", "startcursor"}
+	totalProperties = []string{"total", "totalcount", "totalitems", "totalresults", "totalsize"}
+	moreProperties  = []string{"hasmore", "hasnext", "hasnextpage"}
+	metaProperties  = []string{"meta", "pagination", "paging", "pageinfo", "links"}
+)
+
+// paginationParams holds the names of the pagination parameters an operation declares.
+type paginationParams struct {
+	limit       string
+	limitSchema *base.Schema
+	offset      string
+	page        string
+	cursor      string
+}
+
+// page is the slice of the imaginary collection requested.
+type page struct {
+	offset int
+	size   int
+	total  int
+}
+
+// SetCollectionSize sets the size of the imaginary collection paginated responses page through, sizes below one
+// reset it to the default of 100.
+func (rme *ResponseMockEngine) SetCollectionSize(size int) {
+	if size < 1 {
+		size = defaultCollectionSize
+	}
+	rme.collectionSize = size
+}
+
+func normalizeParamName(name string) string {
+	return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(name))
+}
+
+// findPaginationParams locates the pagination query parameters declared on an operation or its path. Returns nil
+// if the operation is not paginated.
+func findPaginationParams(pathItem *v3.PathItem, operation *v3.Operation) *paginationParams {
+	var params []*v3.Parameter
+	if pathItem != nil {
+		params = append(params, pathItem.Parameters...)
+	}
+	if operation != nil {
+		params = append(params, operation.Parameters...)
+	}
+	pp := &paginationParams{}
+	for _, param := range params {
+		if param == nil || param.In != "query" {
+			continue
+		}
+		name := normalizeParamName(param.Name)
+		if slices.Contains(ambiguousParams, name) && !isIntegerParam(param) {
+			continue
+		}
+		switch {
+		case pp.limit == "" && slices.Contains(limitParams, name):
+			pp.limit = param.Name
+			if param.Schema != nil {
+				pp.limitSchema = param.Schema.Schema()
+			}
+		case pp.offset == "" && slices.Contains(offsetParams, name):
+			pp.offset = param.Name
+		case pp.page == "" && slices.Contains(pageParams, name):
+			pp.page = param.Name
+		case pp.cursor == "" && slices.Contains(cursorParams, name):
+			pp.cursor = param.Name
+		}
+	}
+	if pp.limit == "" && pp.offset == "" && pp.page == "" && pp.cursor == "" {
+		return nil
+	}
+	return pp
+}
+
+// isIntegerParam checks if the schema of a parameter is an integer.
+func isIntegerParam(param *v3.Parameter) bool {
+	if param.Schema == nil {
+		return false
+	}
+	schema := param.Schema.Schema()
+	return schema != nil && slices.Contains(schema.Type, "integer")
+}
+
+// resolvePage works out which slice of a collection of the given size the request is asking for.
+func (pp *paginationParams) resolvePage(query url.Values, total int) page {
+	p := page{size: defaultPageSize, total: total}
+
+	if pp.limitSchema != nil && pp.limitSchema.Default != nil {
+		if v, err := strconv.Atoi(pp.limitSchema.Default.Value); err == nil && v > 0 {
+			p.size = v
+		}
+	}
+	if pp.limit != "" {
+		if v, err := strconv.Atoi(query.Get(pp.limit)); err == nil && v >= 0 {
+			p.size = v
+		}
+	}
+	if pp.limitSchema != nil && pp.limitSchema.Maximum != nil && p.size > int(*pp.limitSchema.Maximum) {
+		p.size = int(*pp.limitSchema.Maximum)
+	}
+
+	switch {
+	case pp.cursor != "" && query.Get(pp.cursor) != "":
+		p.offset = decodeCursor(query.Get(pp.cursor))
+	case pp.offset != "" && query.Get(pp.offset) != "":
+		if v, err := strconv.Atoi(query.Get(pp.offset)); err == nil && v > 0 {
+			p.offset = v
+		}
+	case pp.page != "" && query.Get(pp.page) != "":
+		if v, err := strconv.Atoi(query.Get(pp.page)); err == nil && v > 1 {
+			p.offset = (v - 1) * p.size
+		}
+	}
+	return p
+}
+
+// count is the number of items that fit on this page.
+func (p page) count() int {
+	remaining := p.total - p.offset
+	if remaining < 0 {
+		remaining = 0
+	}
+	return min(p.size, remaining)
+}
+
+func (p page) hasNext() bool {
+	return p.size > 0 && p.offset+p.size < p.total
+}
+
+func (p page) hasPrev() bool {
+	return p.offset > 0
+}
+
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) int {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(b), cursorPrefix) {
+		return 0
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(b), cursorPrefix))
+	if err != nil || offset < 0 {
+		return 0
+	}
+	return offset
+}
+
+// paginate resizes the list in a generated mock to the requested page and fills in any cursor, total and
+// `has more` properties. It returns the re-rendered mock and the `Link` header for the page. If the mock does not
+// contain a list, it is returned untouched. The mock is patched in place, so it keeps its key order.
+func (rme *ResponseMockEngine) paginate(request *http.Request, pp *paginationParams,
+	mt *v3.MediaType, mock []byte) ([]byte, http.Header) {
+
+	if mt == nil || mt.Schema == nil || len(mock) == 0 {
+		return mock, nil
+	}
+	schema := mt.Schema.Schema()
+	if schema == nil {
+		return mock, nil
+	}
+
+	p := pp.resolvePage(request.URL.Query(), rme.collectionSize)
+	var paged any
+
+	if obj, ok := decodeObject(mock); ok {
+		if key, listSchema := findListProperty(obj, schema); key != "" {
+			obj.set(key, resizeList(asList(obj.get(key)), listSchema, p.count()))
+			fillPageProperties(obj, schema, pp, p)
+			for _, meta := range metaProperties {
+				for _, k := range obj.keys {
+					if normalizeParamName(k) != meta {
+						continue
+					}
+					if nested, isObject := decodeObject(obj.get(k)); isObject {
+						fillPageProperties(nested, propertySchema(schema, k), pp, p)
+						obj.set(k, nested)
+					}
+				}
+			}
+			paged = obj
+		}
+	} else if list := asList(mock); list != nil && slices.Contains(schema.Type, "array") {
+		paged = resizeList(list, schema, p.count())
+	}
+	if paged == nil {
+		return mock, nil
+	}
+
+	headers := http.Header{}
+	if link := buildLinkHeader(request.URL, pp, p); link != "" {
+		headers.Set("Link", link)
+	}
+	return rme.render(paged), headers
+}
+
+// hasNamedExample checks if the media type has the example requested by the `preferred` header or a scenario.
+// A chosen example is served as it was written, so it is not resized to the page.
+func hasNamedExample(mt *v3.MediaType, name string) bool {
+	if mt == nil || mt.Examples == nil || name == "" {
+		return false
+	}
+	_, present := mt.Examples.Get(name)
+	return present
+}
+
+// asList decodes a JSON array, keeping each item raw. Returns nil if the data is not an array.
+func asList(data json.RawMessage) []json.RawMessage {
+	var list []json.RawMessage
+	if json.Unmarshal(data, &list) != nil || list == nil {
+		return nil
+	}
+	return list
+}
+
+func propertySchema(schema *base.Schema, name string) *base.Schema {
+	if schema == nil || schema.Properties == nil {
+		return nil
+	}
+	if proxy := schema.Properties.GetOrZero(name); proxy != nil {
+		return proxy.Schema()
+	}
+	return nil
+}
+
+// findListProperty returns the name and schema of the array property holding the page of results. Well known
+// names are preferred, otherwise the first array property is used.
+func findListProperty(obj *jsonObject, schema *base.Schema) (string, *base.Schema) {
+	if schema == nil || schema.Properties == nil {
+		return "", nil
+	}
+	var firstKey string
+	var firstSchema *base.Schema
+	for pair := schema.Properties.First(); pair != nil; pair = pair.Next() {
+		if !obj.has(pair.Key()) {
+			continue
+		}
+		s := pair.Value().Schema()
+		if s == nil || !slices.Contains(s.Type, "array") {
+			continue
+		}
+		if slices.Contains(listProperties, normalizeParamName(pair.Key())) {
+			return pair.Key(), s
+		}
+		if firstKey == "" {
+			firstKey, firstSchema = pair.Key(), s
+		}
+	}
+	return firstKey, firstSchema
+}
+
+// resizeList grows or shrinks a generated list to the page size, re-using the generated items, while respecting
+// the `minItems` and `maxItems` of the array schema.
+func resizeList(list []json.RawMessage, schema *base.Schema, size int) []json.RawMessage {
+	if schema != nil && schema.MaxItems != nil && size > int(*schema.MaxItems) {
+		size = int(*schema.MaxItems)
+	}
+	if schema != nil && schema.MinItems != nil && size < int(*schema.MinItems) {
+		size = int(*schema.MinItems)
+	}
+	if len(list) == 0 {
+		// nothing to copy from.
+		return list
+	}
+	resized := make([]json.RawMessage, size)
+	for i := range resized {
+		resized[i] = list[i%len(list)]
+	}
+	return resized
+}
+
+// fillPageProperties sets the cursor, total and `has more` properties of a page object, when the schema defines them.
+func fillPageProperties(obj *jsonObject, schema *base.Schema, pp *paginationParams, p page) {
+	for _, key := range obj.keys {
+		name := normalizeParamName(key)
+		s := propertySchema(schema, key)
+		switch {
+		case slices.Contains(nextProperties, name):
+			obj.set(key, pageReference(s, pp, p.hasNext(), p.offset+p.size, p.size))
+		case slices.Contains(prevProperties, name):
+			obj.set(key, pageReference(s, pp, p.hasPrev(), max(p.offset-p.size, 0), p.size))
+		case slices.Contains(totalProperties, name):
+			obj.set(key, p.total)
+		case slices.Contains(moreProperties, name):
+			obj.set(key, p.hasNext())
+		}
+	}
+}
+
+// pageReference renders a reference to another page, as a page number if the property is numeric, or a cursor.
+func pageReference(schema *base.Schema, pp *paginationParams, exists bool, offset, size int) any {
+	if !exists {
+		return nil
+	}
+	if schema != nil && (slices.Contains(schema.Type, "integer") || slices.Contains(schema.Type, "number")) {
+		if size == 0 {
+			return 1
+		}
+		return offset/size + 1
+	}
+	if pp.cursor == "" && pp.page != "" && size > 0 {
+		return strconv.Itoa(offset/size + 1)
+	}
+	return encodeCursor(offset)
+}
+
+// buildLinkHeader builds an RFC 8288 `Link` header with next, prev, first and last relations.
+func buildLinkHeader(u *url.URL, pp *paginationParams, p page) string {
+	link := func(offset int, rel string) string {
+		q := u.Query()
+		switch {
+		case pp.cursor != "":
+			if offset == 0 {
+				q.Del(pp.cursor)
+			} else {
+				q.Set(pp.cursor, encodeCursor(offset))
+			}
+		case pp.offset != "":
+			q.Set(pp.offset, strconv.Itoa(offset))
+		case pp.page != "" && p.size > 0:
+			q.Set(pp.page, strconv.Itoa(offset/p.size+1))
+		default:
+			return ""
+		}
+		if pp.limit != "" {
+			q.Set(pp.limit, strconv.Itoa(p.size))
+		}
+		next := *u
+		next.RawQuery = q.Encode()
+		return fmt.Sprintf("<%s>; rel=\"%s\"", next.String(), rel)
+	}
+
+	var links []string
+	if p.hasNext() {
+		links = append(links, link(p.offset+p.size, "next"))
+	}
+	if p.hasPrev() {
+		links = append(links, link(max(p.offset-p.size, 0), "prev"))
+	}
+	links = append(links, link(0, "first"))
+	if pp.cursor == "" && p.size > 0 {
+		links = append(links, link(((p.total-1)/p.size)*p.size, "last"))
+	}
+	return strings.Join(slices.DeleteFunc(links, func(s string) bool { return s == "" }), ", ")
+}
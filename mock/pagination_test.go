@@ -0,0 +1,260 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: AGPL
+
+package mock
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi-validator/helpers"
+	"github.com/pb33f/wiretap/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var paginationSpec = `openapi: 3.1.0
+info:
+  title: Test
+  version: 0.1.0
+paths:
+  /pets:
+    get:
+      parameters:
+        - name: limit
+          in: query
+          schema:
+            type: integer
+            default: 5
+            maximum: 50
+        - name: offset
+          in: query
+          schema:
+            type: integer
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                type: array
+                maxItems: 20
+                items:
+                  type: string
+                  example: dog
+  /things:
+    get:
+      parameters:
+        - name: page_size
+          in: query
+          schema:
+            type: integer
+        - name: cursor
+          in: query
+          schema:
+            type: string
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  data:
+                    type: array
+                    minItems: 2
+                    items:
+                      type: integer
+                      example: 1
+                  nextCursor:
+                    type: string
+                    example: abc
+                  meta:
+                    type: object
+                    properties:
+                      total:
+                        type: integer
+                        example: 1
+                      hasMore:
+                        type: boolean
+                        example: false
+  /thumbnails:
+    get:
+      parameters:
+        - name: size
+          in: query
+          schema:
+            type: string
+            enum: [small, large]
+        - name: start
+          in: query
+          schema:
+            type: string
+            format: date
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  type: string
+                  example: dog
+  /owners:
+    get:
+      parameters:
+        - name: limit
+          in: query
+          schema:
+            type: integer
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  owners:
+                    type: array
+                    items:
+                      type: string
+                  total:
+                    type: integer
+              examples:
+                fullList:
+                  value:
+                    owners: [sam, alex, jo]
+                    total: 3
+  /plain:
+    get:
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  type: string
+                  example: dog`
+
+func newPaginationEngine(t *testing.T) *ResponseMockEngine {
+	d, err := libopenapi.NewDocument([]byte(paginationSpec))
+	require.NoError(t, err)
+	doc, _ := d.BuildV3Model()
+	return NewMockEngine(&doc.Model, false, true)
+}
+
+func TestNewMockEngine_Pagination_LimitOffset(t *testing.T) {
+	me := newPaginationEngine(t)
+
+	request, _ := http.NewRequest(http.MethodGet, "https://api.pb33f.io/pets?limit=3&offset=3", nil)
+	resp, err := me.GenerateMockResponse(request, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, `["dog","dog","dog"]`, string(resp.Body))
+	assert.Equal(t, `<https://api.pb33f.io/pets?limit=3&offset=6>; rel="next", `+
+		`<https://api.pb33f.io/pets?limit=3&offset=0>; rel="prev", `+
+		`<https://api.pb33f.io/pets?limit=3&offset=0>; rel="first", `+
+		`<https://api.pb33f.io/pets?limit=3&offset=99>; rel="last"`, resp.Headers.Get("Link"))
+
+	// default limit from the parameter schema.
+	request, _ = http.NewRequest(http.MethodGet, "https://api.pb33f.io/pets", nil)
+	resp, _ = me.GenerateMockResponse(request, nil)
+	var list []string
+	_ = json.Unmarshal(resp.Body, &list)
+	assert.Len(t, list, 5)
+
+	// maxItems caps the page.
+	request, _ = http.NewRequest(http.MethodGet, "https://api.pb33f.io/pets?limit=40", nil)
+	resp, _ = me.GenerateMockResponse(request, nil)
+	_ = json.Unmarshal(resp.Body, &list)
+	assert.Len(t, list, 20)
+
+	// past the end of the collection.
+	request, _ = http.NewRequest(http.MethodGet, "https://api.pb33f.io/pets?limit=10&offset=200", nil)
+	resp, _ = me.GenerateMockResponse(request, nil)
+	assert.Equal(t, `[]`, string(resp.Body))
+	assert.NotContains(t, resp.Headers.Get("Link"), `rel="next"`)
+
+	// the size of the collection can be configured.
+	me.SetCollectionSize(12)
+	request, _ = http.NewRequest(http.MethodGet, "https://api.pb33f.io/pets?limit=5&offset=10", nil)
+	resp, _ = me.GenerateMockResponse(request, nil)
+	assert.Equal(t, `["dog","dog"]`, string(resp.Body))
+	assert.Contains(t, resp.Headers.Get("Link"), `offset=10>; rel="last"`)
+}
+
+func TestNewMockEngine_Pagination_Cursor(t *testing.T) {
+	me := newPaginationEngine(t)
+
+	request, _ := http.NewRequest(http.MethodGet, "https://api.pb33f.io/things?page_size=4", nil)
+	resp, err := me.GenerateMockResponse(request, nil)
+	assert.NoError(t, err)
+
+	var page struct {
+		Data       []int  `json:"data"`
+		NextCursor string `json:"nextCursor"`
+		Meta       struct {
+			Total   int  `json:"total"`
+			HasMore bool `json:"hasMore"`
+		} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Body, &page))
+	assert.Len(t, page.Data, 4)
+	assert.Equal(t, 100, page.Meta.Total)
+	assert.True(t, page.Meta.HasMore)
+	assert.Equal(t, 4, decodeCursor(page.NextCursor))
+	assert.Contains(t, resp.Headers.Get("Link"), "cursor="+page.NextCursor)
+
+	// follow the cursor to the last page, minItems keeps the list from being empty.
+	request, _ = http.NewRequest(http.MethodGet, "https://api.pb33f.io/things?page_size=4&cursor="+
+		encodeCursor(99), nil)
+	resp, _ = me.GenerateMockResponse(request, nil)
+	page.NextCursor = "not-reset"
+	require.NoError(t, json.Unmarshal(resp.Body, &page))
+	assert.Len(t, page.Data, 2)
+	assert.False(t, page.Meta.HasMore)
+	assert.Contains(t, string(resp.Body), `"nextCursor":null`)
+}
+
+func TestNewMockEngine_Pagination_NotPaginated(t *testing.T) {
+	me := newPaginationEngine(t)
+
+	request, _ := http.NewRequest(http.MethodGet, "https://api.pb33f.io/plain?limit=10", nil)
+	resp, err := me.GenerateMockResponse(request, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `["dog"]`, string(resp.Body))
+	assert.Empty(t, resp.Headers)
+
+	// parameters with ambiguous names only page when they are integers.
+	request, _ = http.NewRequest(http.MethodGet, "https://api.pb33f.io/thumbnails?size=large&start=2024-01-01", nil)
+	resp, err = me.GenerateMockResponse(request, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `["dog"]`, string(resp.Body))
+	assert.Empty(t, resp.Headers)
+}
+
+func TestNewMockEngine_Pagination_NamedExample(t *testing.T) {
+	me := newPaginationEngine(t)
+
+	// an example chosen by name is served as written.
+	request, _ := http.NewRequest(http.MethodGet, "https://api.pb33f.io/owners?limit=1", nil)
+	request.Header.Set(helpers.Preferred, "fullList")
+	resp, err := me.GenerateMockResponse(request, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"owners":["sam","alex","jo"],"total":3}`, string(resp.Body))
+	assert.Empty(t, resp.Headers)
+
+	// as is an example chosen by a scenario.
+	request, _ = http.NewRequest(http.MethodGet, "https://api.pb33f.io/owners?limit=5", nil)
+	resp, err = me.GenerateMockResponse(request, &shared.WiretapMockScenario{Example: "fullList"})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"owners":["sam","alex","jo"],"total":3}`, string(resp.Body))
+
+	// without one, the first example is paged.
+	request, _ = http.NewRequest(http.MethodGet, "https://api.pb33f.io/owners?limit=2", nil)
+	resp, err = me.GenerateMockResponse(request, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"owners":["sam","alex"],"total":100}`, string(resp.Body))
+}
@@ -0,0 +1,129 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: AGPL
+
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// runtimeContext holds everything an OpenAPI runtime expression can refer to.
+// https://spec.openapis.org/oas/v3.1.0#runtime-expressions
+type runtimeContext struct {
+	request         *http.Request
+	requestBody     []byte
+	pathParams      map[string]string
+	statusCode      int
+	responseHeaders http.Header
+	responseBody    []byte
+}
+
+// evaluate resolves a single runtime expression, such as `$request.body#/callbackUrl`.
+func (rc *runtimeContext) evaluate(expression string) (string, error) {
+	switch {
+	case expression == "$url":
+		return rc.request.URL.String(), nil
+	case expression == "$method":
+		return rc.request.Method, nil
+	case expression == "$statusCode":
+		return strconv.Itoa(rc.statusCode), nil
+	case strings.HasPrefix(expression, "$request."):
+		return rc.evaluateSource(strings.TrimPrefix(expression, "$request."),
+			rc.request.Header, rc.requestBody, true)
+	case strings.HasPrefix(expression, "$response."):
+		return rc.evaluateSource(strings.TrimPrefix(expression, "$response."),
+			rc.responseHeaders, rc.responseBody, false)
+	}
+	return "", fmt.Errorf("unsupported runtime expression '%s'", expression)
+}
+
+func (rc *runtimeContext) evaluateSource(source string, headers http.Header, body []byte, isRequest bool) (string, error) {
+	switch {
+	case strings.HasPrefix(source, "header."):
+		if v := headers.Get(strings.TrimPrefix(source, "header.")); v != "" {
+			return v, nil
+		}
+	case isRequest && strings.HasPrefix(source, "query."):
+		if v := rc.request.URL.Query().Get(strings.TrimPrefix(source, "query.")); v != "" {
+			return v, nil
+		}
+	case isRequest && strings.HasPrefix(source, "path."):
+		if v := rc.pathParams[strings.TrimPrefix(source, "path.")]; v != "" {
+			return v, nil
+		}
+	case source == "body":
+		if len(body) > 0 {
+			return string(body), nil
+		}
+	case strings.HasPrefix(source, "body#"):
+		return resolveJSONPointer(body, strings.TrimPrefix(source, "body#"))
+	}
+	return "", fmt.Errorf("runtime expression '%s' could not be resolved", source)
+}
+
+// expand replaces every `{expression}` in a template with its value. A template that is a bare expression is
+// resolved as-is.
+func (rc *runtimeContext) expand(template string) (string, error) {
+	if strings.HasPrefix(template, "$") {
+		return rc.evaluate(template)
+	}
+	var sb strings.Builder
+	rest := template
+	for {
+		open := strings.Index(rest, "{")
+		if open < 0 {
+			sb.WriteString(rest)
+			break
+		}
+		closing := strings.Index(rest[open:], "}")
+		if closing < 0 {
+			return "", fmt.Errorf("unterminated runtime expression in '%s'", template)
+		}
+		sb.WriteString(rest[:open])
+		value, err := rc.evaluate(rest[open+1 : open+closing])
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(value)
+		rest = rest[open+closing+1:]
+	}
+	return sb.String(), nil
+}
+
+// resolveJSONPointer locates a value in a JSON document using an RFC 6901 JSON pointer. Strings are returned
+// as-is, all other values are returned as JSON.
+func resolveJSONPointer(document []byte, pointer string) (string, error) {
+	var value any
+	if err := json.Unmarshal(document, &value); err != nil {
+		return "", fmt.Errorf("body is not JSON, cannot resolve pointer '%s'", pointer)
+	}
+	if pointer != "" && pointer != "/" {
+		for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+			token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+			switch v := value.(type) {
+			case map[string]any:
+				var ok bool
+				if value, ok = v[token]; !ok {
+					return "", fmt.Errorf("pointer '%s' not found in body", pointer)
+				}
+			case []any:
+				i, err := strconv.Atoi(token)
+				if err != nil || i < 0 || i >= len(v) {
+					return "", fmt.Errorf("pointer '%s' not found in body", pointer)
+				}
+				value = v[i]
+			default:
+				return "", fmt.Errorf("pointer '%s' not found in body", pointer)
+			}
+		}
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	b, _ := json.Marshal(value)
+	return string(b), nil
+}
@@ -0,0 +1,379 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: AGPL
+
+package mock
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// ScopeError is returned when a request carries valid credentials, that do not grant the scopes required by the
+// security requirement of an operation. It allows the mock engine to tell a 403 apart from a 401.
+type ScopeError struct {
+	Scheme  string
+	Missing []string
+}
+
+func (se *ScopeError) Error() string {
+	return fmt.Sprintf("%s authorization failed: token is missing required %s: %s", se.Scheme,
+		pluralizeScope(len(se.Missing)), strings.Join(se.Missing, ", "))
+}
+
+func pluralizeScope(n int) string {
+	if n == 1 {
+		return "scope"
+	}
+	return "scopes"
+}
+
+// JSONWebKey is a single key from a JSON Web Key Set (RFC 7517), only the members wiretap needs to verify
+// signatures are read.
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	K   string `json:"k,omitempty"`
+}
+
+type jsonWebKeySet struct {
+	Keys []*JSONWebKey `json:"keys"`
+}
+
+type jsonWebToken struct {
+	header    map[string]any
+	claims    map[string]any
+	signed    []byte
+	signature []byte
+}
+
+// LoadJWKS reads a local JSON Web Key Set file. Once loaded, every JWT presented to an operation secured by a bearer,
+// oauth2 or openIdConnect scheme must carry a signature that verifies against one of the keys in the set.
+func (rme *ResponseMockEngine) LoadJWKS(location string) error {
+	b, err := os.ReadFile(location)
+	if err != nil {
+		return err
+	}
+	var set jsonWebKeySet
+	if err = json.Unmarshal(b, &set); err != nil {
+		return fmt.Errorf("unable to parse JWKS '%s': %w", location, err)
+	}
+	if len(set.Keys) == 0 {
+		return fmt.Errorf("JWKS '%s' does not contain any keys", location)
+	}
+	rme.jwks = set.Keys
+	return nil
+}
+
+// validateBearer checks the `Authorization` header carries a bearer token. JWTs are checked for structure, expiry,
+// signature (when a JWKS is loaded) and the scopes required by the security requirement.
+func (rme *ResponseMockEngine) validateBearer(request *http.Request, scheme *v3.SecurityScheme,
+	schemeName string, scopes []string) error {
+
+	authHeader := request.Header.Get("Authorization")
+	if authHeader == "" {
+		return fmt.Errorf("bearer authentication failed: bearer token not found, " +
+			"no `Authorization` header found in request")
+	}
+	if len(authHeader) < 7 || !strings.EqualFold(authHeader[:7], "bearer ") {
+		return fmt.Errorf("bearer authentication failed: `Authorization` header does not use the `Bearer` scheme")
+	}
+	token := strings.TrimSpace(authHeader[7:])
+	if token == "" {
+		return fmt.Errorf("bearer authentication failed: bearer token is empty")
+	}
+
+	mustBeJWT := strings.EqualFold(scheme.BearerFormat, "jwt") || scheme.Type == "openIdConnect"
+	if !mustBeJWT && strings.Count(token, ".") != 2 {
+		// an opaque token, there is nothing more that can be checked.
+		return nil
+	}
+
+	jwt, err := parseJWT(token)
+	if err != nil {
+		return fmt.Errorf("bearer authentication failed: %s", err.Error())
+	}
+	if err = jwt.checkTimes(time.Now()); err != nil {
+		return fmt.Errorf("bearer authentication failed: %s", err.Error())
+	}
+	if len(rme.jwks) > 0 {
+		if err = jwt.verify(rme.jwks); err != nil {
+			return fmt.Errorf("bearer authentication failed: %s", err.Error())
+		}
+	}
+	if missing := jwt.missingScopes(scopes); len(missing) > 0 {
+		return &ScopeError{Scheme: schemeName, Missing: missing}
+	}
+	return nil
+}
+
+// validateBasic checks the `Authorization` header carries base64 encoded `username:password` credentials.
+func (rme *ResponseMockEngine) validateBasic(request *http.Request) error {
+	authHeader := request.Header.Get("Authorization")
+	if authHeader == "" {
+		return fmt.Errorf("basic authentication failed: basic credentials not found, " +
+			"no `Authorization` header found in request")
+	}
+	if len(authHeader) < 6 || !strings.EqualFold(authHeader[:6], "basic ") {
+		return fmt.Errorf("basic authentication failed: `Authorization` header does not use the `Basic` scheme")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(authHeader[6:]))
+	if err != nil {
+		return fmt.Errorf("basic authentication failed: credentials are not valid base64")
+	}
+	user, _, found := strings.Cut(string(decoded), ":")
+	if !found || user == "" {
+		return fmt.Errorf("basic authentication failed: credentials must be in the format `username:password`")
+	}
+	return nil
+}
+
+// validateMutualTLS checks the client presented a certificate during the TLS handshake.
+func (rme *ResponseMockEngine) validateMutualTLS(request *http.Request) error {
+	if request.TLS == nil || len(request.TLS.PeerCertificates) == 0 {
+		return fmt.Errorf("mutualTLS authentication failed: no client certificate presented")
+	}
+	return nil
+}
+
+func parseJWT(token string) (*jsonWebToken, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("token is not a JWT, expected 3 segments, found %d", len(parts))
+	}
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("JWT header is not valid base64url")
+	}
+	claimBytes, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("JWT claims are not valid base64url")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("JWT signature is not valid base64url")
+	}
+	jwt := &jsonWebToken{
+		signed:    []byte(parts[0] + "." + parts[1]),
+		signature: signature,
+	}
+	if err = json.Unmarshal(headerBytes, &jwt.header); err != nil {
+		return nil, fmt.Errorf("JWT header is not a JSON object")
+	}
+	if err = json.Unmarshal(claimBytes, &jwt.claims); err != nil {
+		return nil, fmt.Errorf("JWT claims are not a JSON object")
+	}
+	if alg, _ := jwt.header["alg"].(string); alg == "" {
+		return nil, fmt.Errorf("JWT header does not contain an `alg`")
+	}
+	return jwt, nil
+}
+
+func (jwt *jsonWebToken) checkTimes(now time.Time) error {
+	if exp, ok := jwt.claims["exp"].(float64); ok && now.Unix() >= int64(exp) {
+		return fmt.Errorf("JWT expired at %s", time.Unix(int64(exp), 0).UTC().Format(time.RFC3339))
+	}
+	if nbf, ok := jwt.claims["nbf"].(float64); ok && now.Unix() < int64(nbf) {
+		return fmt.Errorf("JWT is not valid before %s", time.Unix(int64(nbf), 0).UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// missingScopes returns every required scope not granted by the `scope`, `scp` or `scopes` claims.
+func (jwt *jsonWebToken) missingScopes(required []string) []string {
+	if len(required) == 0 {
+		return nil
+	}
+	granted := make(map[string]bool)
+	for _, claim := range []string{"scope", "scp", "scopes"} {
+		switch v := jwt.claims[claim].(type) {
+		case string:
+			for _, s := range strings.Fields(v) {
+				granted[s] = true
+			}
+		case []any:
+			for _, s := range v {
+				granted[fmt.Sprint(s)] = true
+			}
+		}
+	}
+	var missing []string
+	for _, s := range required {
+		if !granted[s] {
+			missing = append(missing, s)
+		}
+	}
+	return missing
+}
+
+// verify checks the JWT signature against the keys of a JWKS. When the token has a `kid` only that key is tried.
+func (jwt *jsonWebToken) verify(keys []*JSONWebKey) error {
+	alg, _ := jwt.header["alg"].(string)
+	if strings.EqualFold(alg, "none") {
+		return fmt.Errorf("unsigned JWTs are not accepted")
+	}
+	kid, _ := jwt.header["kid"].(string)
+	var tried int
+	var errs []error
+	for _, key := range keys {
+		if kid != "" && key.Kid != kid {
+			continue
+		}
+		if key.Alg != "" && key.Alg != alg {
+			continue
+		}
+		tried++
+		err := verifySignature(alg, key, jwt.signed, jwt.signature)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	if tried == 0 {
+		return fmt.Errorf("no key in the JWKS matches the JWT (kid: '%s', alg: '%s')", kid, alg)
+	}
+	return fmt.Errorf("JWT signature verification failed: %w", errors.Join(errs...))
+}
+
+func verifySignature(alg string, key *JSONWebKey, signed, signature []byte) error {
+	if len(alg) != 5 {
+		return fmt.Errorf("unsupported JWT algorithm '%s'", alg)
+	}
+	var hash crypto.Hash
+	switch alg[2:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported JWT algorithm '%s'", alg)
+	}
+	digest := hashBytes(hash, signed)
+
+	switch alg[:2] {
+	case "RS", "PS":
+		pub, err := key.rsaPublicKey()
+		if err != nil {
+			return err
+		}
+		if alg[:2] == "PS" {
+			return rsa.VerifyPSS(pub, hash, digest, signature, nil)
+		}
+		return rsa.VerifyPKCS1v15(pub, hash, digest, signature)
+	case "ES":
+		pub, err := key.ecdsaPublicKey()
+		if err != nil {
+			return err
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return fmt.Errorf("invalid ECDSA signature length")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return fmt.Errorf("invalid ECDSA signature")
+		}
+		return nil
+	case "HS":
+		if key.Kty != "oct" {
+			return fmt.Errorf("key '%s' is not a symmetric key", key.Kid)
+		}
+		secret, err := base64.RawURLEncoding.DecodeString(key.K)
+		if err != nil {
+			return fmt.Errorf("key '%s' has an invalid `k` value", key.Kid)
+		}
+		mac := hmac.New(hash.New, secret)
+		mac.Write(signed)
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return fmt.Errorf("invalid HMAC signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported JWT algorithm '%s'", alg)
+}
+
+func hashBytes(hash crypto.Hash, b []byte) []byte {
+	switch hash {
+	case crypto.SHA384:
+		h := sha512.Sum384(b)
+		return h[:]
+	case crypto.SHA512:
+		h := sha512.Sum512(b)
+		return h[:]
+	default:
+		h := sha256.Sum256(b)
+		return h[:]
+	}
+}
+
+func (k *JSONWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	if k.Kty != "RSA" {
+		return nil, fmt.Errorf("key '%s' is not an RSA key", k.Kid)
+	}
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("key '%s' has an invalid modulus", k.Kid)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("key '%s' has an invalid exponent", k.Kid)
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+
+func (k *JSONWebKey) ecdsaPublicKey() (*ecdsa.PublicKey, error) {
+	if k.Kty != "EC" {
+		return nil, fmt.Errorf("key '%s' is not an EC key", k.Kid)
+	}
+	var curve elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("key '%s' uses an unsupported curve '%s'", k.Kid, k.Crv)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, fmt.Errorf("key '%s' has an invalid `x` value", k.Kid)
+	}
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil {
+		return nil, fmt.Errorf("key '%s' has an invalid `y` value", k.Kid)
+	}
+	return &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}, nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/pb33f/ranch/model"
 	"github.com/pb33f/ranch/service"
 	"github.com/pb33f/wiretap/daemon"
+	"github.com/pb33f/wiretap/shadow"
 )
 
 const (
@@ -18,6 +19,7 @@ const (
 
 type ReportService struct {
 	transactionStore bus.BusStore
+	shadowStore      bus.BusStore
 }
 
 type GenerateReport struct {
@@ -25,6 +27,8 @@ type GenerateReport struct {
 
 type ReportResponse struct {
 	Transactions []*daemon.HttpTransaction `json:"transactions,omitempty"`
+	// ShadowAPI is the undocumented traffic observed, when a contract is loaded.
+	ShadowAPI []*shadow.Finding `json:"shadowApi,omitempty"`
 }
 
 func NewReportService() *ReportService {
@@ -32,6 +36,7 @@ func NewReportService() *ReportService {
 	transactionStore := storeManager.GetStore(daemon.WiretapServiceChan)
 	return &ReportService{
 		transactionStore: transactionStore,
+		shadowStore:      storeManager.GetStore(shadow.ShadowServiceChan),
 	}
 }
 
@@ -60,7 +65,11 @@ func (rs *ReportService) buildReport(request *model.Request, core service.Fabric
 				transactions = append(transactions, i)
 			}
 		}
-		core.SendResponse(request, &ReportResponse{transactions})
+		response := &ReportResponse{Transactions: transactions}
+		if rs.shadowStore != nil {
+			response.ShadowAPI, _ = rs.shadowStore.GetValue(shadow.FindingsKey).([]*shadow.Finding)
+		}
+		core.SendResponse(request, response)
 
 	} else {
 		core.SendErrorResponse(request, 400, "Invalid report request")
@@ -0,0 +1,220 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+// Package shadow detects "shadow API" traffic, the traffic a contract does not describe: requests for undocumented
+// paths and methods, and responses with undocumented status codes or content types. Findings are aggregated, so
+// every kind of undocumented traffic is reported once, with the number of times it was observed.
+package shadow
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pb33f/libopenapi-validator/paths"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"github.com/pb33f/wiretap/shared"
+	"github.com/pterm/pterm"
+)
+
+const (
+	UndocumentedPath        = "undocumented-path"
+	UndocumentedOperation   = "undocumented-operation"
+	UndocumentedStatus      = "undocumented-status"
+	UndocumentedContentType = "undocumented-content-type"
+)
+
+// Kinds are the kinds of undocumented traffic, in the order they are reported.
+var Kinds = []string{UndocumentedPath, UndocumentedOperation, UndocumentedStatus, UndocumentedContentType}
+
+// identifierSegment matches the path segments that identify a resource, numbers and UUIDs.
+var identifierSegment = regexp.MustCompile(`^([0-9]+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})$`)
+
+// maxExamples is the number of example URLs kept for a finding.
+const maxExamples = 3
+
+// Finding is a kind of undocumented traffic. The path is the path of the contract, or for undocumented paths the
+// observed path with the segments that identify a resource collapsed into `{id}`.
+type Finding struct {
+	Kind        string   `json:"kind"`
+	Method      string   `json:"method"`
+	Path        string   `json:"path"`
+	StatusCode  int      `json:"statusCode,omitempty"`
+	ContentType string   `json:"contentType,omitempty"`
+	Message     string   `json:"message"`
+	Count       int      `json:"count"`
+	Examples    []string `json:"examples"`
+}
+
+// Detector compares traffic with a contract, and aggregates the traffic the contract does not describe.
+type Detector struct {
+	doc      *v3.Document
+	lock     sync.Mutex
+	findings map[string]*Finding
+	// order keeps the findings in the order they were first observed.
+	order []string
+}
+
+// NewDetector creates a detector for a contract.
+func NewDetector(doc *v3.Document) *Detector {
+	return &Detector{doc: doc, findings: make(map[string]*Finding)}
+}
+
+// Observe detects undocumented traffic in a request, and the status code and content type of its response. The
+// content type is only checked when the response has a body. A copy of the finding the traffic was aggregated into is
+// returned, nil when the traffic is documented.
+func (d *Detector) Observe(request *http.Request, statusCode int, contentType string, hasBody bool) *Finding {
+	if d.doc == nil || d.doc.Paths == nil {
+		return nil
+	}
+	method := strings.ToUpper(request.Method)
+	pathItem, errs, template := paths.FindPath(request, d.doc)
+	if pathItem == nil {
+		path := collapsePath(paths.StripRequestPath(request, d.doc))
+		return d.record(&Finding{Kind: UndocumentedPath, Method: method, Path: path,
+			Message: fmt.Sprintf("%s %s is not documented", method, path)}, request)
+	}
+	var operation *v3.Operation
+	if len(errs) == 0 {
+		operation, _ = pathItem.GetOperations().Get(strings.ToLower(method))
+	}
+	if operation == nil {
+		return d.record(&Finding{Kind: UndocumentedOperation, Method: method, Path: template,
+			Message: fmt.Sprintf("%s is not documented for %s", method, template)}, request)
+	}
+	if operation.Responses == nil || statusCode == 0 {
+		return nil
+	}
+
+	response := documentedResponse(operation.Responses, statusCode)
+	if response == nil {
+		return d.record(&Finding{Kind: UndocumentedStatus, Method: method, Path: template, StatusCode: statusCode,
+			Message: fmt.Sprintf("%s %s responded with undocumented status %d", method, template, statusCode)},
+			request)
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if !hasBody || mediaType == "" || documentedContentType(response.Content, mediaType) {
+		return nil
+	}
+	return d.record(&Finding{Kind: UndocumentedContentType, Method: method, Path: template, StatusCode: statusCode,
+		ContentType: mediaType, Message: fmt.Sprintf("%s %s responded %d with undocumented content type %s",
+			method, template, statusCode, mediaType)}, request)
+}
+
+// Key identifies the kind of undocumented traffic a finding aggregates.
+func (f *Finding) Key() string {
+	return strings.Join([]string{f.Kind, f.Method, f.Path, strconv.Itoa(f.StatusCode), f.ContentType}, " ")
+}
+
+func (d *Detector) record(finding *Finding, request *http.Request) *Finding {
+	key := finding.Key()
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	existing := d.findings[key]
+	if existing == nil {
+		existing = finding
+		existing.Examples = []string{}
+		d.findings[key] = existing
+		d.order = append(d.order, key)
+	}
+	existing.Count++
+	example := request.URL.String()
+	if len(existing.Examples) < maxExamples && !slices.Contains(existing.Examples, example) {
+		existing.Examples = append(existing.Examples, example)
+	}
+	return existing.copy()
+}
+
+func (f *Finding) copy() *Finding {
+	c := *f
+	c.Examples = append([]string{}, f.Examples...)
+	return &c
+}
+
+// Findings returns a copy of the findings, ordered by kind and then by the order they were first observed.
+func (d *Detector) Findings() []*Finding {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	findings := make([]*Finding, 0, len(d.order))
+	for _, key := range d.order {
+		findings = append(findings, d.findings[key].copy())
+	}
+	SortFindings(findings)
+	return findings
+}
+
+// SortFindings orders findings by kind, keeping the order of findings of the same kind.
+func SortFindings(findings []*Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		return slices.Index(Kinds, findings[i].Kind) < slices.Index(Kinds, findings[j].Kind)
+	})
+}
+
+// PrintFindings prints the undocumented traffic that was observed.
+func PrintFindings(findings []*Finding) {
+	if len(findings) == 0 {
+		return
+	}
+	pterm.Info.Printf("Detected %d %s of undocumented (shadow API) traffic:\n", len(findings),
+		shared.Pluralize(len(findings), "kind", "kinds"))
+	for _, finding := range findings {
+		pterm.Printf("👻 %s %s (%d×)\n", pterm.LightCyan(finding.Kind), finding.Message, finding.Count)
+	}
+	pterm.Println()
+}
+
+// collapsePath replaces the segments of a path that identify a resource with `{id}`, so requests for different
+// resources of an undocumented path are aggregated.
+func collapsePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if identifierSegment.MatchString(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// documentedResponse returns the response documented for a status code, by the code itself, its range (such as
+// 4XX) or the default response.
+func documentedResponse(responses *v3.Responses, statusCode int) *v3.Response {
+	code := strconv.Itoa(statusCode)
+	if responses.Codes != nil {
+		if response, found := responses.Codes.Get(code); found {
+			return response
+		}
+		for pair := orderedmap.First(responses.Codes); pair != nil; pair = pair.Next() {
+			if strings.EqualFold(pair.Key(), code[:1]+"XX") {
+				return pair.Value()
+			}
+		}
+	}
+	return responses.Default
+}
+
+// documentedContentType checks if a media type is documented, by itself or a media type range.
+func documentedContentType(content *orderedmap.Map[string, *v3.MediaType], mediaType string) bool {
+	if content == nil {
+		return false
+	}
+	for pair := orderedmap.First(content); pair != nil; pair = pair.Next() {
+		documented, _, err := mime.ParseMediaType(pair.Key())
+		if err != nil {
+			continue
+		}
+		if documented == mediaType || documented == "*/*" ||
+			(strings.HasSuffix(documented, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(documented, "*"))) {
+			return true
+		}
+	}
+	return false
+}
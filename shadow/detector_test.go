@@ -0,0 +1,87 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package shadow
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const petContract = `openapi: 3.1.0
+info:
+  title: pets
+  version: 1.0.0
+servers:
+  - url: https://api.pets.com/v1
+paths:
+  /pets/{id}:
+    get:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        200:
+          description: a pet
+          content:
+            application/json:
+              schema:
+                type: object
+        4XX:
+          description: a problem
+          content:
+            application/problem+json:
+              schema:
+                type: object`
+
+func request(t *testing.T, method, url string) *http.Request {
+	r, err := http.NewRequest(method, url, nil)
+	require.NoError(t, err)
+	return r
+}
+
+func TestDetector_Findings(t *testing.T) {
+	document, err := libopenapi.NewDocument([]byte(petContract))
+	require.NoError(t, err)
+	model, errs := document.BuildV3Model()
+	require.Empty(t, errs)
+	detector := NewDetector(&model.Model)
+
+	// documented traffic.
+	assert.Nil(t, detector.Observe(request(t, "GET", "https://api.pets.com/v1/pets/1"), 200,
+		"application/json; charset=utf-8", true))
+	assert.Nil(t, detector.Observe(request(t, "GET", "https://api.pets.com/v1/pets/2"), 404,
+		"application/problem+json", true))
+	assert.Nil(t, detector.Observe(request(t, "GET", "https://api.pets.com/v1/pets/3"), 200, "text/html", false))
+
+	// undocumented traffic.
+	detector.Observe(request(t, "GET", "https://api.pets.com/v1/pets/1"), 200, "text/html", true)
+	detector.Observe(request(t, "GET", "https://api.pets.com/v1/pets/1"), 500, "", false)
+	detector.Observe(request(t, "DELETE", "https://api.pets.com/v1/pets/1"), 204, "", false)
+	detector.Observe(request(t, "GET", "https://api.pets.com/v1/owners/1"), 200, "application/json", true)
+	finding := detector.Observe(request(t, "GET", "https://api.pets.com/v1/owners/2"), 200, "application/json", true)
+	require.NotNil(t, finding)
+	assert.Equal(t, 2, finding.Count)
+
+	findings := detector.Findings()
+	require.Len(t, findings, 4)
+	assert.Equal(t, &Finding{Kind: UndocumentedPath, Method: "GET", Path: "/owners/{id}", Count: 2,
+		Message:  "GET /owners/{id} is not documented",
+		Examples: []string{"https://api.pets.com/v1/owners/1", "https://api.pets.com/v1/owners/2"}}, findings[0])
+	assert.Equal(t, UndocumentedOperation, findings[1].Kind)
+	assert.Equal(t, "DELETE", findings[1].Method)
+	assert.Equal(t, "/pets/{id}", findings[1].Path)
+	assert.Equal(t, UndocumentedStatus, findings[2].Kind)
+	assert.Equal(t, 500, findings[2].StatusCode)
+	assert.Equal(t, UndocumentedContentType, findings[3].Kind)
+	assert.Equal(t, "text/html", findings[3].ContentType)
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package shadow
+
+import (
+	"github.com/google/uuid"
+	"github.com/pb33f/ranch/bus"
+	"github.com/pb33f/ranch/model"
+	"github.com/pb33f/ranch/service"
+	configModel "github.com/pb33f/wiretap/config"
+	"github.com/pb33f/wiretap/daemon"
+	"github.com/pb33f/wiretap/shared"
+)
+
+const (
+	ShadowServiceChan      = "shadow-service"
+	ShadowBroadcastChan    = "shadow-broadcast"
+	GetShadowReportRequest = "get-shadow-report"
+	// FindingsKey is the key of the findings in the store of the shadow service.
+	FindingsKey = "findings"
+)
+
+// Report is the undocumented traffic observed so far.
+type Report struct {
+	Findings []*Finding `json:"findings"`
+}
+
+// ShadowService detects undocumented traffic in every transaction proxied to the API, except on paths validation is
+// ignored for. The findings are kept in the store of the service as they change, so the report can include them,
+// and each finding is broadcast to the monitor as it is observed. The findings can be requested at any time.
+type ShadowService struct {
+	detector      *Detector
+	config        *shared.WiretapConfiguration
+	store         bus.BusStore
+	broadcastChan *bus.Channel
+}
+
+// NewShadowService creates a shadow service for the contract of the wiretap service, and registers it as a traffic
+// observer of the wiretap service.
+func NewShadowService(wiretapService *daemon.WiretapService, config *shared.WiretapConfiguration) *ShadowService {
+	eventBus := bus.GetBus()
+
+	// create the broadcast channel of the monitor and set it to galactic
+	channel := eventBus.GetChannelManager().CreateChannel(ShadowBroadcastChan)
+	channel.SetGalactic(ShadowBroadcastChan)
+
+	ss := &ShadowService{
+		detector:      NewDetector(wiretapService.GetDocumentModel()),
+		config:        config,
+		store:         eventBus.GetStoreManager().CreateStore(ShadowServiceChan),
+		broadcastChan: channel,
+	}
+	wiretapService.AddTrafficObserver(ss)
+	return ss
+}
+
+// ObserveTransaction detects undocumented traffic in a proxied transaction.
+func (ss *ShadowService) ObserveTransaction(transaction *daemon.ObservedTransaction) {
+	path := transaction.Request.URL.Path
+	if configModel.IgnoreValidationOnPath(path, ss.config) && !configModel.PathValidationAllowListed(path, ss.config) {
+		return
+	}
+	finding := ss.detector.Observe(transaction.Request, transaction.StatusCode,
+		transaction.ResponseHeader.Get("Content-Type"), len(transaction.ResponseBody) > 0)
+	if finding != nil {
+		ss.store.Put(FindingsKey, ss.detector.Findings(), nil)
+		ss.broadcastFinding(finding)
+	}
+}
+
+// broadcastFinding sends a finding to the monitor, every time traffic is aggregated into it.
+func (ss *ShadowService) broadcastFinding(finding *Finding) {
+	id, _ := uuid.NewUUID()
+	ss.broadcastChan.Send(&model.Message{
+		Id:            &id,
+		DestinationId: &id,
+		Channel:       ShadowBroadcastChan,
+		Destination:   ShadowBroadcastChan,
+		Payload:       finding,
+		Direction:     model.ResponseDir,
+	})
+}
+
+func (ss *ShadowService) HandleServiceRequest(request *model.Request, core service.FabricServiceCore) {
+	switch request.RequestCommand {
+	case GetShadowReportRequest:
+		core.SendResponse(request, &Report{Findings: ss.detector.Findings()})
+	default:
+		core.HandleUnknownRequest(request)
+	}
+}
+
+// OnServerShutdown prints the undocumented traffic observed.
+func (ss *ShadowService) OnServerShutdown() {
+	PrintFindings(ss.detector.Findings())
+}
@@ -0,0 +1,49 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package shadow
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/ranch/bus"
+	"github.com/pb33f/ranch/model"
+	"github.com/pb33f/wiretap/daemon"
+	"github.com/pb33f/wiretap/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShadowService_BroadcastsFindings(t *testing.T) {
+	doc, err := libopenapi.NewDocument([]byte(petContract))
+	require.NoError(t, err)
+	wiretapService, err := daemon.NewWiretapService(doc, &shared.WiretapConfiguration{})
+	require.NoError(t, err)
+	ss := NewShadowService(wiretapService, &shared.WiretapConfiguration{})
+
+	handler, err := bus.GetBus().ListenStream(ShadowBroadcastChan)
+	require.NoError(t, err)
+	defer handler.Close()
+	broadcast := make(chan *Finding, 1)
+	handler.Handle(func(msg *model.Message) {
+		if finding, ok := msg.Payload.(*Finding); ok {
+			broadcast <- finding
+		}
+	}, func(error) {})
+
+	request, _ := http.NewRequest(http.MethodGet, "https://api.pets.com/v1/owners/1", nil)
+	ss.ObserveTransaction(&daemon.ObservedTransaction{Request: request, StatusCode: 200})
+
+	select {
+	case finding := <-broadcast:
+		assert.Equal(t, UndocumentedPath, finding.Kind)
+		assert.Equal(t, 1, finding.Count)
+	case <-time.After(time.Second):
+		t.Fatal("finding was not broadcast to the monitor")
+	}
+}
@@ -46,19 +46,36 @@ type WiretapConfiguration struct {
 	MockMode                    bool                                        `json:"mockMode,omitempty" yaml:"mockMode,omitempty"`
 	MockModeList                []string                                    `json:"mockModeList,omitempty" yaml:"mockModeList,omitempty"`
 	StaticMockDir               string                                      `json:"staticMockDir,omitempty" yaml:"staticMockDir,omitempty"`
+	StaticMockStrict            bool                                        `json:"staticMockStrict,omitempty" yaml:"staticMockStrict,omitempty"`
+	StaticMockRecord            *WiretapRecordConfig                        `json:"staticMockRecord,omitempty" yaml:"staticMockRecord,omitempty"`
 	UseAllMockResponseFields    bool                                        `json:"useAllMockResponseFields,omitempty" yaml:"useAllMockResponseFields,omitempty"`
 	MockModePretty              bool                                        `json:"mockModePretty,omitempty" yaml:"mockModePretty,omitempty"`
+	MockScenarios               []*WiretapMockScenario                      `json:"mockScenarios,omitempty" yaml:"mockScenarios,omitempty"`
+	MockCallbacks               *WiretapCallbackConfig                      `json:"mockCallbacks,omitempty" yaml:"mockCallbacks,omitempty"`
+	MockEcho                    bool                                        `json:"mockEcho,omitempty" yaml:"mockEcho,omitempty"`
+	MockCollectionSize          int                                         `json:"mockCollectionSize,omitempty" yaml:"mockCollectionSize,omitempty"`
+	MockJWKS                    string                                      `json:"mockJwks,omitempty" yaml:"mockJwks,omitempty"`
 	Base                        string                                      `json:"base,omitempty" yaml:"base,omitempty"`
 	HAR                         string                                      `json:"har,omitempty" yaml:"har,omitempty"`
 	HARValidate                 bool                                        `json:"harValidate,omitempty" yaml:"harValidate,omitempty"`
 	HARPathAllowList            []string                                    `json:"harPathAllowList,omitempty" yaml:"harPathAllowList,omitempty"`
+	HARInclude                  []string                                    `json:"harInclude,omitempty" yaml:"harInclude,omitempty"`
+	HARExclude                  []string                                    `json:"harExclude,omitempty" yaml:"harExclude,omitempty"`
+	CaptureFormat               string                                      `json:"captureFormat,omitempty" yaml:"captureFormat,omitempty"`
+	HARExport                   string                                      `json:"harExport,omitempty" yaml:"harExport,omitempty"`
+	HARReplay                   *WiretapHARReplayConfig                     `json:"harReplay,omitempty" yaml:"harReplay,omitempty"`
+	Learn                       *WiretapLearnConfig                         `json:"learn,omitempty" yaml:"learn,omitempty"`
+	Drift                       *WiretapDriftConfig                         `json:"drift,omitempty" yaml:"drift,omitempty"`
 	StreamReport                bool                                        `json:"streamReport,omitempty" yaml:"streamReport,omitempty"`
 	ReportFile                  string                                      `json:"reportFilename,omitempty" yaml:"reportFilename,omitempty"`
+	ReportFormat                string                                      `json:"reportFormat,omitempty" yaml:"reportFormat,omitempty"`
 	IgnoreRedirects             []string                                    `json:"ignoreRedirects,omitempty" yaml:"ignoreRedirects,omitempty"`
 	RedirectAllowList           []string                                    `json:"redirectAllowList,omitempty" yaml:"redirectAllowList,omitempty"`
 	WebsocketConfigs            map[string]*WiretapWebsocketConfig          `json:"websockets" yaml:"websockets"`
 	IgnoreValidation            []string                                    `json:"ignoreValidation,omitempty" yaml:"ignoreValidation,omitempty"`
 	ValidationAllowList         []string                                    `json:"validationAllowList,omitempty" yaml:"validationAllowList,omitempty"`
+	ValidationRules             []*WiretapValidationRule                    `json:"validationRules,omitempty" yaml:"validationRules,omitempty"`
+	FailOn                      string                                      `json:"failOn,omitempty" yaml:"failOn,omitempty"`
 	StrictRedirectLocation      bool                                        `json:"strictRedirectLocation,omitempty" yaml:"strictRedirectLocation,omitempty"`
 	IgnorePathRewrite           []*IgnoreRewriteConfig                      `json:"ignorePathRewrite,omitempty" yaml:"ignorePathRewrite,omitempty"`
 	HARFile                     *harhar.HAR                                 `json:"-" yaml:"-"`
@@ -73,7 +90,10 @@ type WiretapConfiguration struct {
 	CompiledRedirectAllowList   []*CompiledRedirect                         `json:"-" yaml:"-"`
 	CompiledIgnoreValidations   []*CompiledRedirect                         `json:"-" yaml:"-"`
 	CompiledValidationAllowList []*CompiledRedirect                         `json:"-" yaml:"-"`
+	CompiledValidationRules     []*CompiledValidationRule                   `json:"-" yaml:"-"`
 	CompiledIgnorePathRewrite   []*CompiledIgnoreRewrite                    `json:"-" yaml:"-"`
+	CompiledMockScenarios       []*CompiledMockScenario                     `json:"-" yaml:"-"`
+	CompiledWebhookTriggers     []*CompiledWebhookTrigger                   `json:"-" yaml:"-"`
 	FS                          embed.FS                                    `json:"-"`
 	Logger                      *slog.Logger
 }
@@ -159,6 +179,35 @@ func (wtc *WiretapConfiguration) CompileMockModeList() {
 	}
 }
 
+func (wtc *WiretapConfiguration) CompileMockScenarios() {
+	wtc.CompiledMockScenarios = make([]*CompiledMockScenario, 0)
+	for _, x := range wtc.MockScenarios {
+		compiled := &CompiledMockScenario{
+			Scenario: x,
+		}
+		if x.Path != "" {
+			compiled.CompiledPath = glob.MustCompile(wtc.ReplaceWithVariables(x.Path))
+		}
+		wtc.CompiledMockScenarios = append(wtc.CompiledMockScenarios, compiled)
+	}
+}
+
+func (wtc *WiretapConfiguration) CompileMockCallbacks() {
+	wtc.CompiledWebhookTriggers = make([]*CompiledWebhookTrigger, 0)
+	if wtc.MockCallbacks == nil {
+		return
+	}
+	for _, x := range wtc.MockCallbacks.Webhooks {
+		compiled := &CompiledWebhookTrigger{
+			Trigger: x,
+		}
+		if x.Path != "" {
+			compiled.CompiledPath = glob.MustCompile(wtc.ReplaceWithVariables(x.Path))
+		}
+		wtc.CompiledWebhookTriggers = append(wtc.CompiledWebhookTriggers, compiled)
+	}
+}
+
 func (wtc *WiretapConfiguration) CompileHardErrorList() {
 	wtc.CompiledHardErrorList = make([]glob.Glob, 0)
 	for _, x := range wtc.HardErrorsList {
@@ -290,6 +339,95 @@ type CompiledPathRewrite struct {
 	CompiledTarget glob.Glob
 }
 
+// WiretapMockScenario binds a path glob and a set of request predicates to a mocked status code, named example and
+// delay. Predicate values are compared as strings or regular expressions, body predicates are keyed by JSONPath.
+type WiretapMockScenario struct {
+	Name       string            `json:"name,omitempty" yaml:"name,omitempty"`
+	Path       string            `json:"path,omitempty" yaml:"path,omitempty"`
+	Method     string            `json:"method,omitempty" yaml:"method,omitempty"`
+	Query      map[string]string `json:"query,omitempty" yaml:"query,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body       map[string]string `json:"body,omitempty" yaml:"body,omitempty"`
+	StatusCode int               `json:"statusCode,omitempty" yaml:"statusCode,omitempty"`
+	Example    string            `json:"example,omitempty" yaml:"example,omitempty"`
+	Delay      int               `json:"delay,omitempty" yaml:"delay,omitempty"`
+}
+
+type CompiledMockScenario struct {
+	Scenario     *WiretapMockScenario
+	CompiledPath glob.Glob
+}
+
+// WiretapCallbackConfig controls the callbacks and webhooks fired in mock mode. Callbacks are sent to the URL
+// resolved from the triggering request, or the target if that is not possible. Webhooks are sent to the target
+// of the trigger, or the target of this configuration. Delays are in milliseconds.
+type WiretapCallbackConfig struct {
+	Enabled  bool                     `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	Target   string                   `json:"target,omitempty" yaml:"target,omitempty"`
+	Delay    int                      `json:"delay,omitempty" yaml:"delay,omitempty"`
+	Webhooks []*WiretapWebhookTrigger `json:"webhooks,omitempty" yaml:"webhooks,omitempty"`
+}
+
+// WiretapWebhookTrigger fires the named webhook from the specification, when a mocked request matches the path
+// glob and method. A nil delay falls back to the delay of the callback configuration.
+type WiretapWebhookTrigger struct {
+	Webhook string `json:"webhook,omitempty" yaml:"webhook,omitempty"`
+	Path    string `json:"path,omitempty" yaml:"path,omitempty"`
+	Method  string `json:"method,omitempty" yaml:"method,omitempty"`
+	Target  string `json:"target,omitempty" yaml:"target,omitempty"`
+	Delay   *int   `json:"delay,omitempty" yaml:"delay,omitempty"`
+}
+
+type CompiledWebhookTrigger struct {
+	Trigger      *WiretapWebhookTrigger
+	CompiledPath glob.Glob
+}
+
+// WiretapRecordConfig controls record mode, which writes every proxied transaction into the static mock directory
+// as a mock definition. Only the request headers listed are matched, all query parameters are matched unless some
+// are listed. Redacted headers, query parameters and JSON fields are never written, they are added to the defaults
+// (authorization, cookies, API keys, passwords, secrets and tokens). Response bodies larger than the threshold, in
+// bytes, are written into their own file.
+type WiretapRecordConfig struct {
+	Enabled           bool     `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	Headers           []string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	QueryParams       []string `json:"queryParams,omitempty" yaml:"queryParams,omitempty"`
+	MatchBody         bool     `json:"matchBody,omitempty" yaml:"matchBody,omitempty"`
+	Redact            []string `json:"redact,omitempty" yaml:"redact,omitempty"`
+	BodyFileThreshold int      `json:"bodyFileThreshold,omitempty" yaml:"bodyFileThreshold,omitempty"`
+}
+
+// WiretapLearnConfig controls learning mode, which drafts an OpenAPI 3.1 document from proxied traffic (and the
+// HAR file, when one is loaded). The document is written to the output file when wiretap shuts down, as JSON when
+// the file has a .json extension and as YAML otherwise.
+type WiretapLearnConfig struct {
+	Enabled bool   `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	Output  string `json:"output,omitempty" yaml:"output,omitempty"`
+	Title   string `json:"title,omitempty" yaml:"title,omitempty"`
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+}
+
+// WiretapDriftConfig controls contract drift detection, which compares traffic with the contract and suggests the
+// contract updates (as a JSON Patch or an OpenAPI Overlay) that would make the traffic compliant. Differences
+// observed fewer times than the minimum (one by default) are not suggested. Suggestions are written to the output
+// file when wiretap shuts down, or once a HAR file has been validated.
+type WiretapDriftConfig struct {
+	Enabled        bool   `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	Output         string `json:"output,omitempty" yaml:"output,omitempty"`
+	Format         string `json:"format,omitempty" yaml:"format,omitempty"`
+	MinOccurrences int    `json:"minOccurrences,omitempty" yaml:"minOccurrences,omitempty"`
+}
+
+// WiretapHARReplayConfig configures replaying a HAR file against the API. Responses are compared with the recorded
+// responses, ignoring the headers listed and the parts of the body the JSON pointers point to (a `*` token matches
+// any key or index). The report is written as JSON, when a file is set.
+type WiretapHARReplayConfig struct {
+	Enabled       bool     `json:"enabled,omitempty" yaml:"enabled,omitempty" mapstructure:"enabled"`
+	IgnoreHeaders []string `json:"ignoreHeaders,omitempty" yaml:"ignoreHeaders,omitempty" mapstructure:"ignoreHeaders"`
+	IgnorePaths   []string `json:"ignorePaths,omitempty" yaml:"ignorePaths,omitempty" mapstructure:"ignorePaths"`
+	Report        string   `json:"report,omitempty" yaml:"report,omitempty" mapstructure:"report"`
+}
+
 type CompiledRedirect struct {
 	CompiledPath glob.Glob
 }
@@ -0,0 +1,116 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: AGPL
+
+package shared
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	JSONValueAdded   = "added"
+	JSONValueRemoved = "removed"
+	JSONValueChanged = "changed"
+)
+
+// JSONDifference is a value that differs between two JSON documents. The path is a JSON pointer, and the change is
+// one of added, removed or changed.
+type JSONDifference struct {
+	Path     string `json:"path"`
+	Change   string `json:"change"`
+	Expected any    `json:"expected,omitempty"`
+	Actual   any    `json:"actual,omitempty"`
+}
+
+// DiffJSON compares two decoded JSON documents, objects key by key and arrays index by index, and returns every
+// difference ordered by path.
+func DiffJSON(expected, actual any) []*JSONDifference {
+	var differences []*JSONDifference
+	diffJSON("", expected, actual, &differences)
+	return differences
+}
+
+func diffJSON(path string, expected, actual any, differences *[]*JSONDifference) {
+	switch e := expected.(type) {
+	case map[string]any:
+		a, ok := actual.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(e)+len(a))
+		for key := range e {
+			keys = append(keys, key)
+		}
+		for key := range a {
+			if _, found := e[key]; !found {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			expectedValue, inExpected := e[key]
+			actualValue, inActual := a[key]
+			child := path + "/" + escapePointerToken(key)
+			switch {
+			case !inActual:
+				*differences = append(*differences, &JSONDifference{Path: child, Change: JSONValueRemoved,
+					Expected: expectedValue})
+			case !inExpected:
+				*differences = append(*differences, &JSONDifference{Path: child, Change: JSONValueAdded,
+					Actual: actualValue})
+			default:
+				diffJSON(child, expectedValue, actualValue, differences)
+			}
+		}
+		return
+	case []any:
+		a, ok := actual.([]any)
+		if !ok {
+			break
+		}
+		for i := 0; i < len(e) || i < len(a); i++ {
+			child := path + "/" + strconv.Itoa(i)
+			switch {
+			case i >= len(a):
+				*differences = append(*differences, &JSONDifference{Path: child, Change: JSONValueRemoved,
+					Expected: e[i]})
+			case i >= len(e):
+				*differences = append(*differences, &JSONDifference{Path: child, Change: JSONValueAdded,
+					Actual: a[i]})
+			default:
+				diffJSON(child, e[i], a[i], differences)
+			}
+		}
+		return
+	}
+	if !jsonEqual(expected, actual) {
+		*differences = append(*differences, &JSONDifference{Path: path, Change: JSONValueChanged,
+			Expected: expected, Actual: actual})
+	}
+}
+
+// escapePointerToken escapes a key so it can be used as a token of a JSON pointer.
+func escapePointerToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// PointerMatches checks if a JSON pointer is, or is inside, the value a pattern points to. A `*` token of the
+// pattern matches any key or index.
+func PointerMatches(pattern, pointer string) bool {
+	patternTokens, err := parsePointer(pattern)
+	if err != nil {
+		return false
+	}
+	tokens, err := parsePointer(pointer)
+	if err != nil || len(tokens) < len(patternTokens) {
+		return false
+	}
+	for i, token := range patternTokens {
+		if token != "*" && token != tokens[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,264 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: AGPL
+
+package shared
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// JSONPatchOperation is a single operation of a JSON Patch (RFC 6902) document.
+type JSONPatchOperation struct {
+	Op    string `json:"op" yaml:"op"`
+	Path  string `json:"path" yaml:"path"`
+	From  string `json:"from,omitempty" yaml:"from,omitempty"`
+	Value any    `json:"value,omitempty" yaml:"value,omitempty"`
+}
+
+// ApplyJSONPatch applies JSON Patch operations to a decoded JSON document, in order, and returns the patched
+// document. The document passed in may be modified.
+func ApplyJSONPatch(document any, operations []JSONPatchOperation) (any, error) {
+	var err error
+	for i, op := range operations {
+		switch op.Op {
+		case "add":
+			document, err = pointerAdd(document, op.Path, copyJSON(op.Value))
+		case "remove":
+			document, _, err = pointerRemove(document, op.Path)
+		case "replace":
+			if document, _, err = pointerRemove(document, op.Path); err == nil {
+				document, err = pointerAdd(document, op.Path, copyJSON(op.Value))
+			}
+		case "move":
+			var value any
+			if document, value, err = pointerRemove(document, op.From); err == nil {
+				document, err = pointerAdd(document, op.Path, value)
+			}
+		case "copy":
+			var value any
+			if value, err = pointerGet(document, op.From); err == nil {
+				document, err = pointerAdd(document, op.Path, copyJSON(value))
+			}
+		case "test":
+			var value any
+			if value, err = pointerGet(document, op.Path); err == nil && !jsonEqual(value, op.Value) {
+				err = fmt.Errorf("value at '%s' is not equal to the test value", op.Path)
+			}
+		default:
+			err = fmt.Errorf("unknown operation '%s'", op.Op)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("patch operation %d (%s '%s') failed: %w", i, op.Op, op.Path, err)
+		}
+	}
+	return document, nil
+}
+
+// ApplyMergePatch applies a JSON Merge Patch (RFC 7396) to a decoded JSON document and returns the patched document.
+// Fields set to null in the patch are removed, objects are merged and anything else replaces the target.
+func ApplyMergePatch(document, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return copyJSON(patch)
+	}
+	target, ok := document.(map[string]any)
+	if !ok {
+		target = make(map[string]any)
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+		target[key] = ApplyMergePatch(target[key], value)
+	}
+	return target
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("pointer '%s' must start with '/'", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if allowEnd && token == "-" {
+		return length, nil
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, fmt.Errorf("invalid array index '%s'", token)
+	}
+	limit := length - 1
+	if allowEnd {
+		limit = length
+	}
+	if index > limit {
+		return 0, fmt.Errorf("array index %d is out of range", index)
+	}
+	return index, nil
+}
+
+func pointerGet(document any, pointer string) (any, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	current := document
+	for _, token := range tokens {
+		switch c := current.(type) {
+		case map[string]any:
+			value, ok := c[token]
+			if !ok {
+				return nil, fmt.Errorf("'%s' does not exist", pointer)
+			}
+			current = value
+		case []any:
+			index, iErr := arrayIndex(token, len(c), false)
+			if iErr != nil {
+				return nil, iErr
+			}
+			current = c[index]
+		default:
+			return nil, fmt.Errorf("'%s' does not exist", pointer)
+		}
+	}
+	return current, nil
+}
+
+// pointerAdd adds a value at a pointer. Arrays are inserted into, an empty pointer replaces the document.
+func pointerAdd(document any, pointer string, value any) (any, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	parentPointer := pointer[:strings.LastIndex(pointer, "/")]
+	parent, err := pointerGet(document, parentPointer)
+	if err != nil {
+		return nil, err
+	}
+	last := tokens[len(tokens)-1]
+	switch p := parent.(type) {
+	case map[string]any:
+		p[last] = value
+		return document, nil
+	case []any:
+		index, iErr := arrayIndex(last, len(p), true)
+		if iErr != nil {
+			return nil, iErr
+		}
+		p = append(p[:index], append([]any{value}, p[index:]...)...)
+		return pointerSet(document, parentPointer, p)
+	}
+	return nil, fmt.Errorf("'%s' is not an object or array", parentPointer)
+}
+
+// pointerRemove removes the value at a pointer, and returns it.
+func pointerRemove(document any, pointer string) (any, any, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, document, nil
+	}
+	parentPointer := pointer[:strings.LastIndex(pointer, "/")]
+	parent, err := pointerGet(document, parentPointer)
+	if err != nil {
+		return nil, nil, err
+	}
+	last := tokens[len(tokens)-1]
+	switch p := parent.(type) {
+	case map[string]any:
+		value, ok := p[last]
+		if !ok {
+			return nil, nil, fmt.Errorf("'%s' does not exist", pointer)
+		}
+		delete(p, last)
+		return document, value, nil
+	case []any:
+		index, iErr := arrayIndex(last, len(p), false)
+		if iErr != nil {
+			return nil, nil, iErr
+		}
+		value := p[index]
+		p = append(p[:index:index], p[index+1:]...)
+		document, err = pointerSet(document, parentPointer, p)
+		return document, value, err
+	}
+	return nil, nil, fmt.Errorf("'%s' is not an object or array", parentPointer)
+}
+
+// pointerSet replaces the value at a pointer, used when an array has been resized.
+func pointerSet(document any, pointer string, value any) (any, error) {
+	if pointer == "" {
+		return value, nil
+	}
+	parentPointer := pointer[:strings.LastIndex(pointer, "/")]
+	parent, err := pointerGet(document, parentPointer)
+	if err != nil {
+		return nil, err
+	}
+	tokens, _ := parsePointer(pointer)
+	last := tokens[len(tokens)-1]
+	switch p := parent.(type) {
+	case map[string]any:
+		p[last] = value
+	case []any:
+		index, iErr := arrayIndex(last, len(p), false)
+		if iErr != nil {
+			return nil, iErr
+		}
+		p[index] = value
+	}
+	return document, nil
+}
+
+// copyJSON deep copies a decoded JSON value, so patch values are never shared between documents.
+func copyJSON(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		c := make(map[string]any, len(v))
+		for key, field := range v {
+			c[key] = copyJSON(field)
+		}
+		return c
+	case []any:
+		c := make([]any, len(v))
+		for i := range v {
+			c[i] = copyJSON(v[i])
+		}
+		return c
+	}
+	return value
+}
+
+// jsonEqual compares two decoded JSON values, numbers are compared by value whatever their Go type.
+func jsonEqual(a, b any) bool {
+	normalize := func(v any) any {
+		bytes, err := json.Marshal(v)
+		if err != nil {
+			return v
+		}
+		var out any
+		_ = json.Unmarshal(bytes, &out)
+		return out
+	}
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
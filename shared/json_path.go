@@ -0,0 +1,58 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: AGPL
+
+package shared
+
+import (
+	"fmt"
+
+	"github.com/vmware-labs/yaml-jsonpath/pkg/yamlpath"
+	"gopkg.in/yaml.v3"
+)
+
+// QueryJSONPath evaluates a JSONPath expression against a JSON document and returns the decoded value of every
+// node that matched. JSON is a subset of YAML, so the document is parsed as YAML to re-use the same path engine
+// that libopenapi uses.
+func QueryJSONPath(document []byte, expression string) ([]any, error) {
+	if len(document) == 0 {
+		return nil, nil
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(document, &root); err != nil {
+		return nil, fmt.Errorf("unable to parse document: %w", err)
+	}
+	path, err := yamlpath.NewPath(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSONPath expression '%s': %w", expression, err)
+	}
+	nodes, err := path.Find(&root)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]any, 0, len(nodes))
+	for _, node := range nodes {
+		var value any
+		if dErr := node.Decode(&value); dErr == nil {
+			values = append(values, value)
+		}
+	}
+	return values, nil
+}
+
+// JSONPathMatches checks if any value located by a JSONPath expression matches the expected string or regex.
+// An empty expected value only checks that the path exists.
+func JSONPathMatches(document []byte, expression, expected string) bool {
+	values, err := QueryJSONPath(document, expression)
+	if err != nil || len(values) == 0 {
+		return false
+	}
+	if expected == "" {
+		return true
+	}
+	for _, value := range values {
+		if StringCompare(expected, fmt.Sprint(value)) {
+			return true
+		}
+	}
+	return false
+}
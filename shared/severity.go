@@ -0,0 +1,102 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package shared
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gobwas/glob"
+	"github.com/pb33f/libopenapi-validator/errors"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+	SeverityOff     = "off"
+)
+
+// Severities are the severities a validation rule can set, from the most to the least severe.
+var Severities = []string{SeverityError, SeverityWarning, SeverityInfo, SeverityOff}
+
+// IsSeverity checks if a severity is one of the severities, in any case.
+func IsSeverity(severity string) bool {
+	return severityRank(severity) >= 0
+}
+
+// SeverityAtLeast checks if a severity is as severe as a threshold, nothing is as severe as off.
+func SeverityAtLeast(severity, threshold string) bool {
+	rank := severityRank(severity)
+	return rank >= 0 && rank <= severityRank(threshold) && rank < severityRank(SeverityOff)
+}
+
+// MostSevere returns the most severe of two severities.
+func MostSevere(a, b string) string {
+	if severityRank(b) < severityRank(a) {
+		return b
+	}
+	return a
+}
+
+func severityRank(severity string) int {
+	for i, s := range Severities {
+		if strings.EqualFold(s, severity) {
+			return i
+		}
+	}
+	return -1
+}
+
+// WiretapValidationRule sets the severity of the violations it matches. Violations are identified by their
+// validation type and sub-type, such as `parameter/header` or `response/statusCode`, and schema violations also by
+// the keyword that failed, such as `response/schema/additionalProperties` or `requestBody/schema/format`. The
+// violation is a glob, `*` matches a single segment and `**` any number of segments. Rules can be limited to a path
+// glob and a method. Violations no rule matches are errors.
+type WiretapValidationRule struct {
+	Violation string `json:"violation,omitempty" yaml:"violation,omitempty"`
+	Severity  string `json:"severity,omitempty" yaml:"severity,omitempty"`
+	Path      string `json:"path,omitempty" yaml:"path,omitempty"`
+	Method    string `json:"method,omitempty" yaml:"method,omitempty"`
+}
+
+type CompiledValidationRule struct {
+	Rule              *WiretapValidationRule
+	CompiledViolation glob.Glob
+	CompiledPath      glob.Glob
+}
+
+// Notice is a violation that was given a severity lower than error by a validation rule. It is reported, but does
+// not fail validation.
+type Notice struct {
+	Severity string `json:"severity"`
+	*errors.ValidationError
+}
+
+// CompileValidationRules compiles the validation rules, and checks their severities.
+func (wtc *WiretapConfiguration) CompileValidationRules() error {
+	wtc.CompiledValidationRules = make([]*CompiledValidationRule, 0)
+	for _, x := range wtc.ValidationRules {
+		if !IsSeverity(x.Severity) {
+			return fmt.Errorf("unknown severity '%s' for validation rule '%s', use one of: %s", x.Severity,
+				x.Violation, strings.Join(Severities, ", "))
+		}
+		violation := x.Violation
+		if violation == "" {
+			violation = "**"
+		}
+		compiledViolation, err := glob.Compile(violation, '/')
+		if err != nil {
+			return fmt.Errorf("invalid validation rule '%s': %w", x.Violation, err)
+		}
+		compiled := &CompiledValidationRule{Rule: x, CompiledViolation: compiledViolation}
+		if x.Path != "" {
+			compiled.CompiledPath = glob.MustCompile(wtc.ReplaceWithVariables(x.Path))
+		}
+		wtc.CompiledValidationRules = append(wtc.CompiledValidationRules, compiled)
+	}
+	return nil
+}
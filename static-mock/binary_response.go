@@ -0,0 +1,203 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package staticMock
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var (
+	errNoRange            = errors.New("no usable range")
+	errRangeUnsatisfiable = errors.New("range not satisfiable")
+)
+
+// isBinaryResponse checks if a response is served as-is from a file or base64 body, rather than from a JSON
+// template.
+func isBinaryResponse(response StaticMockDefinitionResponse) bool {
+	return response.BodyFile != "" || response.BodyBase64 != ""
+}
+
+// checkBase64Bodies makes sure every base64 body of a definition can be decoded.
+func checkBase64Bodies(definition StaticMockDefinition) error {
+	for _, response := range append([]StaticMockDefinitionResponse{definition.Response}, definition.Responses...) {
+		if response.BodyBase64 == "" {
+			continue
+		}
+		if _, err := base64.StdEncoding.DecodeString(response.BodyBase64); err != nil {
+			return fmt.Errorf("invalid base64 body: %w", err)
+		}
+	}
+	return nil
+}
+
+// readBinaryBody reads the body of a binary response, and works out its content type. Files are resolved from the
+// static mock directory, and cannot be outside of it.
+func readBinaryBody(staticMockDir string, response StaticMockDefinitionResponse) ([]byte, string, error) {
+	var body []byte
+	contentType := response.ContentType
+	if response.BodyFile != "" {
+		root, _ := filepath.Abs(staticMockDir)
+		path := filepath.Join(root, filepath.FromSlash(response.BodyFile))
+		if rel, err := filepath.Rel(root, path); err != nil || strings.HasPrefix(rel, "..") {
+			return nil, "", fmt.Errorf("body file '%s' is outside of the static mock directory", response.BodyFile)
+		}
+		file, err := os.ReadFile(path)
+		if err != nil {
+			return nil, "", err
+		}
+		body = file
+		if contentType == "" {
+			contentType = mime.TypeByExtension(filepath.Ext(strings.TrimSuffix(path, ".gz")))
+		}
+	} else {
+		decoded, err := base64.StdEncoding.DecodeString(response.BodyBase64)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid base64 body: %w", err)
+		}
+		body = decoded
+	}
+	if contentType == "" {
+		sniff := body
+		if isGzipped(body) {
+			if plain, err := gunzip(body); err == nil {
+				sniff = plain
+			}
+		}
+		contentType = http.DetectContentType(sniff)
+	}
+	return body, contentType, nil
+}
+
+// getBinaryStaticMockResponse returns a response served from a file or base64 body. Bodies are gzip encoded when
+// the response asks for it and the client accepts it, and a single byte range is served if one is requested.
+func (sms *StaticMockService) getBinaryStaticMockResponse(matchedMockDefinition StaticMockDefinition,
+	request *http.Request) *http.Response {
+
+	definition := matchedMockDefinition.Response
+	body, contentType, err := readBinaryBody(sms.wiretapService.StaticMockDir, definition)
+	if err != nil {
+		panic(err)
+	}
+
+	header := buildStaticMockHeaders(definition, contentType)
+	renderHeaderTemplates(header, definition, request)
+	statusCode := definition.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+
+	if definition.Gzip {
+		// compress the body for clients that accept gzip, and send it plain to those that don't.
+		if strings.Contains(request.Header.Get("Accept-Encoding"), "gzip") {
+			if !isGzipped(body) {
+				body = gzipBytes(body)
+			}
+			header.Set("Content-Encoding", "gzip")
+			header.Add("Vary", "Accept-Encoding")
+		} else if isGzipped(body) {
+			if body, err = gunzip(body); err != nil {
+				panic(err)
+			}
+		}
+	} else {
+		header.Set("Accept-Ranges", "bytes")
+		if statusCode == http.StatusOK {
+			start, end, rangeErr := parseRange(request.Header.Get("Range"), len(body))
+			switch rangeErr {
+			case nil:
+				header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(body)))
+				body = body[start : end+1]
+				statusCode = http.StatusPartialContent
+			case errRangeUnsatisfiable:
+				header.Set("Content-Range", fmt.Sprintf("bytes */%d", len(body)))
+				body = nil
+				statusCode = http.StatusRequestedRangeNotSatisfiable
+			}
+		}
+	}
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     header,
+		Body:       io.NopCloser(bytes.NewReader(body)),
+	}
+}
+
+// parseRange reads a single byte range from a Range header, returning the first and last byte to serve. Multiple
+// ranges are not supported, the full body is served instead.
+func parseRange(rangeHeader string, size int) (int, int, error) {
+	spec, found := strings.CutPrefix(rangeHeader, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, 0, errNoRange
+	}
+	first, last, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return 0, 0, errNoRange
+	}
+	if first == "" {
+		// a suffix range, the last n bytes.
+		n, err := strconv.Atoi(last)
+		if err != nil || n < 0 {
+			return 0, 0, errNoRange
+		}
+		if n == 0 || size == 0 {
+			return 0, 0, errRangeUnsatisfiable
+		}
+		if n > size {
+			n = size
+		}
+		return size - n, size - 1, nil
+	}
+	start, err := strconv.Atoi(first)
+	if err != nil || start < 0 {
+		return 0, 0, errNoRange
+	}
+	if start >= size {
+		return 0, 0, errRangeUnsatisfiable
+	}
+	end := size - 1
+	if last != "" {
+		if end, err = strconv.Atoi(last); err != nil || end < start {
+			return 0, 0, errNoRange
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+	return start, end, nil
+}
+
+func isGzipped(body []byte) bool {
+	return len(body) > 2 && body[0] == 0x1f && body[1] == 0x8b
+}
+
+func gzipBytes(body []byte) []byte {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	_, _ = writer.Write(body)
+	_ = writer.Close()
+	return buf.Bytes()
+}
+
+func gunzip(body []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(reader)
+}
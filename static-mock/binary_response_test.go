@@ -0,0 +1,121 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package staticMock
+
+import (
+	"encoding/base64"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pb33f/wiretap/daemon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		header     string
+		start, end int
+		err        error
+	}{
+		{"bytes=0-4", 0, 4, nil},
+		{"bytes=5-", 5, 9, nil},
+		{"bytes=-3", 7, 9, nil},
+		{"bytes=-30", 0, 9, nil},
+		{"bytes=8-100", 8, 9, nil},
+		{"bytes=10-", 0, 0, errRangeUnsatisfiable},
+		{"bytes=0-1,3-4", 0, 0, errNoRange},
+		{"bytes=4-2", 0, 0, errNoRange},
+		{"items=0-1", 0, 0, errNoRange},
+		{"", 0, 0, errNoRange},
+	}
+	for _, tt := range tests {
+		start, end, err := parseRange(tt.header, 10)
+		assert.Equal(t, tt.err, err, tt.header)
+		if tt.err == nil {
+			assert.Equal(t, []int{tt.start, tt.end}, []int{start, end}, tt.header)
+		}
+	}
+}
+
+func TestReadBinaryBody(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "files"), 0755))
+	pdf := []byte("%PDF-1.4 test")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "files", "doc.pdf"), pdf, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "files", "data"), gzipBytes([]byte("<html></html>")), 0644))
+
+	body, contentType, err := readBinaryBody(dir, StaticMockDefinitionResponse{BodyFile: "files/doc.pdf"})
+	require.NoError(t, err)
+	assert.Equal(t, pdf, body)
+	assert.Equal(t, "application/pdf", contentType)
+
+	_, contentType, err = readBinaryBody(dir, StaticMockDefinitionResponse{BodyFile: "files/data"})
+	require.NoError(t, err)
+	assert.Equal(t, "text/html; charset=utf-8", contentType)
+
+	_, contentType, err = readBinaryBody(dir, StaticMockDefinitionResponse{BodyFile: "files/doc.pdf",
+		ContentType: "application/x-protobuf"})
+	require.NoError(t, err)
+	assert.Equal(t, "application/x-protobuf", contentType)
+
+	body, contentType, err = readBinaryBody(dir, StaticMockDefinitionResponse{
+		BodyBase64: base64.StdEncoding.EncodeToString([]byte("\x89PNG\r\n\x1a\n0000"))})
+	require.NoError(t, err)
+	assert.Len(t, body, 12)
+	assert.Equal(t, "image/png", contentType)
+
+	_, _, err = readBinaryBody(dir, StaticMockDefinitionResponse{BodyFile: "../secret.txt"})
+	assert.ErrorContains(t, err, "outside of the static mock directory")
+}
+
+func TestStaticMockService_BinaryResponse(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "logo.svg"), []byte("<svg>${ignored}</svg>"), 0644))
+	sms := &StaticMockService{logger: slog.Default(), state: newMockState(),
+		wiretapService: &daemon.WiretapService{StaticMockDir: dir}}
+
+	send := func(response StaticMockDefinitionResponse, header http.Header) (*http.Response, []byte) {
+		req, _ := http.NewRequest(http.MethodGet, "http://localhost/logo", nil)
+		req.Header = header
+		resp := sms.getStaticMockResponse(StaticMockDefinition{Response: response}, req)
+		body, _ := io.ReadAll(resp.Body)
+		return resp, body
+	}
+
+	resp, body := send(StaticMockDefinitionResponse{BodyFile: "logo.svg"}, http.Header{})
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "image/svg+xml", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "bytes", resp.Header.Get("Accept-Ranges"))
+	assert.Equal(t, "<svg>${ignored}</svg>", string(body))
+
+	resp, body = send(StaticMockDefinitionResponse{BodyFile: "logo.svg"}, http.Header{"Range": {"bytes=0-4"}})
+	assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	assert.Equal(t, "bytes 0-4/21", resp.Header.Get("Content-Range"))
+	assert.Equal(t, "<svg>", string(body))
+
+	resp, _ = send(StaticMockDefinitionResponse{BodyFile: "logo.svg"}, http.Header{"Range": {"bytes=50-"}})
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, resp.StatusCode)
+	assert.Equal(t, "bytes */21", resp.Header.Get("Content-Range"))
+
+	resp, body = send(StaticMockDefinitionResponse{BodyFile: "logo.svg", Gzip: true},
+		http.Header{"Accept-Encoding": {"gzip, deflate"}})
+	assert.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+	plain, err := gunzip(body)
+	require.NoError(t, err)
+	assert.Equal(t, "<svg>${ignored}</svg>", string(plain))
+
+	gzipped := base64.StdEncoding.EncodeToString(gzipBytes([]byte(`{"ok": true}`)))
+	resp, body = send(StaticMockDefinitionResponse{BodyBase64: gzipped, Gzip: true,
+		ContentType: "application/json"}, http.Header{})
+	assert.Empty(t, resp.Header.Get("Content-Encoding"))
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, `{"ok": true}`, string(body))
+}
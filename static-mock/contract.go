@@ -0,0 +1,223 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package staticMock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pb33f/libopenapi-validator/errors"
+	"github.com/pb33f/libopenapi-validator/helpers"
+	"github.com/pb33f/libopenapi-validator/paths"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/wiretap/validation"
+)
+
+// MockContractViolation describes how a static mock definition does not comply with the OpenAPI contract.
+type MockContractViolation struct {
+	Id     string
+	Source string
+	// Response is the position of the response in `responses`, or -1 for the request or a single `response`.
+	Response int
+	Message  string
+	Reason   string
+}
+
+func (v *MockContractViolation) Error() string {
+	if v.Response >= 0 {
+		return fmt.Sprintf("%s (%s) response %d: %s", v.Id, v.Source, v.Response, v.Message)
+	}
+	return fmt.Sprintf("%s (%s): %s", v.Id, v.Source, v.Message)
+}
+
+func (v *MockContractViolation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"id":       v.Id,
+		"source":   v.Source,
+		"response": v.Response,
+		"message":  v.Message,
+		"reason":   v.Reason,
+	})
+}
+
+// requestValuePattern finds request values in a response body, these are only known when a request is made.
+var requestValuePattern = regexp.MustCompile(`\$\{[^}]+}`)
+
+// checkContractCompliance validates every definition against the contract. The request must match an operation,
+// and each response must use a status code, content type, required headers and body the operation describes.
+// Definitions that match the path with a pattern cannot be located in the contract, and are not checked.
+func checkContractCompliance(docModel *v3.Document, staticMockDir string,
+	definitions []StaticMockDefinition) []*MockContractViolation {
+
+	if docModel == nil {
+		return nil
+	}
+	validator := validation.NewHttpValidator(docModel)
+
+	var violations []*MockContractViolation
+	for _, definition := range definitions {
+		violation := func(response int, message, reason string) {
+			violations = append(violations, &MockContractViolation{
+				Id:       definition.Id,
+				Source:   definition.Source,
+				Response: response,
+				Message:  message,
+				Reason:   reason,
+			})
+		}
+
+		urlPath := definition.Request.UrlPath
+		if urlPath == "" || regexp.QuoteMeta(urlPath) != urlPath {
+			continue
+		}
+		host := definition.Request.Host
+		if host == "" || regexp.QuoteMeta(host) != host {
+			host = "localhost"
+		}
+		request, err := http.NewRequest(strings.ToUpper(definition.Request.Method),
+			fmt.Sprintf("http://%s%s", host, urlPath), nil)
+		if err != nil {
+			violation(-1, fmt.Sprintf("request cannot be checked: %s", err.Error()), "")
+			continue
+		}
+
+		pathItem, pathErrs, _ := paths.FindPath(request, docModel)
+		if len(pathErrs) > 0 {
+			for _, e := range pathErrs {
+				violation(-1, e.Message, e.Reason)
+			}
+			continue
+		}
+		operation := helpers.ExtractOperation(request, pathItem)
+		if operation == nil {
+			violation(-1, fmt.Sprintf("%s operation for '%s' is not defined in the contract", request.Method,
+				urlPath), "")
+			continue
+		}
+
+		// proxied responses come from the API, they are validated when they are returned.
+		if definition.Proxy != nil {
+			continue
+		}
+
+		responses := definition.Responses
+		position := 0
+		if len(responses) == 0 {
+			responses = []StaticMockDefinitionResponse{definition.Response}
+			position = -1
+		}
+		for i, mockResponse := range responses {
+			if position >= 0 {
+				position = i
+			}
+			for _, e := range checkResponseCompliance(validator, request, operation, staticMockDir, mockResponse) {
+				violation(position, e.Message, e.Reason)
+			}
+		}
+	}
+	return violations
+}
+
+// checkResponseCompliance validates a single response definition against the operation it mocks.
+func checkResponseCompliance(validator validation.HttpValidator, request *http.Request, operation *v3.Operation,
+	staticMockDir string, mockResponse StaticMockDefinitionResponse) []*errors.ValidationError {
+
+	body := []byte(mockResponse.Body)
+	contentType := "application/json"
+	templated := false
+	switch {
+	case isBinaryResponse(mockResponse):
+		file, binaryContentType, err := readBinaryBody(staticMockDir, mockResponse)
+		if err != nil {
+			return []*errors.ValidationError{{
+				Message: "unable to read response body",
+				Reason:  err.Error(),
+			}}
+		}
+		if isGzipped(file) {
+			if file, err = gunzip(file); err != nil {
+				return []*errors.ValidationError{{Message: "unable to decompress response body", Reason: err.Error()}}
+			}
+		}
+		body, contentType = file, binaryContentType
+	case mockResponse.BodyJsonFilename != "":
+		file, err := os.ReadFile(staticMockDir + MockBodyJsonsPath + mockResponse.BodyJsonFilename)
+		if err != nil {
+			return []*errors.ValidationError{{
+				Message: fmt.Sprintf("unable to read body file '%s'", mockResponse.BodyJsonFilename),
+				Reason:  err.Error(),
+			}}
+		}
+		body = file
+		templated = mockResponse.Template || requestValuePattern.Match(body)
+	default:
+		templated = mockResponse.Template || requestValuePattern.Match(body)
+	}
+
+	statusCode := mockResponse.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	response := &http.Response{
+		StatusCode: statusCode,
+		Header:     buildStaticMockHeaders(mockResponse, contentType),
+		Body:       io.NopCloser(bytes.NewReader(body)),
+	}
+
+	var violations []*errors.ValidationError
+	_, errs := validator.ValidateHttpResponse(request, response)
+	for _, e := range errs {
+		// a body built from request values cannot be checked against the schema until a request is made.
+		if templated && e.ValidationSubType == helpers.Schema {
+			continue
+		}
+		violations = append(violations, e)
+	}
+
+	// check required response headers are sent.
+	code := strconv.Itoa(statusCode)
+	definedResponse := operation.Responses.Codes.GetOrZero(code)
+	if definedResponse == nil {
+		definedResponse = operation.Responses.Codes.GetOrZero(code[:1] + "XX")
+	}
+	if definedResponse == nil {
+		definedResponse = operation.Responses.Default
+	}
+	if definedResponse != nil && definedResponse.Headers != nil {
+		for pairs := definedResponse.Headers.First(); pairs != nil; pairs = pairs.Next() {
+			if pairs.Value().Required && response.Header.Get(pairs.Key()) == "" {
+				violations = append(violations, &errors.ValidationError{
+					Message: fmt.Sprintf("required response header '%s' is missing", pairs.Key()),
+					Reason: fmt.Sprintf("The %d response defines the header '%s' as required, but the mock "+
+						"does not send it", statusCode, pairs.Key()),
+				})
+			}
+		}
+	}
+	return violations
+}
+
+// refuseNonCompliant removes every definition that has a contract violation.
+func refuseNonCompliant(definitions []StaticMockDefinition, violations []*MockContractViolation) []StaticMockDefinition {
+	refused := make(map[string]bool)
+	for _, v := range violations {
+		refused[v.Id] = true
+	}
+	compliant := definitions[:0]
+	for _, definition := range definitions {
+		if !refused[definition.Id] {
+			compliant = append(compliant, definition)
+		}
+	}
+	return compliant
+}
@@ -0,0 +1,103 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package staticMock
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var contractSpec = `openapi: 3.1.0
+info:
+  title: Test
+  version: 0.1.0
+paths:
+  /pets/{id}:
+    get:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        '200':
+          headers:
+            X-Rate-Limit:
+              required: true
+              schema:
+                type: integer
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [name]
+                properties:
+                  name:
+                    type: string
+        '404':
+          description: not found`
+
+func TestCheckContractCompliance(t *testing.T) {
+	d, err := libopenapi.NewDocument([]byte(contractSpec))
+	require.NoError(t, err)
+	m, _ := d.BuildV3Model()
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "body-jsons"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "body-jsons", "pet.json"), []byte(`{"name": 1}`), 0644))
+
+	rateLimit := map[string]any{"X-Rate-Limit": "100"}
+	definitions := []StaticMockDefinition{
+		{Id: "ok", Request: StaticMockDefinitionRequest{Method: "GET", UrlPath: "/pets/1"},
+			Response: StaticMockDefinitionResponse{StatusCode: 200, Header: rateLimit, Body: `{"name": "fido"}`}},
+		{Id: "templated", Request: StaticMockDefinitionRequest{Method: "GET", UrlPath: "/pets/2"},
+			Response: StaticMockDefinitionResponse{Header: rateLimit, Body: `{"id": "${urlPath}"}`}},
+		{Id: "pattern", Request: StaticMockDefinitionRequest{Method: "GET", UrlPath: "/cats/.*"}},
+		{Id: "no-path", Request: StaticMockDefinitionRequest{Method: "GET", UrlPath: "/cats"}},
+		{Id: "no-method", Request: StaticMockDefinitionRequest{Method: "DELETE", UrlPath: "/pets/1"}},
+		{Id: "responses", Request: StaticMockDefinitionRequest{Method: "GET", UrlPath: "/pets/3"},
+			Responses: []StaticMockDefinitionResponse{
+				{StatusCode: 404},
+				{StatusCode: 418},
+				{StatusCode: 200, BodyJsonFilename: "pet.json"},
+				{StatusCode: 200, BodyJsonFilename: "missing.json"},
+			}},
+	}
+
+	violations := checkContractCompliance(&m.Model, dir, definitions)
+
+	byId := make(map[string][]*MockContractViolation)
+	for _, v := range violations {
+		byId[v.Id] = append(byId[v.Id], v)
+	}
+	assert.Empty(t, byId["ok"])
+	assert.Empty(t, byId["templated"])
+	assert.Empty(t, byId["pattern"])
+	assert.Len(t, byId["no-path"], 1)
+	assert.Len(t, byId["no-method"], 1)
+
+	positions := make(map[int]int)
+	for _, v := range byId["responses"] {
+		positions[v.Response]++
+	}
+	assert.Zero(t, positions[0])
+	assert.Equal(t, 1, positions[1])
+	assert.Equal(t, 2, positions[2]) // schema and the missing required header
+	assert.Equal(t, 1, positions[3])
+
+	compliant := refuseNonCompliant(definitions, violations)
+	var ids []string
+	for _, c := range compliant {
+		ids = append(ids, c.Id)
+	}
+	assert.Equal(t, []string{"ok", "templated", "pattern"}, ids)
+}
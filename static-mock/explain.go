@@ -0,0 +1,106 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package staticMock
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pb33f/ranch/model"
+	"github.com/pb33f/ranch/service"
+)
+
+const ExplainStaticMockRequest = "explain-static-mock"
+
+// ExplainRequest describes a request to check against the loaded mock definitions.
+type ExplainRequest struct {
+	Method  string            `json:"method,omitempty"`
+	Url     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// StaticMockMatchExplanation describes why a mock definition did, or did not, match a request.
+type StaticMockMatchExplanation struct {
+	Id          string   `json:"id"`
+	Source      string   `json:"source,omitempty"`
+	Priority    int      `json:"priority"`
+	Specificity int      `json:"specificity"`
+	Matched     bool     `json:"matched"`
+	Selected    bool     `json:"selected"`
+	Reasons     []string `json:"reasons,omitempty"`
+}
+
+// ExplainMatch checks every mock definition against the request, in the order they are evaluated, and explains
+// why each one matched or not. The first definition that matches is the one that would be selected.
+func (sms *StaticMockService) ExplainMatch(incoming *http.Request) []*StaticMockMatchExplanation {
+	definitions := sms.definitions()
+	explanations := make([]*StaticMockMatchExplanation, 0, len(definitions))
+	selected := false
+	for _, mock := range definitions {
+		reasons := sms.explainDefinition(mock, incoming)
+		sms.state.lock.Lock()
+		if !sms.state.inRequiredState(mock) {
+			reasons = append(reasons, fmt.Sprintf("scenario '%s' is in state '%s', requires '%s'",
+				mock.Scenario, sms.state.scenarioState(mock.Scenario), mock.RequiredState))
+		}
+		sms.state.lock.Unlock()
+		explanation := &StaticMockMatchExplanation{
+			Id:          mock.Id,
+			Source:      mock.Source,
+			Priority:    mock.Priority,
+			Specificity: mock.Specificity,
+			Matched:     len(reasons) == 0,
+			Reasons:     reasons,
+		}
+		if explanation.Matched && !selected {
+			explanation.Selected = true
+			selected = true
+		}
+		explanations = append(explanations, explanation)
+	}
+	return explanations
+}
+
+// explainDefinition collects every reason a definition does not match, recovering from a body that cannot be read.
+func (sms *StaticMockService) explainDefinition(mock StaticMockDefinition, incoming *http.Request) (reasons []string) {
+	defer func() {
+		if r := recover(); r != nil {
+			reasons = append(reasons, fmt.Sprintf("unable to evaluate definition: %v", r))
+		}
+	}()
+	return sms.evaluateRequestMatch(mock.Request, incoming, false)
+}
+
+func (sms *StaticMockService) explainStaticMock(request *model.Request, core service.FabricServiceCore) {
+	if dl, ok := request.Payload.(map[string]interface{}); ok {
+
+		// decode the object into a request
+		var r ExplainRequest
+		_ = mapstructure.Decode(dl, &r)
+
+		if r.Method == "" {
+			r.Method = http.MethodGet
+		}
+		incoming, err := http.NewRequest(strings.ToUpper(r.Method), r.Url, strings.NewReader(r.Body))
+		if err != nil {
+			core.SendErrorResponse(request, 400, fmt.Sprintf("Invalid request to explain: %s", err.Error()))
+			return
+		}
+		for k, v := range r.Headers {
+			incoming.Header.Set(k, v)
+		}
+		if incoming.Host == "" {
+			incoming.Host = incoming.Header.Get("Host")
+		}
+		core.SendResponse(request, sms.ExplainMatch(incoming))
+
+	} else {
+		core.SendErrorResponse(request, 400, "Invalid request to explain")
+	}
+}
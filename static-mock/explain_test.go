@@ -0,0 +1,74 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package staticMock
+
+import (
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMockDefinitionFile_PriorityAndSpecificity(t *testing.T) {
+	path := writeDefinition(t, "pets.yaml", `- request:
+    method: GET
+    urlPath: /pets.*
+  response:
+    statusCode: 200
+- id: pet-one
+  request:
+    method: GET
+    urlPath: /pets/1
+    header:
+      X-Tenant: acme
+  response:
+    statusCode: 200
+- id: fallback
+  priority: 10
+  request:
+    method: GET
+  response:
+    statusCode: 503`)
+
+	definitions, errs := loadMockDefinitionFile(path)
+	require.Empty(t, errs)
+	require.Len(t, definitions, 3)
+	assert.Equal(t, "pets.yaml#0", definitions[0].Id)
+	assert.Equal(t, 3, definitions[0].Specificity)
+	assert.Equal(t, 7, definitions[1].Specificity)
+
+	sortStaticMockDefinitions(definitions)
+	assert.Equal(t, "fallback", definitions[0].Id)
+	assert.Equal(t, "pet-one", definitions[1].Id)
+	assert.Equal(t, "pets.yaml#0", definitions[2].Id)
+}
+
+func TestStaticMockService_ExplainMatch(t *testing.T) {
+	header := map[string]any{"X-Tenant": "acme"}
+	sms := &StaticMockService{logger: slog.Default(), state: newMockState(), mockDefinitions: []StaticMockDefinition{
+		{Id: "post", Request: StaticMockDefinitionRequest{Method: "POST", UrlPath: "/pets"}},
+		{Id: "tenant", Request: StaticMockDefinitionRequest{Method: "GET", UrlPath: "/pets", Header: &header}},
+		{Id: "any", Request: StaticMockDefinitionRequest{Method: "GET", UrlPath: "/pets"}},
+		{Id: "also", Request: StaticMockDefinitionRequest{Method: "GET"}},
+	}}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/pets", nil)
+	explanations := sms.ExplainMatch(req)
+	require.Len(t, explanations, 4)
+
+	assert.False(t, explanations[0].Matched)
+	assert.Equal(t, []string{"method 'GET' does not match 'POST'"}, explanations[0].Reasons)
+	assert.False(t, explanations[1].Matched)
+	assert.Equal(t, []string{"header 'X-Tenant' does not match"}, explanations[1].Reasons)
+	assert.True(t, explanations[2].Matched)
+	assert.True(t, explanations[2].Selected)
+	assert.True(t, explanations[3].Matched)
+	assert.False(t, explanations[3].Selected)
+
+	assert.Equal(t, "any", sms.checkStaticMockExists(req).Id)
+}
@@ -27,6 +27,15 @@ func (sms *StaticMockService) getBodyFromMockDefinition(matchedMockDefinition St
 		bodyStr = string(file)
 	}
 
+	// templates are rendered on their own, `${}` request values are only replaced in bodies that are not templates.
+	if matchedMockDefinition.Response.Template {
+		rendered, err := renderTemplate(bodyStr, request)
+		if err != nil {
+			panic(err)
+		}
+		return rendered
+	}
+
 	requestObjectWithIncomingRequestValues := StaticMockDefinitionRequest{
 		Method:  request.Method,
 		UrlPath: request.URL.Path,
@@ -71,20 +80,31 @@ func (sms *StaticMockService) getBodyFromMockDefinition(matchedMockDefinition St
 
 // getHeadersFromMockDefinition returns headers from the matched static mock
 func (sms *StaticMockService) getHeadersFromMockDefinition(matchedMockDefinition StaticMockDefinition) http.Header {
+	return buildStaticMockHeaders(matchedMockDefinition.Response, "application/json")
+}
+
+// buildStaticMockHeaders returns the CORS and content-type headers every static mock sends, along with the headers
+// of the response definition. The content type of the definition, or its headers, replace the one given.
+func buildStaticMockHeaders(response StaticMockDefinitionResponse, contentType string) http.Header {
 	header := http.Header{}
 	// wiretap needs to work from anywhere, so allow everything.
 	headers := make(map[string][]string)
 	shared.SetCORSHeaders(headers)
-	headers["Content-Type"] = []string{"application/json"}
+	if response.ContentType != "" {
+		contentType = response.ContentType
+	}
+	headers["Content-Type"] = []string{contentType}
 
 	// Add cors and content-type headers
 	for k, v := range headers {
-		header.Add(k, fmt.Sprint(v))
+		for _, value := range v {
+			header.Add(k, value)
+		}
 	}
 
 	// Add headers from mock definition JSON
-	for k, v := range matchedMockDefinition.Response.Header {
-		header.Add(k, fmt.Sprint(v))
+	for k, v := range response.Header {
+		header.Set(k, fmt.Sprint(v))
 	}
 
 	return header
@@ -92,6 +112,10 @@ func (sms *StaticMockService) getHeadersFromMockDefinition(matchedMockDefinition
 
 // getStaticMockResponse returns response from the matched static mock
 func (sms *StaticMockService) getStaticMockResponse(matchedMockDefinition StaticMockDefinition, request *http.Request) *http.Response {
+	if isBinaryResponse(matchedMockDefinition.Response) {
+		return sms.getBinaryStaticMockResponse(matchedMockDefinition, request)
+	}
+
 	body := sms.getBodyFromMockDefinition(matchedMockDefinition, request)
 
 	buff := bytes.NewBuffer([]byte(body))
@@ -101,6 +125,7 @@ func (sms *StaticMockService) getStaticMockResponse(matchedMockDefinition Static
 		Body:       io.NopCloser(buff),
 	}
 	response.Header = sms.getHeadersFromMockDefinition(matchedMockDefinition)
+	renderHeaderTemplates(response.Header, matchedMockDefinition.Response, request)
 
 	return response
 }
@@ -7,44 +7,24 @@ package staticMock
 
 import (
 	"bytes"
-	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
-	"net/url"
 
 	"github.com/pb33f/ranch/model"
 	"github.com/pb33f/wiretap/shared"
 )
 
-// getBodyFromHttpRequest reads the body of the incoming request and returns it as an interface{}
+// getBodyFromHttpRequest reads the body of the incoming request, decoded by its content type.
 func (sms *StaticMockService) getBodyFromHttpRequest(request *http.Request) interface{} {
-	bodyBytes, err := io.ReadAll(request.Body)
-	if err != nil {
-		panic(err)
-	}
-
-	// Restore request.Body so it can be read again
-	request.Body = io.NopCloser(bytes.NewReader(bodyBytes))
-
-	var bodyJsonObj interface{}
-
-	if len(bodyBytes) == 0 {
-		return bodyJsonObj
-	}
-
-	err = json.Unmarshal(bodyBytes, &bodyJsonObj)
-	if err != nil {
-		sms.logger.Error("Error decoding JSON of incoming request. JSON => \n%s", string(bodyBytes), err)
-		panic(err)
-	}
-
-	return bodyJsonObj
+	return decodeRequestBody(request)
 }
 
-// compareJsonBody compares the JSON body of the incoming request with the mock definition
+// compareJsonBody compares a JSON or form body of the incoming request with the mock definition
 func (sms *StaticMockService) compareJsonBody(mock StaticMockDefinitionRequest, request *http.Request) bool {
-	// Mock body is JSON but incoming body is not JSON
-	if request.Header.Get("Content-Type") != "application/json" {
+	// Mock body is an object or array, but incoming body is not JSON or a form
+	mediaType, _ := requestMediaType(request)
+	if !isJSONMediaType(mediaType) && !isFormMediaType(mediaType) {
 		return false
 	}
 
@@ -54,57 +34,23 @@ func (sms *StaticMockService) compareJsonBody(mock StaticMockDefinitionRequest,
 	return shared.IsSubset(mock.Body, incomingBody)
 }
 
-// transStrArrToInterfaceArr transforms a string array to an interface array (helper method)
-func (sms *StaticMockService) transStrArrToInterfaceArr(strArr []string) []interface{} {
-	strArrTransformedValues := make([]interface{}, 0)
-	for _, value := range strArr {
-		strArrTransformedValues = append(strArrTransformedValues, interface{}(value))
-	}
-	return strArrTransformedValues
-}
-
-// compareHeaders compares the headers of the incoming request with the mock definition
-func (sms *StaticMockService) compareHeaders(mockHeaders map[string]any, incoming *http.Request) bool {
-	found := true
-	// Check if all headers in mockHeaders are subset of incoming headers
-	for key, value := range mockHeaders {
-		switch v := value.(type) {
-		case string:
-			found = found && shared.IsSubset([]interface{}{v}, sms.transStrArrToInterfaceArr(incoming.Header[key]))
-		case []interface{}:
-			found = found && shared.IsSubset(value, sms.transStrArrToInterfaceArr(incoming.Header[key]))
-		}
-	}
-
-	return found
-}
-
-// compareQueryParams compares the query parameters of the incoming request with the mock definition
-func (sms *StaticMockService) compareQueryParams(mockQueryParams map[string]any, incomingQueries url.Values) bool {
-	found := true
-	// Check if all headers in mockHeaders are subset of incoming headers
-	for key, value := range mockQueryParams {
-		switch v := value.(type) {
-		case string:
-			found = found && shared.IsSubset([]interface{}{v}, sms.transStrArrToInterfaceArr(incomingQueries[key]))
-		case []interface{}:
-			found = found && shared.IsSubset(value, sms.transStrArrToInterfaceArr(incomingQueries[key]))
-		}
+// readRequestBody reads the body of the incoming request, restoring it so it can be read again.
+func readRequestBody(request *http.Request) []byte {
+	if request.Body == nil || request.Body == http.NoBody {
+		return nil
 	}
-
-	return found
+	bodyBytes, _ := io.ReadAll(request.Body)
+	_ = request.Body.Close()
+	request.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+	return bodyBytes
 }
 
 // compareBody compares the body of the incoming request with the mock definition
 func (sms *StaticMockService) compareBody(mock StaticMockDefinitionRequest, incoming *http.Request) bool {
 	switch mb := mock.Body.(type) {
-	case string: // Case string body
-		incomingBodyBytes, err := io.ReadAll(incoming.Body)
-		if err != nil {
-			panic(err)
-		}
-
-		if string(incomingBodyBytes) != string(mb) {
+	case string: // Case string body, exact or a pattern
+		body := string(readRequestBody(incoming))
+		if body != mb && !shared.StringCompare(mb, body) {
 			return false
 		}
 	case map[string]interface{}: // Case JSON Object
@@ -125,59 +71,120 @@ func (sms *StaticMockService) compareBody(mock StaticMockDefinitionRequest, inco
 
 // isRequestMatch checks if the incoming request matches a mock definition
 func (sms *StaticMockService) isRequestMatch(mock StaticMockDefinitionRequest, incoming *http.Request) bool {
+	return len(sms.evaluateRequestMatch(mock, incoming, true)) == 0
+}
+
+// evaluateRequestMatch compares the incoming request with a mock definition and returns the reason for every check
+// that failed. When stopAtFirst is set, only the first failure is returned.
+func (sms *StaticMockService) evaluateRequestMatch(mock StaticMockDefinitionRequest, incoming *http.Request,
+	stopAtFirst bool) []string {
+
+	var reasons []string
+	fail := func(reason string, args ...any) bool {
+		reasons = append(reasons, fmt.Sprintf(reason, args...))
+		return stopAtFirst
+	}
+
 	// Compare Host if defined
 	if mock.Host != "" && !shared.StringCompare(mock.Host, incoming.Host) {
-		return false
+		if fail("host '%s' does not match '%s'", incoming.Host, mock.Host) {
+			return reasons
+		}
 	}
 
-	// Compare HTTP method
-	if incoming.Method != mock.Method {
-		return false
+	// Compare HTTP method, definitions always have one, verification requests may not
+	if mock.Method != "" && incoming.Method != mock.Method {
+		if fail("method '%s' does not match '%s'", incoming.Method, mock.Method) {
+			return reasons
+		}
 	}
 
 	// Compare url of the request
 	if mock.UrlPath != "" && !shared.StringCompare(mock.UrlPath, incoming.URL.Path) {
-		return false
+		if fail("path '%s' does not match '%s'", incoming.URL.Path, mock.UrlPath) {
+			return reasons
+		}
 	}
 
 	// Compare headers
 	if mock.Header != nil {
-		if !sms.compareHeaders(*mock.Header, incoming) {
-			return false
+		for _, key := range mismatchedFields(*mock.Header, incoming.Header.Values) {
+			if fail("header '%s' does not match", key) {
+				return reasons
+			}
 		}
 	}
 
 	// Compare query parameters
 	if mock.QueryParams != nil {
-		if !sms.compareQueryParams(*mock.QueryParams, incoming.URL.Query()) {
-			return false
+		query := incoming.URL.Query()
+		for _, key := range mismatchedFields(*mock.QueryParams, func(key string) []string { return query[key] }) {
+			if fail("query parameter '%s' does not match", key) {
+				return reasons
+			}
+		}
+	}
+
+	// Compare cookies
+	if mock.Cookies != nil {
+		for _, key := range mismatchedFields(*mock.Cookies, cookieLookup(incoming)) {
+			if fail("cookie '%s' does not match", key) {
+				return reasons
+			}
+		}
+	}
+
+	// Compare body fields located by JSONPath
+	if len(mock.BodyMatchers) > 0 {
+		for _, path := range sms.mismatchedBodyMatchers(mock.BodyMatchers, incoming) {
+			if fail("body field '%s' does not match", path) {
+				return reasons
+			}
+		}
+	}
+
+	// Compare XML body values located by XPath
+	if len(mock.XPath) > 0 {
+		for _, expression := range mismatchedXPathMatchers(mock.XPath, incoming) {
+			if fail("xpath '%s' does not match", expression) {
+				return reasons
+			}
 		}
 	}
 
 	// Compare body content
 	if mock.Body != nil {
 		if !sms.compareBody(mock, incoming) {
-			return false
+			if fail("body does not match") {
+				return reasons
+			}
 		}
 	}
 
-	// If all checks passed, the requests match
-	return true
+	return reasons
 }
 
-// checkStaticMockExists checks if a static mock definition exists for the incoming request.
+// checkStaticMockExists checks if a static mock definition exists for the incoming request. The definition
+// returned carries the response to use, picked from its sequence and scenario state. Requests are matched without
+// holding the lock, it is only held to check and move on the scenario of a definition that matched.
 func (sms *StaticMockService) checkStaticMockExists(request *http.Request) *StaticMockDefinition {
-	var matchedMockDefinition *StaticMockDefinition
-	// check for a static mock definition.
-	for _, mockDefinition := range sms.mockDefinitions {
-		if sms.isRequestMatch(mockDefinition.Request, request) {
-			// found a match
-			matchedMockDefinition = &mockDefinition
-			break
+	for _, mockDefinition := range sms.definitions() {
+		if !sms.isRequestMatch(mockDefinition.Request, request) {
+			continue
 		}
-	}
+		sms.state.lock.Lock()
+		inRequiredState := sms.state.inRequiredState(mockDefinition)
+		if inRequiredState {
+			mockDefinition.Response = sms.state.advance(mockDefinition)
+		}
+		sms.state.lock.Unlock()
 
-	return matchedMockDefinition
+		if inRequiredState {
+			sms.journal.record(mockDefinition.Id, request)
+			return &mockDefinition
+		}
+	}
+	return nil
 }
 
 // handleStaticMockRequest handles incoming requests and checks against static mock definitions.
@@ -207,6 +214,13 @@ func (sms *StaticMockService) handleStaticMockRequest(request *model.Request) {
 		return
 	}
 
+	// found a static mock that patches the response of the API, pass the request on with the patch.
+	if matchedMockDefinition.Proxy != nil {
+		sms.wiretapService.HandleHttpRequestWithModifier(request,
+			proxyPatchModifier(*matchedMockDefinition, request.HttpRequest))
+		return
+	}
+
 	// found a static mock, handle it.
 	response := sms.getStaticMockResponse(*matchedMockDefinition, request.HttpRequest)
 
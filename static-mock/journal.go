@@ -0,0 +1,262 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package staticMock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pb33f/ranch/model"
+	"github.com/pb33f/ranch/service"
+)
+
+const (
+	VerifyStaticMockRequest = "verify-static-mock"
+	GetStaticMockJournal    = "get-static-mock-journal"
+	ResetStaticMockJournal  = "reset-static-mock-journal"
+
+	// maxJournalEntries is the most requests kept in the journal, the oldest are dropped first.
+	maxJournalEntries = 10000
+)
+
+// JournalEntry is a request that matched a mock definition.
+type JournalEntry struct {
+	Id      string              `json:"id"`
+	Method  string              `json:"method"`
+	Url     string              `json:"url"`
+	Host    string              `json:"host,omitempty"`
+	Headers map[string][]string `json:"headers,omitempty"`
+	Body    string              `json:"body,omitempty"`
+	Time    time.Time           `json:"time"`
+}
+
+// request rebuilds the HTTP request of an entry, so it can be checked against a request definition.
+func (je *JournalEntry) request() *http.Request {
+	u, err := url.Parse(je.Url)
+	if err != nil {
+		u = &url.URL{Path: je.Url}
+	}
+	req := &http.Request{
+		Method: je.Method,
+		URL:    u,
+		Host:   je.Host,
+		Header: http.Header(je.Headers).Clone(),
+		Body:   http.NoBody,
+	}
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	if je.Body != "" {
+		req.Body = io.NopCloser(strings.NewReader(je.Body))
+	}
+	return req
+}
+
+// VerifyRequest selects journal entries by the id of the definition they matched, a request definition, or both.
+// Count, AtLeast and AtMost are the number of entries expected, when none are set at least one is expected.
+type VerifyRequest struct {
+	Id      string                       `json:"id,omitempty"`
+	Request *StaticMockDefinitionRequest `json:"request,omitempty"`
+	Count   *int                         `json:"count,omitempty"`
+	AtLeast *int                         `json:"atLeast,omitempty"`
+	AtMost  *int                         `json:"atMost,omitempty"`
+}
+
+// VerifyResponse reports the entries selected by a VerifyRequest, and whether there were as many as expected.
+type VerifyResponse struct {
+	Verified bool            `json:"verified"`
+	Count    int             `json:"count"`
+	Message  string          `json:"message,omitempty"`
+	Requests []*JournalEntry `json:"requests"`
+}
+
+// requestJournal keeps every request that matched a mock definition, in the order they were received. Secrets are
+// redacted the same way record mode redacts them.
+type requestJournal struct {
+	lock    sync.Mutex
+	entries []*JournalEntry
+	redact  redactions
+}
+
+func newRequestJournal() *requestJournal {
+	return &requestJournal{redact: newRedactions(nil)}
+}
+
+// record adds a matched request to the journal, with redacted headers, query parameters and JSON fields replaced.
+func (j *requestJournal) record(definitionId string, request *http.Request) {
+	if j == nil {
+		return
+	}
+	entry := &JournalEntry{
+		Id:      definitionId,
+		Method:  request.Method,
+		Url:     j.redactURL(request.URL),
+		Host:    request.Host,
+		Headers: j.redactHeaders(request.Header),
+		Body:    j.redactBody(readRequestBody(request)),
+		Time:    time.Now(),
+	}
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	if len(j.entries) >= maxJournalEntries {
+		j.entries = j.entries[1:]
+	}
+	j.entries = append(j.entries, entry)
+}
+
+func (j *requestJournal) redactURL(u *url.URL) string {
+	query := u.Query()
+	var redacted bool
+	for key, values := range query {
+		if j.redact.has(key) {
+			for i := range values {
+				values[i] = Redacted
+			}
+			redacted = true
+		}
+	}
+	if !redacted {
+		return u.String()
+	}
+	clean := *u
+	clean.RawQuery = query.Encode()
+	return clean.String()
+}
+
+func (j *requestJournal) redactHeaders(header http.Header) map[string][]string {
+	headers := header.Clone()
+	for key, values := range headers {
+		if j.redact.has(key) {
+			for i := range values {
+				values[i] = Redacted
+			}
+		}
+	}
+	return headers
+}
+
+// redactBody replaces redacted fields of a JSON body, other bodies are kept as they are.
+func (j *requestJournal) redactBody(body []byte) string {
+	var decoded any
+	if json.Unmarshal(body, &decoded) != nil {
+		return string(body)
+	}
+	switch decoded.(type) {
+	case map[string]any, []any:
+		if b, err := json.Marshal(j.redact.redactJSON(decoded, false)); err == nil {
+			return string(b)
+		}
+	}
+	return string(body)
+}
+
+// snapshot returns a copy of the entries in the journal.
+func (j *requestJournal) snapshot() []*JournalEntry {
+	if j == nil {
+		return nil
+	}
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return append([]*JournalEntry{}, j.entries...)
+}
+
+func (j *requestJournal) reset() {
+	if j == nil {
+		return
+	}
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	j.entries = nil
+}
+
+// Journal returns every request that matched a mock definition, oldest first.
+func (sms *StaticMockService) Journal() []*JournalEntry {
+	return sms.journal.snapshot()
+}
+
+// FindRequests returns the journal entries that matched the definition with the id, and the request definition,
+// when they are set.
+func (sms *StaticMockService) FindRequests(id string, definition *StaticMockDefinitionRequest) []*JournalEntry {
+	found := make([]*JournalEntry, 0)
+	for _, entry := range sms.journal.snapshot() {
+		if id != "" && entry.Id != id {
+			continue
+		}
+		if definition != nil && len(sms.evaluateRequestMatch(*definition, entry.request(), true)) > 0 {
+			continue
+		}
+		found = append(found, entry)
+	}
+	return found
+}
+
+// Verify checks that the journal holds as many requests as expected.
+func (sms *StaticMockService) Verify(verify VerifyRequest) *VerifyResponse {
+	found := sms.FindRequests(verify.Id, verify.Request)
+	count := len(found)
+	response := &VerifyResponse{Verified: true, Count: count, Requests: found}
+	switch {
+	case verify.Count != nil && count != *verify.Count:
+		response.Message = fmt.Sprintf("expected %d requests, received %d", *verify.Count, count)
+	case verify.AtLeast != nil && count < *verify.AtLeast:
+		response.Message = fmt.Sprintf("expected at least %d requests, received %d", *verify.AtLeast, count)
+	case verify.AtMost != nil && count > *verify.AtMost:
+		response.Message = fmt.Sprintf("expected at most %d requests, received %d", *verify.AtMost, count)
+	case verify.Count == nil && verify.AtLeast == nil && verify.AtMost == nil && count == 0:
+		response.Message = "expected at least 1 request, received 0"
+	}
+	response.Verified = response.Message == ""
+	return response
+}
+
+// decodeVerifyRequest reads a VerifyRequest from a payload, the request definition uses the same shape as a mock
+// definition file.
+func decodeVerifyRequest(payload any) (VerifyRequest, error) {
+	var verify VerifyRequest
+	if payload == nil {
+		return verify, nil
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return verify, err
+	}
+	if err = json.Unmarshal(b, &verify); err != nil {
+		return verify, err
+	}
+	if verify.Request != nil {
+		err = checkXPathMatchers(*verify.Request)
+	}
+	return verify, err
+}
+
+func (sms *StaticMockService) verifyStaticMock(request *model.Request, core service.FabricServiceCore) {
+	verify, err := decodeVerifyRequest(request.Payload)
+	if err != nil {
+		core.SendErrorResponse(request, 400, fmt.Sprintf("Invalid verification request: %s", err.Error()))
+		return
+	}
+	core.SendResponse(request, sms.Verify(verify))
+}
+
+func (sms *StaticMockService) getStaticMockJournal(request *model.Request, core service.FabricServiceCore) {
+	verify, err := decodeVerifyRequest(request.Payload)
+	if err != nil {
+		core.SendErrorResponse(request, 400, fmt.Sprintf("Invalid journal request: %s", err.Error()))
+		return
+	}
+	core.SendResponse(request, sms.FindRequests(verify.Id, verify.Request))
+}
+
+func (sms *StaticMockService) resetStaticMockJournal(request *model.Request, core service.FabricServiceCore) {
+	sms.journal.reset()
+	core.SendResponse(request, sms.journal.snapshot())
+}
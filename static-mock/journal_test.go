@@ -0,0 +1,93 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package staticMock
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticMockService_Verify(t *testing.T) {
+	sms := &StaticMockService{logger: slog.Default(), state: newMockState(), journal: newRequestJournal(),
+		mockDefinitions: []StaticMockDefinition{
+			{Id: "create-pet", Request: StaticMockDefinitionRequest{Method: "POST", UrlPath: "/pets"}},
+			{Id: "list-pets", Request: StaticMockDefinitionRequest{Method: "GET", UrlPath: "/pets"}},
+		}}
+
+	for _, name := range []string{"fido", "rex"} {
+		req, _ := http.NewRequest(http.MethodPost, "http://localhost/pets", strings.NewReader(`{"name":"`+name+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		require.NotNil(t, sms.checkStaticMockExists(req))
+	}
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/pets?limit=1", nil)
+	require.NotNil(t, sms.checkStaticMockExists(req))
+	req, _ = http.NewRequest(http.MethodDelete, "http://localhost/pets", nil)
+	require.Nil(t, sms.checkStaticMockExists(req))
+
+	journal := sms.Journal()
+	require.Len(t, journal, 3)
+	assert.Equal(t, "create-pet", journal[0].Id)
+	assert.Equal(t, `{"name":"fido"}`, journal[0].Body)
+	assert.Equal(t, "http://localhost/pets?limit=1", journal[2].Url)
+
+	two, one := 2, 1
+	result := sms.Verify(VerifyRequest{Id: "create-pet", Count: &two})
+	assert.True(t, result.Verified)
+	assert.Len(t, result.Requests, 2)
+
+	verify, err := decodeVerifyRequest(map[string]any{
+		"request": map[string]any{"bodyMatchers": map[string]any{"$.name": "rex"}},
+		"count":   1,
+	})
+	require.NoError(t, err)
+	result = sms.Verify(verify)
+	assert.True(t, result.Verified)
+	assert.Equal(t, `{"name":"rex"}`, result.Requests[0].Body)
+
+	result = sms.Verify(VerifyRequest{Request: &StaticMockDefinitionRequest{UrlPath: "/pets"}, AtMost: &one})
+	assert.False(t, result.Verified)
+	assert.Equal(t, "expected at most 1 requests, received 3", result.Message)
+
+	result = sms.Verify(VerifyRequest{Id: "missing"})
+	assert.False(t, result.Verified)
+	assert.Equal(t, "expected at least 1 request, received 0", result.Message)
+
+	sms.journal.reset()
+	assert.Empty(t, sms.Journal())
+}
+
+func TestStaticMockService_JournalRedactsSecrets(t *testing.T) {
+	sms := &StaticMockService{logger: slog.Default(), state: newMockState(), journal: newRequestJournal(),
+		mockDefinitions: []StaticMockDefinition{
+			{Id: "login", Request: StaticMockDefinitionRequest{Method: "POST", UrlPath: "/login"}},
+		}}
+
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost/login?api_key=abc&next=home",
+		strings.NewReader(`{"user":"sam","password":"hunter2"}`))
+	req.Header.Set("Authorization", "Bearer abc")
+	req.Header.Set("Cookie", "session=abc")
+	req.Header.Set("Content-Type", "application/json")
+	require.NotNil(t, sms.checkStaticMockExists(req))
+
+	journal := sms.Journal()
+	require.Len(t, journal, 1)
+	assert.Equal(t, []string{Redacted}, journal[0].Headers["Authorization"])
+	assert.Equal(t, []string{Redacted}, journal[0].Headers["Cookie"])
+	assert.Equal(t, []string{"application/json"}, journal[0].Headers["Content-Type"])
+	assert.Equal(t, "http://localhost/login?api_key=REDACTED&next=home", journal[0].Url)
+	assert.Equal(t, `{"password":"REDACTED","user":"sam"}`, journal[0].Body)
+	assert.NotContains(t, journal[0].Url+journal[0].Body, "abc")
+
+	// redacted headers can still be verified by their presence.
+	result := sms.Verify(VerifyRequest{Request: &StaticMockDefinitionRequest{
+		Header: &map[string]any{"Authorization": map[string]any{"exists": true}}}})
+	assert.True(t, result.Verified)
+}
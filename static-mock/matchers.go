@@ -0,0 +1,294 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package staticMock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pb33f/wiretap/shared"
+)
+
+// StaticMockMatcher is an explicit matcher for a header, query parameter, cookie or body field. Every operator set
+// must pass for at least one of the values found. `absent` passes only when no value is found, `exists` only checks
+// that a value is (or is not) found. `equals: null` only passes for a null value.
+type StaticMockMatcher struct {
+	Equals   any      `json:"equals,omitempty"`
+	Contains string   `json:"contains,omitempty"`
+	Regex    string   `json:"regex,omitempty"`
+	Exists   *bool    `json:"exists,omitempty"`
+	Absent   bool     `json:"absent,omitempty"`
+	Gt       *float64 `json:"gt,omitempty"`
+	Gte      *float64 `json:"gte,omitempty"`
+	Lt       *float64 `json:"lt,omitempty"`
+	Lte      *float64 `json:"lte,omitempty"`
+	// equalsNull is set when equals is null, which cannot be told apart from an unset Equals.
+	equalsNull bool
+}
+
+// UnmarshalJSON decodes a matcher, noting when equals is set to null.
+func (m *StaticMockMatcher) UnmarshalJSON(b []byte) error {
+	type matcher StaticMockMatcher
+	if err := json.Unmarshal(b, (*matcher)(m)); err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+	if equals, ok := fields["equals"]; ok && string(bytes.TrimSpace(equals)) == "null" {
+		m.equalsNull = true
+	}
+	return nil
+}
+
+// regexCache holds the compiled regex of matchers, by pattern, so each pattern is only compiled once. It is cleared
+// every time the definitions are loaded, like the template cache.
+var regexCache sync.Map
+
+// resetRegexCache forgets the regex compiled for previously loaded definitions.
+func resetRegexCache() {
+	regexCache.Clear()
+}
+
+// compileMatcherRegex compiles the regex of a matcher, or returns it from the cache.
+func compileMatcherRegex(pattern string) (*regexp.Regexp, error) {
+	if cached, ok := regexCache.Load(pattern); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexCache.Store(pattern, re)
+	return re, nil
+}
+
+// checkMatchers compiles the regex of every matcher of a request definition, so a definition with an invalid
+// pattern fails to load instead of never matching.
+func checkMatchers(request StaticMockDefinitionRequest) error {
+	fields := map[string]map[string]any{"bodyMatchers": request.BodyMatchers, "xpath": request.XPath}
+	if request.Header != nil {
+		fields["header"] = *request.Header
+	}
+	if request.QueryParams != nil {
+		fields["queryParams"] = *request.QueryParams
+	}
+	if request.Cookies != nil {
+		fields["cookies"] = *request.Cookies
+	}
+	for _, kind := range sortedKeys(fields) {
+		for _, key := range sortedKeys(fields[kind]) {
+			definition, ok := fields[kind][key].(map[string]any)
+			if !ok {
+				continue
+			}
+			matcher, err := toMatcher(definition)
+			if err != nil {
+				return fmt.Errorf("invalid %s matcher '%s': %w", kind, key, err)
+			}
+			if matcher.Regex == "" {
+				continue
+			}
+			if _, err = compileMatcherRegex(matcher.Regex); err != nil {
+				return fmt.Errorf("invalid regex for %s matcher '%s': %w", kind, key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of a map in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// toMatcher converts a matcher defined as an object in a mock definition into a StaticMockMatcher.
+func toMatcher(definition map[string]any) (*StaticMockMatcher, error) {
+	b, err := json.Marshal(definition)
+	if err != nil {
+		return nil, err
+	}
+	var matcher StaticMockMatcher
+	if err = json.Unmarshal(b, &matcher); err != nil {
+		return nil, err
+	}
+	return &matcher, nil
+}
+
+// Match checks the matcher against every value found for a field.
+func (m *StaticMockMatcher) Match(values []any) bool {
+	if m.Absent || (m.Exists != nil && !*m.Exists) {
+		return len(values) == 0
+	}
+	if len(values) == 0 {
+		return false
+	}
+	for _, value := range values {
+		if m.matchValue(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *StaticMockMatcher) matchValue(value any) bool {
+	str := stringifyValue(value)
+	if m.equalsNull && value != nil {
+		return false
+	}
+	if m.Equals != nil && stringifyValue(m.Equals) != str {
+		return false
+	}
+	if m.Contains != "" && !strings.Contains(str, m.Contains) {
+		return false
+	}
+	if m.Regex != "" {
+		re, err := compileMatcherRegex(m.Regex)
+		if err != nil || !re.MatchString(str) {
+			return false
+		}
+	}
+	if m.Gt != nil || m.Gte != nil || m.Lt != nil || m.Lte != nil {
+		n, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return false
+		}
+		if (m.Gt != nil && !(n > *m.Gt)) || (m.Gte != nil && !(n >= *m.Gte)) ||
+			(m.Lt != nil && !(n < *m.Lt)) || (m.Lte != nil && !(n <= *m.Lte)) {
+			return false
+		}
+	}
+	return true
+}
+
+// stringifyValue renders a value for comparison, objects and arrays are compared as JSON.
+func stringifyValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case map[string]any, []any:
+		b, _ := json.Marshal(v)
+		return string(b)
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// matchField checks the values of a header, query parameter or cookie against its definition. A string or array of
+// strings is compared as a subset (exact or regex), an object is read as a StaticMockMatcher.
+func matchField(definition any, values []string) bool {
+	incoming := make([]any, len(values))
+	for i, v := range values {
+		incoming[i] = v
+	}
+	switch d := definition.(type) {
+	case string:
+		return shared.IsSubset([]any{d}, incoming)
+	case []any:
+		return shared.IsSubset(d, incoming)
+	case map[string]any:
+		matcher, err := toMatcher(d)
+		if err != nil {
+			return false
+		}
+		return matcher.Match(incoming)
+	}
+	return false
+}
+
+// mismatchedFields checks every field defined against the values returned by lookup, and returns the keys of
+// the fields that did not match, in a stable order.
+func mismatchedFields(definitions map[string]any, lookup func(key string) []string) []string {
+	var mismatched []string
+	for key, definition := range definitions {
+		if !matchField(definition, lookup(key)) {
+			mismatched = append(mismatched, key)
+		}
+	}
+	sort.Strings(mismatched)
+	return mismatched
+}
+
+// cookieLookup returns a lookup of the cookie values of a request, by name.
+func cookieLookup(incoming *http.Request) func(key string) []string {
+	cookies := incoming.Cookies()
+	return func(key string) []string {
+		var values []string
+		for _, c := range cookies {
+			if c.Name == key {
+				values = append(values, c.Value)
+			}
+		}
+		return values
+	}
+}
+
+// valueMatcher returns the matcher for a body field. A plain value must equal a value found, an object is read as
+// a StaticMockMatcher.
+func valueMatcher(definition any) (*StaticMockMatcher, error) {
+	if d, ok := definition.(map[string]any); ok {
+		return toMatcher(d)
+	}
+	return &StaticMockMatcher{Equals: definition, equalsNull: definition == nil}, nil
+}
+
+// mismatchedValues checks every matcher against the values returned by query, and returns the keys of the
+// matchers that did not match, in a stable order.
+func mismatchedValues(matchers map[string]any, query func(key string) ([]any, error)) []string {
+	var mismatched []string
+	for key, definition := range matchers {
+		found, err := query(key)
+		if err != nil {
+			mismatched = append(mismatched, key)
+			continue
+		}
+		matcher, err := valueMatcher(definition)
+		if err != nil || !matcher.Match(found) {
+			mismatched = append(mismatched, key)
+		}
+	}
+	sort.Strings(mismatched)
+	return mismatched
+}
+
+// mismatchedBodyMatchers checks JSONPath keyed matchers against the body of the incoming request and returns the
+// paths that did not match. Form bodies are matched as an object of their fields.
+func (sms *StaticMockService) mismatchedBodyMatchers(bodyMatchers map[string]any, incoming *http.Request) []string {
+	body := bodyAsJSON(incoming)
+	return mismatchedValues(bodyMatchers, func(path string) ([]any, error) {
+		return shared.QueryJSONPath(body, path)
+	})
+}
+
+// mismatchedXPathMatchers checks XPath keyed matchers against the XML body of the incoming request and returns the
+// expressions that did not match. A body that is not XML matches none of them, except `absent` matchers.
+func mismatchedXPathMatchers(xpathMatchers map[string]any, incoming *http.Request) []string {
+	document, parseErr := parseXML(readRequestBody(incoming))
+	return mismatchedValues(xpathMatchers, func(expression string) ([]any, error) {
+		if parseErr != nil {
+			if _, err := parseXPath(expression); err != nil {
+				return nil, err
+			}
+			return nil, nil
+		}
+		return evaluateXPath(document, expression)
+	})
+}
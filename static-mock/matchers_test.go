@@ -0,0 +1,165 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package staticMock
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeDefinition(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadMockDefinitionFile_YAML(t *testing.T) {
+	path := writeDefinition(t, "pets.yaml", `- request:
+    method: POST
+    urlPath: /pets
+    header:
+      X-Tenant:
+        regex: ^acme-\d+$
+      X-Debug:
+        absent: true
+    queryParams:
+      dryRun: "true"
+    cookies:
+      session:
+        exists: true
+    bodyMatchers:
+      $.name:
+        contains: fi
+      $.age:
+        gte: 1
+        lt: 10
+      $.kind: dog
+      $.owner:
+        exists: true
+  response:
+    statusCode: 201
+    body: '{"created": true}'
+- request:
+    method: GET
+    urlPath: /pets
+  response:
+    statusCode: 200`)
+
+	definitions, errs := loadMockDefinitionFile(path)
+	assert.Empty(t, errs)
+	require.Len(t, definitions, 2)
+
+	sms := &StaticMockService{logger: slog.Default(), state: newMockState(), mockDefinitions: definitions}
+
+	newRequest := func(body string) *http.Request {
+		r, _ := http.NewRequest(http.MethodPost, "http://localhost/pets?dryRun=true", bytes.NewBufferString(body))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("x-tenant", "acme-42")
+		r.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+		return r
+	}
+
+	r := newRequest(`{"name":"fido","age":3,"kind":"dog","owner":{"id":1}}`)
+	assert.True(t, sms.isRequestMatch(definitions[0].Request, r))
+
+	// out of range
+	r = newRequest(`{"name":"fido","age":10,"kind":"dog","owner":{"id":1}}`)
+	assert.False(t, sms.isRequestMatch(definitions[0].Request, r))
+
+	// missing path
+	r = newRequest(`{"name":"fido","age":3,"kind":"dog"}`)
+	assert.False(t, sms.isRequestMatch(definitions[0].Request, r))
+
+	// header that must be absent
+	r = newRequest(`{"name":"fido","age":3,"kind":"dog","owner":{"id":1}}`)
+	r.Header.Set("X-Debug", "1")
+	assert.False(t, sms.isRequestMatch(definitions[0].Request, r))
+
+	// missing cookie
+	r, _ = http.NewRequest(http.MethodPost, "http://localhost/pets?dryRun=true",
+		bytes.NewBufferString(`{"name":"fido","age":3,"kind":"dog","owner":{"id":1}}`))
+	r.Header.Set("X-Tenant", "acme-42")
+	assert.False(t, sms.isRequestMatch(definitions[0].Request, r))
+
+	r, _ = http.NewRequest(http.MethodGet, "http://localhost/pets", nil)
+	matched := sms.checkStaticMockExists(r)
+	require.NotNil(t, matched)
+	assert.Equal(t, 200, matched.Response.StatusCode)
+}
+
+func TestLoadMockDefinitionFile_Errors(t *testing.T) {
+	path := writeDefinition(t, "broken.yml", `request: [`)
+	_, errs := loadMockDefinitionFile(path)
+	require.Len(t, errs, 1)
+	assert.Equal(t, -1, errs[0].Index)
+	assert.Contains(t, errs[0].Error(), "invalid YAML")
+
+	path = writeDefinition(t, "mixed.json", `[
+  {"request": {"method": "GET"}, "response": {"statusCode": 200}},
+  {"request": {"urlPath": "/nope"}, "response": {"statusCode": 200}},
+  {"request": {"method": "GET", "header": {"X-Id": {"regex": "("}}}, "response": {}},
+  {"request": {"method": "GET", "cookie": {}}, "response": {"statusCode": 42}}
+]`)
+	definitions, errs := loadMockDefinitionFile(path)
+	assert.Len(t, definitions, 1)
+	require.Len(t, errs, 3)
+	assert.Equal(t, 1, errs[0].Index)
+	assert.Contains(t, errs[0].Error(), "definition 1: invalid mock definition: at '/request': missing property 'method'")
+	assert.Equal(t, 2, errs[1].Index)
+	assert.Contains(t, errs[1].Error(), "regex")
+	assert.Equal(t, 3, errs[2].Index)
+	assert.Contains(t, errs[2].Error(), "cookie")
+	assert.Contains(t, errs[2].Error(), "statusCode")
+
+	// patterns Go cannot compile fail the definition instead of never matching, valid ones are compiled once.
+	path = writeDefinition(t, "patterns.json", `[
+  {"request": {"method": "GET", "bodyMatchers": {"$.id": {"regex": "^(?=a)"}}}, "response": {"statusCode": 200}},
+  {"request": {"method": "GET", "cookies": {"session": {"regex": "^s-[0-9]+$"}}}, "response": {"statusCode": 200}}
+]`)
+	definitions, errs = loadMockDefinitionFile(path)
+	assert.Len(t, definitions, 1)
+	require.Len(t, errs, 1)
+	assert.Equal(t, 0, errs[0].Index)
+	assert.Contains(t, errs[0].Error(), "regex")
+	_, compiled := regexCache.Load("^s-[0-9]+$")
+	assert.True(t, compiled)
+	assert.Error(t, checkMatchers(StaticMockDefinitionRequest{BodyMatchers: map[string]any{
+		"$.id": map[string]any{"regex": "^(?=a)"}}}))
+
+	path = writeDefinition(t, "scalar.json", `"nope"`)
+	_, errs = loadMockDefinitionFile(path)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "must contain an object or an array")
+}
+
+func TestStaticMockMatcher_Match(t *testing.T) {
+	yes, no := true, false
+	one, ten := 1.0, 10.0
+
+	assert.True(t, (&StaticMockMatcher{Equals: 3}).Match([]any{3.0}))
+	assert.True(t, (&StaticMockMatcher{Equals: "a"}).Match([]any{"b", "a"}))
+	assert.False(t, (&StaticMockMatcher{Equals: "a"}).Match(nil))
+	assert.True(t, (&StaticMockMatcher{Absent: true}).Match(nil))
+	assert.True(t, (&StaticMockMatcher{Exists: &no}).Match(nil))
+	assert.False(t, (&StaticMockMatcher{Exists: &yes}).Match(nil))
+	assert.True(t, (&StaticMockMatcher{Gt: &one, Lte: &ten}).Match([]any{"10"}))
+
+	isNull, err := toMatcher(map[string]any{"equals": nil})
+	require.NoError(t, err)
+	assert.True(t, isNull.Match([]any{nil}))
+	assert.False(t, isNull.Match([]any{"fido"}))
+	plainNull, _ := valueMatcher(nil)
+	assert.False(t, plainNull.Match([]any{"fido"}))
+	assert.False(t, (&StaticMockMatcher{Gt: &one}).Match([]any{"one"}))
+	assert.True(t, (&StaticMockMatcher{Equals: map[string]any{"a": 1.0}}).Match([]any{map[string]any{"a": 1}}))
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package staticMock
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+//go:embed schemas/mock-definition.json
+var mockDefinitionSchema []byte
+
+const mockDefinitionSchemaURL = "https://pb33f.io/wiretap/schemas/mock-definition.json"
+
+var (
+	compiledDefinitionSchema *jsonschema.Schema
+	compileSchemaOnce        sync.Once
+	schemaErrorPrinter       = message.NewPrinter(language.English)
+)
+
+// MockDefinitionLoadError describes a mock definition file, or a definition in a file, that could not be loaded.
+// Index is the position of the definition in the file, or -1 if the whole file failed.
+type MockDefinitionLoadError struct {
+	File  string `json:"file"`
+	Index int    `json:"index"`
+	Err   error  `json:"-"`
+}
+
+func (e *MockDefinitionLoadError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("%s: %s", e.File, e.Err.Error())
+	}
+	return fmt.Sprintf("%s: definition %d: %s", e.File, e.Index, e.Err.Error())
+}
+
+func (e *MockDefinitionLoadError) MarshalJSON() ([]byte, error) {
+	type alias MockDefinitionLoadError
+	return json.Marshal(&struct {
+		*alias
+		Message string `json:"message"`
+	}{alias: (*alias)(e), Message: e.Err.Error()})
+}
+
+func getDefinitionSchema() *jsonschema.Schema {
+	compileSchemaOnce.Do(func() {
+		doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(mockDefinitionSchema))
+		if err != nil {
+			panic(err) // the embedded schema is broken, this is a bug.
+		}
+		c := jsonschema.NewCompiler()
+		c.AssertFormat()
+		if err = c.AddResource(mockDefinitionSchemaURL, doc); err != nil {
+			panic(err)
+		}
+		compiledDefinitionSchema = c.MustCompile(mockDefinitionSchemaURL)
+	})
+	return compiledDefinitionSchema
+}
+
+// validateMockDefinition checks a decoded definition against the mock definition schema.
+func validateMockDefinition(definition any) error {
+	b, err := json.Marshal(definition)
+	if err != nil {
+		return err
+	}
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	if err = getDefinitionSchema().Validate(instance); err != nil {
+		if ve, ok := err.(*jsonschema.ValidationError); ok {
+			return fmt.Errorf("invalid mock definition: %s", flattenSchemaError(ve))
+		}
+		return err
+	}
+	return nil
+}
+
+// flattenSchemaError turns the nested schema validation error into a single line, listing the leaf causes.
+func flattenSchemaError(ve *jsonschema.ValidationError) string {
+	var messages []string
+	var walk func(e *jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			location := "/" + strings.Join(e.InstanceLocation, "/")
+			messages = append(messages, fmt.Sprintf("at '%s': %s", location, e.ErrorKind.LocalizedString(schemaErrorPrinter)))
+			return
+		}
+		for _, c := range e.Causes {
+			walk(c)
+		}
+	}
+	walk(ve)
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package staticMock
+
+import (
+	"regexp"
+	"sort"
+)
+
+// weights used to score how specific a request definition is. Exact values narrow a match more than patterns,
+// which narrow it more than presence checks.
+const (
+	exactWeight    = 3
+	patternWeight  = 2
+	presenceWeight = 1
+)
+
+// sortStaticMockDefinitions orders definitions by priority, then specificity, highest first. Definitions that tie
+// keep the order they were loaded in.
+func sortStaticMockDefinitions(definitions []StaticMockDefinition) {
+	sort.SliceStable(definitions, func(i, j int) bool {
+		if definitions[i].Priority != definitions[j].Priority {
+			return definitions[i].Priority > definitions[j].Priority
+		}
+		return definitions[i].Specificity > definitions[j].Specificity
+	})
+}
+
+// specificity scores a request definition by the number and type of its matchers.
+func (r StaticMockDefinitionRequest) specificity() int {
+	score := 0
+	if r.Method != "" {
+		score += presenceWeight
+	}
+	score += stringSpecificity(r.Host)
+	score += stringSpecificity(r.UrlPath)
+	for _, fields := range []*map[string]any{r.Header, r.QueryParams, r.Cookies} {
+		if fields == nil {
+			continue
+		}
+		for _, definition := range *fields {
+			score += fieldSpecificity(definition)
+		}
+	}
+	for _, definition := range r.BodyMatchers {
+		score += valueSpecificity(definition)
+	}
+	for _, definition := range r.XPath {
+		score += valueSpecificity(definition)
+	}
+	switch b := r.Body.(type) {
+	case string:
+		score += exactWeight
+	case map[string]any:
+		score += patternWeight * len(b)
+	case []any:
+		score += patternWeight * len(b)
+	}
+	return score
+}
+
+func stringSpecificity(value string) int {
+	if value == "" {
+		return 0
+	}
+	if regexp.QuoteMeta(value) == value {
+		return exactWeight
+	}
+	return patternWeight
+}
+
+// valueSpecificity scores a body matcher, plain values are exact.
+func valueSpecificity(definition any) int {
+	if _, ok := definition.(map[string]any); ok {
+		return fieldSpecificity(definition)
+	}
+	return exactWeight
+}
+
+func fieldSpecificity(definition any) int {
+	switch d := definition.(type) {
+	case string:
+		return stringSpecificity(d)
+	case []any:
+		score := 0
+		for _, v := range d {
+			if s, ok := v.(string); ok {
+				score += stringSpecificity(s)
+			}
+		}
+		return score
+	case map[string]any:
+		score := 0
+		for operator := range d {
+			switch operator {
+			case "equals":
+				score += exactWeight
+			case "exists", "absent":
+				score += presenceWeight
+			default:
+				score += patternWeight
+			}
+		}
+		return score
+	}
+	return 0
+}
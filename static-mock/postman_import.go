@@ -0,0 +1,276 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package staticMock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pb33f/harhar"
+	"github.com/pb33f/wiretap/capture"
+)
+
+// importSkipHeaders are request headers Postman or the client sets on every request, matching them would only
+// stop a definition from matching other clients.
+var importSkipHeaders = []string{
+	"Host", "Content-Length", "Connection", "User-Agent", "Accept-Encoding", "Cache-Control", "Postman-Token",
+}
+
+// ImportedDefinition is a mock definition built from a collection example, and the file it is written to,
+// relative to the mock definitions directory.
+type ImportedDefinition struct {
+	File       string
+	Definition StaticMockDefinition
+}
+
+// ImportPostman converts the saved example responses of a Postman collection into static mock definitions. Each
+// request with examples becomes a file, placed in directories named after the folders of the collection. A request
+// with several examples gets an array of definitions, one per example.
+func ImportPostman(data []byte) ([]*ImportedDefinition, error) {
+	examples, err := capture.PostmanExamples(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var imported []*ImportedDefinition
+	files := make(map[string]string)
+	ids := make(map[string]int)
+	for _, example := range examples {
+		var dirs []string
+		for _, folder := range example.Folders {
+			dirs = append(dirs, importSlug(folder, "folder"))
+		}
+		// examples of requests with the same name share a file, names that only slug alike get their own files.
+		key := strings.Join(append(dirs, example.Request), "\x00")
+		file, found := files[key]
+		if !found {
+			file = filepath.Join(append(dirs, importSlug(example.Request, "request"))...)
+			for n := 2; containsFile(files, file+".json"); n++ {
+				file = filepath.Join(append(dirs, fmt.Sprintf("%s-%d", importSlug(example.Request, "request"), n))...)
+			}
+			file += ".json"
+			files[key] = file
+		}
+
+		definition, err := definitionFromEntry(example.Entry)
+		if err != nil {
+			return nil, fmt.Errorf("unable to import example '%s' of '%s': %w", example.Name, example.Request, err)
+		}
+		id := strings.TrimSuffix(filepath.ToSlash(file), ".json") + "/" + importSlug(example.Name, "example")
+		ids[id]++
+		if ids[id] > 1 {
+			id = fmt.Sprintf("%s-%d", id, ids[id])
+		}
+		definition.Id = id
+		imported = append(imported, &ImportedDefinition{File: file, Definition: definition})
+	}
+	return imported, nil
+}
+
+// WriteImportedDefinitions writes imported definitions into the mock definitions directory of the static mock
+// directory, it returns the files written. Existing files are replaced.
+func WriteImportedDefinitions(staticMockDir string, imported []*ImportedDefinition) ([]string, error) {
+	var order []string
+	byFile := make(map[string][]StaticMockDefinition)
+	for _, i := range imported {
+		if _, found := byFile[i.File]; !found {
+			order = append(order, i.File)
+		}
+		byFile[i.File] = append(byFile[i.File], i.Definition)
+	}
+
+	var written []string
+	for _, file := range order {
+		var out []byte
+		if definitions := byFile[file]; len(definitions) == 1 {
+			out, _ = json.MarshalIndent(definitions[0], "", "  ")
+		} else {
+			out, _ = json.MarshalIndent(definitions, "", "  ")
+		}
+		path := filepath.Join(staticMockDir+MockDefinitionsPath, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return written, err
+		}
+		if err := os.WriteFile(path, out, 0644); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// definitionFromEntry builds a definition that matches the request of an example, and responds with its response.
+// Values holding variables the collection does not define can be anything, so they are only checked for presence.
+func definitionFromEntry(entry harhar.Entry) (StaticMockDefinition, error) {
+	target := entry.Request.URL
+	// a host held in a variable, such as {{baseUrl}}/pets.
+	if strings.HasPrefix(target, "{{") {
+		if _, rest, found := strings.Cut(target, "}}"); found {
+			target = rest
+		}
+	}
+	if !strings.Contains(target, "://") && !strings.HasPrefix(target, "/") {
+		if _, rest, found := strings.Cut(target, "/"); found {
+			target = "/" + rest
+		} else {
+			target = "/"
+		}
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return StaticMockDefinition{}, err
+	}
+
+	request := StaticMockDefinitionRequest{
+		Method:  entry.Request.Method,
+		UrlPath: pathPattern(u.Path),
+	}
+
+	if query := u.Query(); len(query) > 0 {
+		queryParams := make(map[string]any)
+		for key, values := range query {
+			var matchers []any
+			for _, v := range values {
+				matchers = append(matchers, importMatcher(v))
+			}
+			if len(matchers) == 1 {
+				queryParams[key] = matchers[0]
+			} else {
+				queryParams[key] = matchers
+			}
+		}
+		request.QueryParams = &queryParams
+	}
+
+	header := make(map[string]any)
+	for _, h := range entry.Request.Headers {
+		name := http.CanonicalHeaderKey(h.Name)
+		if containsFold(importSkipHeaders, name) {
+			continue
+		}
+		if containsFold(defaultRedactions, name) {
+			header[name] = map[string]any{"exists": true}
+			continue
+		}
+		header[name] = importMatcher(h.Value)
+	}
+	if len(header) > 0 {
+		request.Header = &header
+	}
+
+	if content := entry.Request.Body.Content; content != "" {
+		var body any
+		_ = json.Unmarshal([]byte(content), &body)
+		switch body.(type) {
+		case map[string]any, []any:
+			request.Body = withoutVariables(body)
+		default:
+			if !capture.PostmanVariable.MatchString(content) {
+				request.Body = content
+			}
+		}
+	}
+
+	responseHeader := make(map[string]any)
+	for _, h := range entry.Response.Headers {
+		name := http.CanonicalHeaderKey(h.Name)
+		if containsFold(recordSkipHeaders, name) || strings.HasPrefix(name, "Access-Control-") {
+			continue
+		}
+		if existing, found := responseHeader[name]; found {
+			responseHeader[name] = existing.(string) + ", " + h.Value
+		} else {
+			responseHeader[name] = h.Value
+		}
+	}
+	statusCode := entry.Response.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+
+	// examples often contain Postman variables, such as `{{baseUrl}}`, so they are served as they were saved and
+	// are never rendered as templates.
+	return StaticMockDefinition{
+		Request: request,
+		Response: StaticMockDefinitionResponse{
+			StatusCode: statusCode,
+			Header:     responseHeader,
+			Body:       entry.Response.Body.Content,
+			Template:   false,
+		},
+	}, nil
+}
+
+// pathPattern returns a matcher for a request path. Postman path variables (such as `:id`) and undefined variables
+// match any segment, any other segment only matches itself.
+func pathPattern(path string) string {
+	segments := strings.Split(path, "/")
+	variable := false
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") && len(segment) > 1 || capture.PostmanVariable.MatchString(segment) {
+			segments[i] = "[^/]+"
+			variable = true
+		} else {
+			segments[i] = regexp.QuoteMeta(segment)
+		}
+	}
+	if !variable {
+		return exactPattern(path)
+	}
+	return "^" + strings.Join(segments, "/") + "$"
+}
+
+// importMatcher returns an exact matcher for a value, or a presence matcher if it holds an undefined variable.
+func importMatcher(value string) any {
+	if capture.PostmanVariable.MatchString(value) {
+		return map[string]any{"exists": true}
+	}
+	return exactPattern(value)
+}
+
+// withoutVariables removes the fields of a JSON body that hold undefined variables. Bodies are matched as a
+// subset, so a removed field matches any value.
+func withoutVariables(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if s, ok := field.(string); ok && capture.PostmanVariable.MatchString(s) {
+				delete(v, key)
+				continue
+			}
+			v[key] = withoutVariables(field)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = withoutVariables(item)
+		}
+	}
+	return value
+}
+
+// importSlug names a file or directory after a name in the collection, using the fallback for empty names.
+func importSlug(name, fallback string) string {
+	slug := strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if slug == "" {
+		return fallback
+	}
+	return slug
+}
+
+func containsFile(files map[string]string, file string) bool {
+	for _, f := range files {
+		if f == file {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package staticMock
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pb33f/wiretap/daemon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const petCollection = `{
+  "info": {"name": "pets", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+  "variable": [{"key": "baseUrl", "value": "https://api.pets.com/v1"}],
+  "item": [{
+    "name": "Pets",
+    "item": [{
+      "name": "Admin Tools",
+      "item": [{
+        "name": "Create Pet",
+        "request": {
+          "method": "POST",
+          "url": "{{baseUrl}}/pets?notify=true",
+          "header": [{"key": "Content-Type", "value": "application/json"}, {"key": "Authorization", "value": "Bearer abc"},
+            {"key": "X-Tenant", "value": "{{tenant}}"}],
+          "body": {"mode": "raw", "raw": "{\"name\":\"fido\",\"owner\":\"{{owner}}\"}"}
+        },
+        "response": [{
+          "name": "Created",
+          "code": 201,
+          "header": [{"key": "Content-Type", "value": "application/json"}, {"key": "Content-Length", "value": "9"}],
+          "body": "{\"id\":1}"
+        }]
+      }]
+    }, {
+      "name": "Get Pet",
+      "request": {"method": "GET", "url": "{{host}}/v1/pets/:id"},
+      "response": [{
+        "name": "found",
+        "originalRequest": {"method": "GET", "url": "{{host}}/v1/pets/1"},
+        "code": 200,
+        "body": "{\"name\":\"fido\"}"
+      }, {
+        "name": "not found",
+        "originalRequest": {"method": "GET", "url": "{{host}}/v1/pets/:id"},
+        "code": 404,
+        "body": ""
+      }]
+    }]
+  }, {
+    "name": "no examples",
+    "request": {"method": "GET", "url": "{{baseUrl}}/health"},
+    "response": []
+  }]
+}`
+
+func TestImportPostman(t *testing.T) {
+	imported, err := ImportPostman([]byte(petCollection))
+	require.NoError(t, err)
+	require.Len(t, imported, 3)
+
+	create := imported[0]
+	assert.Equal(t, filepath.Join("pets", "admin-tools", "create-pet.json"), create.File)
+	assert.Equal(t, "pets/admin-tools/create-pet/created", create.Definition.Id)
+	assert.Equal(t, "/v1/pets", create.Definition.Request.UrlPath)
+	assert.Equal(t, map[string]any{"notify": "true"}, *create.Definition.Request.QueryParams)
+	assert.Equal(t, map[string]any{
+		"Content-Type":  "application/json",
+		"Authorization": map[string]any{"exists": true},
+		"X-Tenant":      map[string]any{"exists": true},
+	}, *create.Definition.Request.Header)
+	assert.Equal(t, map[string]any{"name": "fido"}, create.Definition.Request.Body)
+	assert.Equal(t, 201, create.Definition.Response.StatusCode)
+	assert.Equal(t, map[string]any{"Content-Type": "application/json"}, create.Definition.Response.Header)
+
+	assert.Equal(t, filepath.Join("pets", "get-pet.json"), imported[1].File)
+	assert.Equal(t, "/v1/pets/1", imported[1].Definition.Request.UrlPath)
+	assert.Equal(t, `^/v1/pets/[^/]+$`, imported[2].Definition.Request.UrlPath)
+	assert.Equal(t, "pets/get-pet/not-found", imported[2].Definition.Id)
+
+	dir := t.TempDir()
+	written, err := WriteImportedDefinitions(dir, imported)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "mock-definitions", "pets", "admin-tools", "create-pet.json"),
+		filepath.Join(dir, "mock-definitions", "pets", "get-pet.json"),
+	}, written)
+
+	// definitions in subdirectories are loaded, and answer the requests of the examples.
+	sms := &StaticMockService{logger: slog.Default(), state: newMockState(),
+		wiretapService: &daemon.WiretapService{StaticMockDir: dir}}
+	definitions, errs, _ := loadStaticMockRequestsAndResponses(sms.wiretapService, slog.Default())
+	require.Empty(t, errs)
+	require.Len(t, definitions, 3)
+	sms.mockDefinitions = definitions
+
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/v1/pets/2", nil)
+	matched := sms.checkStaticMockExists(req)
+	require.NotNil(t, matched)
+	assert.Equal(t, 404, matched.Response.StatusCode)
+
+	req, _ = http.NewRequest(http.MethodPost, "http://localhost/v1/pets?notify=true",
+		strings.NewReader(`{"name":"fido","owner":"sam"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer xyz")
+	req.Header.Set("X-Tenant", "acme")
+	matched = sms.checkStaticMockExists(req)
+	require.NotNil(t, matched)
+	assert.Equal(t, `{"id":1}`, sms.getBodyFromMockDefinition(*matched, req))
+}
+
+func TestImportPostman_Variables(t *testing.T) {
+	imported, err := ImportPostman([]byte(`{
+  "info": {"name": "pets", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+  "item": [{
+    "name": "List Pets",
+    "request": {"method": "GET", "url": "{{baseUrl}}/pets"},
+    "response": [{
+      "name": "ok",
+      "code": 200,
+      "header": [{"key": "X-Request-Id", "value": "{{$randomInt}}"}],
+      "body": "{\"next\":\"{{baseUrl}}/pets?page=2\"}"
+    }]
+  }]
+}`))
+	require.NoError(t, err)
+	dir := t.TempDir()
+	written, err := WriteImportedDefinitions(dir, imported)
+	require.NoError(t, err)
+	require.Len(t, written, 1)
+
+	definitions, errs := loadMockDefinitionFile(written[0])
+	require.Empty(t, errs)
+	require.Len(t, definitions, 1)
+
+	sms := &StaticMockService{logger: slog.Default(), state: newMockState(),
+		wiretapService: &daemon.WiretapService{StaticMockDir: dir}}
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/pets", nil)
+	resp := sms.getStaticMockResponse(definitions[0], req)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, `{"next":"{{baseUrl}}/pets?page=2"}`, string(body))
+	assert.Equal(t, "{{$randomInt}}", resp.Header.Get("X-Request-Id"))
+}
+
+func TestImportPostman_Insomnia(t *testing.T) {
+	_, err := ImportPostman([]byte(`{"_type": "export", "__export_format": 4, "resources": []}`))
+	assert.ErrorContains(t, err, "insomnia exports do not contain saved responses")
+}
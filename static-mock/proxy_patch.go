@@ -0,0 +1,108 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package staticMock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pb33f/wiretap/daemon"
+	"github.com/pb33f/wiretap/shared"
+)
+
+// StaticMockProxyPatch forwards a matched request to the API, and changes the response it returns. The body is
+// patched with a JSON Patch (RFC 6902), then a JSON Merge Patch (RFC 7396), then the headers and status code are
+// overridden.
+type StaticMockProxyPatch struct {
+	StatusCode int                         `json:"statusCode,omitempty"`
+	Patch      []shared.JSONPatchOperation `json:"patch,omitempty"`
+	MergePatch any                         `json:"mergePatch,omitempty"`
+	// Header values replace the headers of the response, they are rendered as templates when Template is set.
+	Header        map[string]any `json:"header,omitempty"`
+	RemoveHeaders []string       `json:"removeHeaders,omitempty"`
+	Template      bool           `json:"template,omitempty"`
+}
+
+// patchesBody checks if the body of the response needs to be decoded.
+func (p *StaticMockProxyPatch) patchesBody() bool {
+	return len(p.Patch) > 0 || p.MergePatch != nil
+}
+
+// proxyPatchModifier returns a modifier that applies the proxy patch of a definition to the response of the API.
+func proxyPatchModifier(definition StaticMockDefinition, request *http.Request) daemon.ResponseModifier {
+	patch := definition.Proxy
+	return func(response *http.Response) (*http.Response, error) {
+		if patch.patchesBody() {
+			if err := patchResponseBody(response, patch); err != nil {
+				return nil, fmt.Errorf("unable to patch response for static mock '%s': %w", definition.Id, err)
+			}
+		}
+		for _, name := range patch.RemoveHeaders {
+			response.Header.Del(name)
+		}
+		for name, value := range patch.Header {
+			rendered := fmt.Sprint(value)
+			if patch.Template {
+				var err error
+				if rendered, err = renderTemplate(rendered, request); err != nil {
+					return nil, fmt.Errorf("unable to render header '%s' for static mock '%s': %w", name,
+						definition.Id, err)
+				}
+			}
+			response.Header.Set(name, rendered)
+		}
+		if patch.StatusCode > 0 {
+			response.StatusCode = patch.StatusCode
+			response.Status = fmt.Sprintf("%d %s", patch.StatusCode, http.StatusText(patch.StatusCode))
+		}
+		return response, nil
+	}
+}
+
+// patchResponseBody decodes the JSON body of a response, decompressing it if needed, and replaces it with the
+// patched body.
+func patchResponseBody(response *http.Response, patch *StaticMockProxyPatch) error {
+	body, err := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(response.Header.Get("Content-Encoding")) {
+	case "", "identity":
+	case "gzip":
+		if body, err = gunzip(body); err != nil {
+			return fmt.Errorf("unable to decompress response: %w", err)
+		}
+		response.Header.Del("Content-Encoding")
+	default:
+		return fmt.Errorf("unsupported response encoding '%s'", response.Header.Get("Content-Encoding"))
+	}
+
+	var document any
+	if err = json.Unmarshal(body, &document); err != nil {
+		return fmt.Errorf("response body is not JSON: %w", err)
+	}
+	if len(patch.Patch) > 0 {
+		if document, err = shared.ApplyJSONPatch(document, patch.Patch); err != nil {
+			return err
+		}
+	}
+	if patch.MergePatch != nil {
+		document = shared.ApplyMergePatch(document, patch.MergePatch)
+	}
+	if body, err = json.Marshal(document); err != nil {
+		return err
+	}
+	response.Body = io.NopCloser(bytes.NewReader(body))
+	response.ContentLength = int64(len(body))
+	response.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return nil
+}
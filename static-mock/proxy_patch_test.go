@@ -0,0 +1,101 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package staticMock
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pb33f/wiretap/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMockDefinitionFile_Proxy(t *testing.T) {
+	path := writeDefinition(t, "proxy.yaml", `- id: rename-pet
+  request:
+    method: GET
+    urlPath: /pets/1
+  proxy:
+    statusCode: 203
+    patch:
+      - op: replace
+        path: /name
+        value: rex
+    mergePatch:
+      owner: null
+    header:
+      X-Patched: "{{ .Method }}"
+    template: true
+- request:
+    method: GET
+  response:
+    statusCode: 200
+  proxy:
+    statusCode: 200
+- request:
+    method: GET
+  proxy:
+    patch:
+      - op: rename
+        path: /name`)
+
+	definitions, errs := loadMockDefinitionFile(path)
+	require.Len(t, definitions, 1)
+	require.Len(t, errs, 2)
+	assert.Equal(t, 1, errs[0].Index)
+	assert.Equal(t, 2, errs[1].Index)
+	assert.Equal(t, 203, definitions[0].Proxy.StatusCode)
+	assert.Equal(t, "/name", definitions[0].Proxy.Patch[0].Path)
+}
+
+func TestProxyPatchModifier(t *testing.T) {
+	definition := StaticMockDefinition{Id: "rename-pet", Proxy: &StaticMockProxyPatch{
+		StatusCode: 203,
+		Patch: []shared.JSONPatchOperation{
+			{Op: "test", Path: "/name", Value: "fido"},
+			{Op: "replace", Path: "/name", Value: "rex"},
+			{Op: "add", Path: "/toys/-", Value: "ball"},
+			{Op: "remove", Path: "/toys/0"},
+			{Op: "copy", From: "/name", Path: "/nickname"},
+			{Op: "move", From: "/age", Path: "/years"},
+		},
+		MergePatch:    map[string]any{"owner": nil, "tags": map[string]any{"good": true}},
+		Header:        map[string]any{"X-Patched": "{{ .Method }} {{ .Path }}"},
+		RemoveHeaders: []string{"X-Upstream"},
+		Template:      true,
+	}}
+	request, _ := http.NewRequest(http.MethodGet, "http://localhost/pets/1", nil)
+
+	body := `{"name":"fido","age":3,"owner":"ada","toys":["bone"]}`
+	response := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{"X-Upstream": {"1"}, "Content-Encoding": {"gzip"}},
+		Body:       io.NopCloser(bytes.NewReader(gzipBytes([]byte(body)))),
+	}
+	patched, err := proxyPatchModifier(definition, request)(response)
+	require.NoError(t, err)
+
+	out, _ := io.ReadAll(patched.Body)
+	assert.JSONEq(t, `{"name":"rex","nickname":"rex","years":3,"toys":["ball"],"tags":{"good":true}}`, string(out))
+	assert.Equal(t, 203, patched.StatusCode)
+	assert.Equal(t, "GET /pets/1", patched.Header.Get("X-Patched"))
+	assert.Empty(t, patched.Header.Get("X-Upstream"))
+	assert.Empty(t, patched.Header.Get("Content-Encoding"))
+	assert.Equal(t, int64(len(out)), patched.ContentLength)
+
+	response = &http.Response{StatusCode: 200, Header: http.Header{},
+		Body: io.NopCloser(strings.NewReader(`{"name":"rex"}`))}
+	_, err = proxyPatchModifier(definition, request)(response)
+	assert.ErrorContains(t, err, "patch operation 0 (test '/name') failed")
+
+	response = &http.Response{StatusCode: 200, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("<pet/>"))}
+	_, err = proxyPatchModifier(definition, request)(response)
+	assert.ErrorContains(t, err, "response body is not JSON")
+}
@@ -0,0 +1,366 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package staticMock
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pb33f/wiretap/daemon"
+	"github.com/pb33f/wiretap/shared"
+)
+
+const (
+	// RecordedPrefix is the prefix of every file written by record mode.
+	RecordedPrefix = "recorded-"
+	// Redacted replaces the value of every redacted header and JSON field.
+	Redacted = "REDACTED"
+	// RecordedFilesPath is the directory, in the static mock directory, large non-JSON bodies are recorded into.
+	RecordedFilesPath = "/files/"
+	// defaultBodyFileThreshold is the size, in bytes, above which recorded response bodies get their own file.
+	defaultBodyFileThreshold = 4096
+)
+
+// defaultRedactions are headers, query parameters and JSON fields that are never written as recorded.
+var defaultRedactions = []string{
+	"authorization", "proxy-authorization", "cookie", "set-cookie", "x-api-key", "api-key", "api_key", "apikey",
+	"password", "secret", "client_secret", "token", "access_token", "refresh_token", "id_token",
+}
+
+// recordSkipHeaders are response headers that describe the recorded connection, rather than the response.
+var recordSkipHeaders = []string{
+	"Content-Length", "Content-Encoding", "Transfer-Encoding", "Connection", "Keep-Alive", "Date",
+}
+
+var slugPattern = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// Recorder writes every transaction proxied to the API into the static mock directory as a mock definition, so
+// the API can be replayed offline. Transactions with the same request matchers are only recorded once. Recorded
+// definitions are not loaded while recording, so every request reaches the API.
+type Recorder struct {
+	config   *shared.WiretapRecordConfig
+	mockDir  string
+	logger   *slog.Logger
+	redact   redactions
+	headers  []string
+	lock     sync.Mutex
+	recorded map[string]bool
+}
+
+// NewRecorder creates a recorder that writes into the static mock directory.
+func NewRecorder(staticMockDir string, config *shared.WiretapRecordConfig, logger *slog.Logger) *Recorder {
+	if config == nil {
+		config = &shared.WiretapRecordConfig{Enabled: true}
+	}
+	var headers []string
+	for _, h := range config.Headers {
+		headers = append(headers, http.CanonicalHeaderKey(h))
+	}
+	return &Recorder{
+		config:   config,
+		mockDir:  staticMockDir,
+		logger:   logger,
+		redact:   newRedactions(config.Redact),
+		headers:  headers,
+		recorded: make(map[string]bool),
+	}
+}
+
+// ObserveTransaction records a proxied transaction as a mock definition.
+func (r *Recorder) ObserveTransaction(transaction *daemon.ObservedTransaction) {
+	definition, responseBody, err := r.buildDefinition(transaction)
+	if err != nil {
+		r.logger.Warn("unable to record transaction", "url", transaction.Request.URL.String(), "error", err.Error())
+		return
+	}
+	name, err := recordedName(definition)
+	if err != nil {
+		r.logger.Warn("unable to record transaction", "url", transaction.Request.URL.String(), "error", err.Error())
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	definitionPath := filepath.Join(r.mockDir+MockDefinitionsPath, name+".json")
+	if r.recorded[name] {
+		return
+	}
+	if _, statErr := os.Stat(definitionPath); statErr == nil {
+		r.recorded[name] = true
+		return
+	}
+
+	threshold := r.config.BodyFileThreshold
+	if threshold <= 0 {
+		threshold = defaultBodyFileThreshold
+	}
+	if err = r.writeResponseBody(&definition.Response, name, responseBody, threshold); err != nil {
+		r.logger.Error("unable to write recorded body", "name", name, "error", err.Error())
+		return
+	}
+
+	out, _ := json.MarshalIndent(definition, "", "  ")
+	if err = os.MkdirAll(r.mockDir+MockDefinitionsPath, 0755); err == nil {
+		err = os.WriteFile(definitionPath, out, 0644)
+	}
+	if err != nil {
+		r.logger.Error("unable to write recorded mock definition", "file", definitionPath, "error", err.Error())
+		return
+	}
+	r.recorded[name] = true
+	r.logger.Info("recorded mock definition", "file", definitionPath)
+}
+
+// writeResponseBody sets the body of a recorded response. JSON bodies are written inline, or into the body JSON
+// directory when they are larger than the threshold. Any other body is served as-is, so it is base64 encoded, or
+// written into the files directory when it is larger than the threshold.
+func (r *Recorder) writeResponseBody(response *StaticMockDefinitionResponse, name string, body []byte,
+	threshold int) error {
+
+	isJSON := json.Valid(body)
+	switch {
+	case len(body) <= threshold && isJSON:
+		response.Body = string(body)
+	case len(body) <= threshold:
+		if len(body) > 0 {
+			response.BodyBase64 = base64.StdEncoding.EncodeToString(body)
+		}
+	case isJSON:
+		if err := os.MkdirAll(r.mockDir+MockBodyJsonsPath, 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(r.mockDir+MockBodyJsonsPath+name+".json", body, 0644); err != nil {
+			return err
+		}
+		response.BodyJsonFilename = name + ".json"
+	default:
+		bodyFile := name + recordedExtension(fmt.Sprint(response.Header["Content-Type"]))
+		if err := os.MkdirAll(r.mockDir+RecordedFilesPath, 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(r.mockDir+RecordedFilesPath+bodyFile, body, 0644); err != nil {
+			return err
+		}
+		response.BodyFile = strings.TrimPrefix(RecordedFilesPath, "/") + bodyFile
+	}
+	return nil
+}
+
+// recordedExtension returns the file extension of a content type, or `.body` if it has none.
+func recordedExtension(contentType string) string {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		if extensions, _ := mime.ExtensionsByType(mediaType); len(extensions) > 0 {
+			return extensions[0]
+		}
+	}
+	return ".body"
+}
+
+// isRecordedFile checks if a file was written by record mode.
+func isRecordedFile(path string) bool {
+	return strings.HasPrefix(filepath.Base(path), RecordedPrefix)
+}
+
+// buildDefinition creates the mock definition for a transaction, with secrets redacted. The response body is
+// returned separately, so it can be written inline or into its own file.
+func (r *Recorder) buildDefinition(transaction *daemon.ObservedTransaction) (StaticMockDefinition, []byte, error) {
+	req := transaction.Request
+	request := StaticMockDefinitionRequest{
+		Method:  req.Method,
+		UrlPath: exactPattern(req.URL.Path),
+	}
+
+	if len(r.headers) > 0 {
+		header := make(map[string]any)
+		for _, h := range r.headers {
+			if value := req.Header.Get(h); value != "" {
+				header[h] = r.matcherFor(h, value)
+			}
+		}
+		if len(header) > 0 {
+			request.Header = &header
+		}
+	}
+
+	query := req.URL.Query()
+	if len(query) > 0 {
+		queryParams := make(map[string]any)
+		for key, values := range query {
+			if len(r.config.QueryParams) > 0 && !containsFold(r.config.QueryParams, key) {
+				continue
+			}
+			if r.redact.has(key) {
+				queryParams[key] = map[string]any{"exists": true}
+				continue
+			}
+			var matchers []any
+			for _, v := range values {
+				matchers = append(matchers, exactPattern(v))
+			}
+			if len(matchers) == 1 {
+				queryParams[key] = matchers[0]
+			} else {
+				queryParams[key] = matchers
+			}
+		}
+		if len(queryParams) > 0 {
+			request.QueryParams = &queryParams
+		}
+	}
+
+	if r.config.MatchBody && len(transaction.RequestBody) > 0 {
+		var body any
+		_ = json.Unmarshal(transaction.RequestBody, &body)
+		switch body.(type) {
+		case map[string]any, []any:
+			request.Body = r.redact.redactJSON(body, true)
+		default:
+			request.Body = string(transaction.RequestBody)
+		}
+	}
+
+	responseBody := transaction.ResponseBody
+	encoding := strings.ToLower(transaction.ResponseHeader.Get("Content-Encoding"))
+	switch encoding {
+	case "", "identity":
+	case "gzip":
+		reader, err := gzip.NewReader(bytes.NewReader(responseBody))
+		if err != nil {
+			return StaticMockDefinition{}, nil, fmt.Errorf("unable to decompress response: %w", err)
+		}
+		if responseBody, err = io.ReadAll(reader); err != nil {
+			return StaticMockDefinition{}, nil, fmt.Errorf("unable to decompress response: %w", err)
+		}
+	default:
+		return StaticMockDefinition{}, nil, fmt.Errorf("unsupported response encoding '%s'", encoding)
+	}
+
+	var decoded any
+	if json.Unmarshal(responseBody, &decoded) == nil {
+		responseBody, _ = json.Marshal(r.redact.redactJSON(decoded, false))
+	}
+
+	responseHeader := make(map[string]any)
+	for key, values := range transaction.ResponseHeader {
+		if containsFold(recordSkipHeaders, key) || strings.HasPrefix(key, "Access-Control-") {
+			continue
+		}
+		if r.redact.has(key) {
+			responseHeader[key] = Redacted
+		} else {
+			responseHeader[key] = strings.Join(values, ", ")
+		}
+	}
+
+	return StaticMockDefinition{
+		Request: request,
+		Response: StaticMockDefinitionResponse{
+			StatusCode: transaction.StatusCode,
+			Header:     responseHeader,
+		},
+	}, responseBody, nil
+}
+
+// matcherFor returns an exact matcher for a header value, or a presence matcher if the header is redacted.
+func (r *Recorder) matcherFor(key, value string) any {
+	if r.redact.has(key) {
+		return map[string]any{"exists": true}
+	}
+	return exactPattern(value)
+}
+
+// exactPattern returns a value that only matches itself. Definitions treat values containing regex characters as
+// patterns, so those are escaped and anchored.
+func exactPattern(value string) string {
+	if regexp.QuoteMeta(value) == value {
+		return value
+	}
+	return "^" + regexp.QuoteMeta(value) + "$"
+}
+
+// redactions are the lower-cased names of headers, query parameters and JSON fields whose values are secret.
+type redactions map[string]bool
+
+// newRedactions adds the names to the default redactions.
+func newRedactions(names []string) redactions {
+	rs := make(redactions)
+	for _, name := range append(append([]string{}, defaultRedactions...), names...) {
+		rs[strings.ToLower(name)] = true
+	}
+	return rs
+}
+
+func (rs redactions) has(name string) bool {
+	return rs[strings.ToLower(name)]
+}
+
+// redactJSON replaces redacted fields of a JSON value. Request bodies are matched as a subset, so redacted fields
+// are removed from them instead, and their strings are made exact.
+func (rs redactions) redactJSON(value any, remove bool) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if rs.has(key) {
+				if remove {
+					delete(v, key)
+				} else {
+					v[key] = Redacted
+				}
+				continue
+			}
+			v[key] = rs.redactJSON(field, remove)
+		}
+	case []any:
+		for i := range v {
+			v[i] = rs.redactJSON(v[i], remove)
+		}
+	case string:
+		if remove {
+			return exactPattern(v)
+		}
+	}
+	return value
+}
+
+// recordedName names the files of a recorded definition after its method, path and a hash of its request
+// matchers, so a request that has already been recorded maps to the same file.
+func recordedName(definition StaticMockDefinition) (string, error) {
+	matchers, err := json.Marshal(definition.Request)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(matchers)
+	path := strings.Trim(slugPattern.ReplaceAllString(definition.Request.UrlPath, "-"), "-")
+	if len(path) > 64 {
+		path = path[:64]
+	}
+	return fmt.Sprintf("%s%s-%s-%s", RecordedPrefix, strings.ToLower(definition.Request.Method), path,
+		hex.EncodeToString(sum[:4])), nil
+}
+
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package staticMock
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pb33f/wiretap/daemon"
+	"github.com/pb33f/wiretap/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func observe(r *Recorder, method, url, requestBody string, status int, header http.Header, body []byte) {
+	req, _ := http.NewRequest(method, url, strings.NewReader(requestBody))
+	req.Header.Set("Authorization", "Bearer abc")
+	req.Header.Set("X-Tenant", "acme.io")
+	req.Header.Set("Content-Type", "application/json")
+	r.ObserveTransaction(&daemon.ObservedTransaction{
+		Request:        req,
+		RequestBody:    []byte(requestBody),
+		StatusCode:     status,
+		ResponseHeader: header,
+		ResponseBody:   body,
+	})
+}
+
+func TestRecorder_ObserveTransaction(t *testing.T) {
+	dir := t.TempDir()
+	recorder := NewRecorder(dir, &shared.WiretapRecordConfig{
+		Enabled:           true,
+		Headers:           []string{"x-tenant", "authorization"},
+		QueryParams:       []string{"limit", "api_key"},
+		MatchBody:         true,
+		Redact:            []string{"ssn"},
+		BodyFileThreshold: 64,
+	}, slog.Default())
+
+	header := http.Header{"Content-Type": {"application/json"}, "Set-Cookie": {"session=1"},
+		"Content-Length": {"42"}}
+	observe(recorder, http.MethodPost, "http://api.io/pets?limit=5&api_key=k&ignored=1",
+		`{"name": "fido", "password": "hunter2"}`, 201, header,
+		[]byte(`{"id": 1, "ssn": "123", "token": "t"}`))
+
+	// the same request is only recorded once.
+	observe(recorder, http.MethodPost, "http://api.io/pets?limit=5&api_key=k&ignored=2",
+		`{"name": "fido", "password": "other"}`, 500, header, []byte(`{}`))
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write([]byte(`[` + strings.Repeat(`{"name": "fido"},`, 10) + `{"name": "rex"}]`))
+	_ = zw.Close()
+	observe(recorder, http.MethodGet, "http://api.io/pets", "", 200,
+		http.Header{"Content-Type": {"application/json"}, "Content-Encoding": {"gzip"}}, gz.Bytes())
+
+	files, _ := filepath.Glob(filepath.Join(dir, "mock-definitions", RecordedPrefix+"*.json"))
+	require.Len(t, files, 2)
+	bodies, _ := filepath.Glob(filepath.Join(dir, "body-jsons", RecordedPrefix+"get-pets-*.json"))
+	require.Len(t, bodies, 1)
+
+	sms := &StaticMockService{logger: slog.Default(), state: newMockState(),
+		wiretapService: &daemon.WiretapService{StaticMockDir: dir}}
+	definitions, errs, _ := loadStaticMockRequestsAndResponses(sms.wiretapService, slog.Default())
+	require.Empty(t, errs)
+	require.Len(t, definitions, 2)
+	sms.mockDefinitions = definitions
+
+	var post StaticMockDefinition
+	for _, d := range definitions {
+		if d.Request.Method == http.MethodPost {
+			post = d
+		}
+	}
+	assert.Equal(t, "/pets", post.Request.UrlPath)
+	assert.Equal(t, map[string]any{"exists": true}, (*post.Request.Header)["Authorization"])
+	assert.Equal(t, `^acme\.io$`, (*post.Request.Header)["X-Tenant"])
+	assert.Equal(t, map[string]any{"limit": "5", "api_key": map[string]any{"exists": true}}, *post.Request.QueryParams)
+	assert.Equal(t, map[string]any{"name": "fido"}, post.Request.Body)
+	assert.Equal(t, 201, post.Response.StatusCode)
+	assert.Equal(t, `{"id":1,"ssn":"REDACTED","token":"REDACTED"}`, post.Response.Body)
+	assert.Equal(t, Redacted, post.Response.Header["Set-Cookie"])
+	assert.NotContains(t, post.Response.Header, "Content-Length")
+
+	// recorded definitions replay the traffic they were recorded from.
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/pets", nil)
+	req.Header.Set("X-Tenant", "acme.io")
+	assert.Nil(t, sms.checkStaticMockExists(req))
+	req.Header.Set("Authorization", "Bearer xyz")
+	matched := sms.checkStaticMockExists(req)
+	require.NotNil(t, matched)
+	body := sms.getBodyFromMockDefinition(*matched, req)
+	assert.Contains(t, body, `"rex"`)
+}
+
+func TestRecorder_ObserveTransaction_NonJSON(t *testing.T) {
+	dir := t.TempDir()
+	recorder := NewRecorder(dir, &shared.WiretapRecordConfig{Enabled: true, BodyFileThreshold: 16}, slog.Default())
+
+	pixel := []byte{0x89, 'P', 'N', 'G', 0x0d, 0x0a, 0x1a, 0x0a, 0xff, 0x00}
+	observe(recorder, http.MethodGet, "http://api.io/pixel", "", 200, http.Header{"Content-Type": {"image/png"}}, pixel)
+	photo := append(append([]byte{}, pixel...), bytes.Repeat([]byte{0xfe}, 32)...)
+	observe(recorder, http.MethodGet, "http://api.io/photo", "", 200, http.Header{"Content-Type": {"image/png"}}, photo)
+	observe(recorder, http.MethodGet, "http://api.io/greeting", "", 200,
+		http.Header{"Content-Type": {"application/json"}}, []byte(`"{{name}}"`))
+
+	files, _ := filepath.Glob(filepath.Join(dir, "files", RecordedPrefix+"get-photo-*.png"))
+	require.Len(t, files, 1)
+
+	// recorded definitions are not loaded while recording, so the requests keep reaching the API.
+	wiretapService := &daemon.WiretapService{StaticMockDir: dir, StaticMockRecord: true}
+	definitions, errs, _ := loadStaticMockRequestsAndResponses(wiretapService, slog.Default())
+	require.Empty(t, errs)
+	assert.Empty(t, definitions)
+
+	wiretapService.StaticMockRecord = false
+	definitions, errs, _ = loadStaticMockRequestsAndResponses(wiretapService, slog.Default())
+	require.Empty(t, errs)
+	require.Len(t, definitions, 3)
+	sms := &StaticMockService{logger: slog.Default(), state: newMockState(), wiretapService: wiretapService,
+		mockDefinitions: definitions}
+
+	for path, expected := range map[string][]byte{"/pixel": pixel, "/photo": photo, "/greeting": []byte(`"{{name}}"`)} {
+		req, _ := http.NewRequest(http.MethodGet, "http://localhost"+path, nil)
+		matched := sms.checkStaticMockExists(req)
+		require.NotNil(t, matched, path)
+		resp := sms.getStaticMockResponse(*matched, req)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, expected, body, path)
+	}
+}
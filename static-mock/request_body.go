@@ -0,0 +1,135 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package staticMock
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxMultipartMemory is the most memory used to decode a multipart body, larger parts are measured and discarded.
+const maxMultipartMemory = 10 << 20
+
+// requestMediaType returns the media type of a request, without parameters such as charset or boundary.
+func requestMediaType(request *http.Request) (string, map[string]string) {
+	contentType := request.Header.Get("Content-Type")
+	if contentType == "" {
+		return "", nil
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0])), nil
+	}
+	return mediaType, params
+}
+
+// isJSONMediaType checks for application/json, or any type with a +json suffix, such as application/problem+json.
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// isXMLMediaType checks for application/xml, text/xml, or any type with a +xml suffix, such as application/soap+xml.
+func isXMLMediaType(mediaType string) bool {
+	return mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
+
+// isFormMediaType checks for url encoded and multipart form bodies.
+func isFormMediaType(mediaType string) bool {
+	return mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data"
+}
+
+// decodeRequestBody decodes the body of a request by its content type. JSON is decoded as JSON, forms are decoded
+// into an object of fields, anything else (or a body that can't be decoded) is returned as a string. An empty body
+// is returned as nil. It never fails, a body that can't be read is treated as empty.
+func decodeRequestBody(request *http.Request) any {
+	body := readRequestBody(request)
+	if len(body) == 0 {
+		return nil
+	}
+	mediaType, params := requestMediaType(request)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			return formFields(values)
+		}
+	case mediaType == "multipart/form-data":
+		if fields, err := decodeMultipart(body, params["boundary"]); err == nil {
+			return fields
+		}
+	case mediaType == "" || isJSONMediaType(mediaType):
+		var decoded any
+		if json.Unmarshal(body, &decoded) == nil {
+			return decoded
+		}
+	}
+	return string(body)
+}
+
+// formFields converts form values into an object. Fields with a single value are strings, repeated fields are arrays.
+func formFields(values url.Values) map[string]any {
+	fields := make(map[string]any, len(values))
+	for key, v := range values {
+		if len(v) == 1 {
+			fields[key] = v[0]
+			continue
+		}
+		list := make([]any, len(v))
+		for i := range v {
+			list[i] = v[i]
+		}
+		fields[key] = list
+	}
+	return fields
+}
+
+// decodeMultipart decodes a multipart form body into an object of fields. File parts are described by their
+// filename, content type and size.
+func decodeMultipart(body []byte, boundary string) (map[string]any, error) {
+	if boundary == "" {
+		return nil, errors.New("multipart body has no boundary")
+	}
+	form, err := multipart.NewReader(bytes.NewReader(body), boundary).ReadForm(maxMultipartMemory)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = form.RemoveAll() }()
+
+	fields := formFields(form.Value)
+	for key, files := range form.File {
+		var described []any
+		for _, f := range files {
+			described = append(described, map[string]any{
+				"filename":    f.Filename,
+				"contentType": f.Header.Get("Content-Type"),
+				"size":        f.Size,
+			})
+		}
+		if len(described) == 1 {
+			fields[key] = described[0]
+		} else {
+			fields[key] = described
+		}
+	}
+	return fields, nil
+}
+
+// bodyAsJSON returns the body of a request as JSON, so JSONPath can be used on form bodies as well as JSON ones.
+func bodyAsJSON(request *http.Request) []byte {
+	mediaType, _ := requestMediaType(request)
+	if isFormMediaType(mediaType) {
+		if decoded, ok := decodeRequestBody(request).(map[string]any); ok {
+			b, _ := json.Marshal(decoded)
+			return b
+		}
+	}
+	return readRequestBody(request)
+}
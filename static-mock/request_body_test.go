@@ -0,0 +1,113 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package staticMock
+
+import (
+	"bytes"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBodyRequest(contentType, body string) *http.Request {
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost/pets", strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req
+}
+
+func TestStaticMockService_MatchRequestBodies(t *testing.T) {
+	sms := &StaticMockService{logger: slog.Default(), state: newMockState()}
+	object := StaticMockDefinitionRequest{Method: "POST", Body: map[string]any{"name": "fido"}}
+
+	assert.True(t, sms.isRequestMatch(object, newBodyRequest("application/json; charset=utf-8", `{"name":"fido","age":2}`)))
+	assert.True(t, sms.isRequestMatch(object, newBodyRequest("application/merge-patch+json", `{"name":"fido"}`)))
+	assert.True(t, sms.isRequestMatch(object, newBodyRequest("application/x-www-form-urlencoded", "name=fido&age=2")))
+	assert.False(t, sms.isRequestMatch(object, newBodyRequest("application/json", `{"name":`)))
+	assert.False(t, sms.isRequestMatch(object, newBodyRequest("text/plain", `{"name":"fido"}`)))
+	assert.False(t, sms.isRequestMatch(object, newBodyRequest("application/json", "")))
+
+	text := StaticMockDefinitionRequest{Method: "POST", Body: "hello.*"}
+	assert.True(t, sms.isRequestMatch(text, newBodyRequest("text/plain", "hello world")))
+	assert.False(t, sms.isRequestMatch(text, newBodyRequest("text/plain", "goodbye")))
+
+	form := StaticMockDefinitionRequest{Method: "POST", BodyMatchers: map[string]any{
+		"$.tag[*]": "b",
+		"$.name":   map[string]any{"regex": "^fi"},
+	}}
+	assert.True(t, sms.isRequestMatch(form, newBodyRequest("application/x-www-form-urlencoded", "name=fido&tag=a&tag=b")))
+	assert.False(t, sms.isRequestMatch(form, newBodyRequest("application/x-www-form-urlencoded", "name=rex&tag=b")))
+}
+
+func TestStaticMockService_MatchMultipartBody(t *testing.T) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	require.NoError(t, writer.WriteField("name", "fido"))
+	part, err := writer.CreateFormFile("photo", "fido.png")
+	require.NoError(t, err)
+	_, _ = part.Write([]byte("not really a png"))
+	require.NoError(t, writer.Close())
+
+	sms := &StaticMockService{logger: slog.Default(), state: newMockState()}
+	mock := StaticMockDefinitionRequest{Method: "POST", BodyMatchers: map[string]any{
+		"$.name":           "fido",
+		"$.photo.filename": map[string]any{"regex": "\\.png$"},
+		"$.photo.size":     map[string]any{"gt": 0},
+	}}
+	assert.True(t, sms.isRequestMatch(mock, newBodyRequest(writer.FormDataContentType(), buf.String())))
+	assert.False(t, sms.isRequestMatch(mock, newBodyRequest("multipart/form-data", buf.String())))
+}
+
+func TestStaticMockService_MatchXPath(t *testing.T) {
+	body := `<?xml version="1.0"?>
+<ns:order xmlns:ns="urn:orders" id="42">
+  <customer><name>Ada</name></customer>
+  <item sku="abc"><quantity>3</quantity></item>
+  <item sku="def"><quantity>1</quantity></item>
+</ns:order>`
+
+	sms := &StaticMockService{logger: slog.Default(), state: newMockState()}
+	mock := StaticMockDefinitionRequest{Method: "POST", XPath: map[string]any{
+		"/order/@id":                  map[string]any{"regex": "^\\d+$"},
+		"//item[@sku='abc']/quantity": map[string]any{"gte": 2},
+		"/order/customer/name":        "Ada",
+		"/order/item[2]/@sku":         "def",
+		"/order/*[name='Ada']/name":   map[string]any{"exists": true},
+		"/order/missing":              map[string]any{"absent": true},
+	}}
+	assert.True(t, sms.isRequestMatch(mock, newBodyRequest("application/xml", body)))
+
+	reasons := sms.evaluateRequestMatch(mock, newBodyRequest("text/xml", strings.Replace(body, "Ada", "Grace", 1)), false)
+	assert.Equal(t, []string{
+		"xpath '/order/*[name='Ada']/name' does not match",
+		"xpath '/order/customer/name' does not match",
+	}, reasons)
+
+	assert.False(t, sms.isRequestMatch(mock, newBodyRequest("application/xml", "not xml")))
+}
+
+func TestParseXPath_Errors(t *testing.T) {
+	for _, expression := range []string{"order", "/order/", "/order[", "/order/@id/name", "/order[name]"} {
+		_, err := parseXPath(expression)
+		assert.Error(t, err, expression)
+	}
+
+	path := writeDefinition(t, "xml.yaml", `request:
+  method: POST
+  xpath:
+    /order[: "1"
+response:
+  statusCode: 200`)
+	_, errs := loadMockDefinitionFile(path)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "invalid xpath")
+}
@@ -0,0 +1,155 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package staticMock
+
+import (
+	"math/rand"
+	"sync"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pb33f/ranch/model"
+	"github.com/pb33f/ranch/service"
+)
+
+const (
+	ResetStaticMockState = "reset-static-mock-state"
+
+	// ScenarioStarted is the state every scenario is in until a definition moves it on.
+	ScenarioStarted = "Started"
+
+	// ResponseModeSequence returns responses in order, repeating the last one once the sequence is exhausted.
+	ResponseModeSequence = "sequence"
+	// ResponseModeCycle returns responses in order, starting again from the first once the sequence is exhausted.
+	ResponseModeCycle = "cycle"
+	// ResponseModeRandom picks a response at random, using the weight of each response.
+	ResponseModeRandom = "random"
+)
+
+// ResetStaticMockStateRequest resets a single scenario, or every scenario and response sequence when empty.
+type ResetStaticMockStateRequest struct {
+	Scenario string `json:"scenario,omitempty"`
+}
+
+// StaticMockStateResponse reports the state of every scenario.
+type StaticMockStateResponse struct {
+	Scenarios map[string]string `json:"scenarios"`
+}
+
+// mockState tracks how many times each definition has been used, and the current state of every scenario.
+type mockState struct {
+	lock      sync.Mutex
+	calls     map[string]int
+	scenarios map[string]string
+}
+
+func newMockState() *mockState {
+	return &mockState{calls: make(map[string]int), scenarios: make(map[string]string)}
+}
+
+// scenarioState returns the current state of a scenario. The lock must be held.
+func (ms *mockState) scenarioState(scenario string) string {
+	if state, ok := ms.scenarios[scenario]; ok {
+		return state
+	}
+	return ScenarioStarted
+}
+
+// inRequiredState checks that the scenario of a definition is in the state it requires. The lock must be held.
+func (ms *mockState) inRequiredState(definition StaticMockDefinition) bool {
+	if definition.Scenario == "" || definition.RequiredState == "" {
+		return true
+	}
+	return ms.scenarioState(definition.Scenario) == definition.RequiredState
+}
+
+// advance records a matched request against a definition, moves its scenario on to the new state and returns
+// the response to use. The lock must be held.
+func (ms *mockState) advance(definition StaticMockDefinition) StaticMockDefinitionResponse {
+	call := ms.calls[definition.Id]
+	ms.calls[definition.Id] = call + 1
+	if definition.Scenario != "" && definition.NewState != "" {
+		ms.scenarios[definition.Scenario] = definition.NewState
+	}
+
+	responses := definition.Responses
+	if len(responses) == 0 {
+		return definition.Response
+	}
+	switch definition.ResponseMode {
+	case ResponseModeCycle:
+		return responses[call%len(responses)]
+	case ResponseModeRandom:
+		return pickWeightedResponse(responses)
+	default:
+		if call >= len(responses) {
+			return responses[len(responses)-1]
+		}
+		return responses[call]
+	}
+}
+
+// reset clears the state of a single scenario, and the sequences of its definitions, or everything if the
+// scenario is empty.
+func (ms *mockState) reset(scenario string, definitions []StaticMockDefinition) {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	if scenario == "" {
+		ms.calls = make(map[string]int)
+		ms.scenarios = make(map[string]string)
+		return
+	}
+	delete(ms.scenarios, scenario)
+	for _, definition := range definitions {
+		if definition.Scenario == scenario {
+			delete(ms.calls, definition.Id)
+		}
+	}
+}
+
+// snapshot returns the state of every scenario used by the definitions.
+func (ms *mockState) snapshot(definitions []StaticMockDefinition) map[string]string {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	scenarios := make(map[string]string)
+	for _, definition := range definitions {
+		if definition.Scenario != "" {
+			scenarios[definition.Scenario] = ms.scenarioState(definition.Scenario)
+		}
+	}
+	return scenarios
+}
+
+// pickWeightedResponse picks a response at random, a response without a weight has a weight of 1.
+func pickWeightedResponse(responses []StaticMockDefinitionResponse) StaticMockDefinitionResponse {
+	weight := func(r StaticMockDefinitionResponse) float64 {
+		if r.Weight <= 0 {
+			return 1
+		}
+		return r.Weight
+	}
+	total := 0.0
+	for _, r := range responses {
+		total += weight(r)
+	}
+	pick := rand.Float64() * total
+	for _, r := range responses {
+		if pick < weight(r) {
+			return r
+		}
+		pick -= weight(r)
+	}
+	return responses[len(responses)-1]
+}
+
+func (sms *StaticMockService) resetStaticMockState(request *model.Request, core service.FabricServiceCore) {
+	var r ResetStaticMockStateRequest
+	if dl, ok := request.Payload.(map[string]interface{}); ok {
+		_ = mapstructure.Decode(dl, &r)
+	}
+	definitions := sms.definitions()
+	sms.state.reset(r.Scenario, definitions)
+	core.SendResponse(request, &StaticMockStateResponse{Scenarios: sms.state.snapshot(definitions)})
+}
@@ -0,0 +1,105 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package staticMock
+
+import (
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func nextStatusCode(t *testing.T, sms *StaticMockService, method, url string) int {
+	req, _ := http.NewRequest(method, url, nil)
+	matched := sms.checkStaticMockExists(req)
+	if matched == nil {
+		return 0
+	}
+	return matched.Response.StatusCode
+}
+
+func TestLoadMockDefinitionFile_Responses(t *testing.T) {
+	path := writeDefinition(t, "jobs.yaml", `- request:
+    method: POST
+  responses:
+    - statusCode: 202
+    - statusCode: 200
+      weight: 2
+  responseMode: cycle
+- request:
+    method: GET
+  responses: []
+- request:
+    method: GET
+  response:
+    statusCode: 200
+  responseMode: forever`)
+
+	definitions, errs := loadMockDefinitionFile(path)
+	require.Len(t, definitions, 1)
+	require.Len(t, definitions[0].Responses, 2)
+	assert.Equal(t, 2.0, definitions[0].Responses[1].Weight)
+	require.Len(t, errs, 2)
+	assert.Equal(t, 1, errs[0].Index)
+	assert.Equal(t, 2, errs[1].Index)
+}
+
+func TestStaticMockService_ResponseModes(t *testing.T) {
+	responses := []StaticMockDefinitionResponse{{StatusCode: 202}, {StatusCode: 200}}
+	sms := &StaticMockService{logger: slog.Default(), state: newMockState(), mockDefinitions: []StaticMockDefinition{
+		{Id: "sequence", Request: StaticMockDefinitionRequest{Method: "GET"}, Responses: responses},
+		{Id: "cycle", Request: StaticMockDefinitionRequest{Method: "POST"}, Responses: responses,
+			ResponseMode: ResponseModeCycle},
+		{Id: "random", Request: StaticMockDefinitionRequest{Method: "PUT"}, ResponseMode: ResponseModeRandom,
+			Responses: []StaticMockDefinitionResponse{{StatusCode: 500, Weight: 0.0001}, {StatusCode: 204, Weight: 1000}}},
+	}}
+
+	var sequence, cycle []int
+	for i := 0; i < 4; i++ {
+		sequence = append(sequence, nextStatusCode(t, sms, "GET", "http://localhost/jobs/1"))
+		cycle = append(cycle, nextStatusCode(t, sms, "POST", "http://localhost/jobs"))
+	}
+	assert.Equal(t, []int{202, 200, 200, 200}, sequence)
+	assert.Equal(t, []int{202, 200, 202, 200}, cycle)
+
+	seen := make(map[int]bool)
+	for i := 0; i < 20; i++ {
+		seen[nextStatusCode(t, sms, "PUT", "http://localhost/jobs/1")] = true
+	}
+	assert.True(t, seen[204])
+
+	sms.state.reset("", sms.mockDefinitions)
+	assert.Equal(t, 202, nextStatusCode(t, sms, "GET", "http://localhost/jobs/1"))
+}
+
+func TestStaticMockService_Scenarios(t *testing.T) {
+	sms := &StaticMockService{logger: slog.Default(), state: newMockState(), mockDefinitions: []StaticMockDefinition{
+		{Id: "empty", Request: StaticMockDefinitionRequest{Method: "GET"}, Scenario: "cart",
+			RequiredState: ScenarioStarted, Response: StaticMockDefinitionResponse{StatusCode: 404}},
+		{Id: "add", Request: StaticMockDefinitionRequest{Method: "POST"}, Scenario: "cart",
+			NewState: "filled", Response: StaticMockDefinitionResponse{StatusCode: 201}},
+		{Id: "filled", Request: StaticMockDefinitionRequest{Method: "GET"}, Scenario: "cart",
+			RequiredState: "filled", Response: StaticMockDefinitionResponse{StatusCode: 200}},
+		{Id: "other", Request: StaticMockDefinitionRequest{Method: "DELETE"}, Scenario: "other",
+			NewState: "gone", Response: StaticMockDefinitionResponse{StatusCode: 204}},
+	}}
+
+	assert.Equal(t, 404, nextStatusCode(t, sms, "GET", "http://localhost/cart"))
+	assert.Equal(t, 201, nextStatusCode(t, sms, "POST", "http://localhost/cart"))
+	assert.Equal(t, 200, nextStatusCode(t, sms, "GET", "http://localhost/cart"))
+	assert.Equal(t, 204, nextStatusCode(t, sms, "DELETE", "http://localhost/other"))
+
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/cart", nil)
+	explanations := sms.ExplainMatch(req)
+	assert.Equal(t, []string{"scenario 'cart' is in state 'filled', requires 'Started'"}, explanations[0].Reasons)
+	assert.True(t, explanations[2].Selected)
+
+	sms.state.reset("cart", sms.mockDefinitions)
+	assert.Equal(t, map[string]string{"cart": ScenarioStarted, "other": "gone"}, sms.state.snapshot(sms.mockDefinitions))
+	assert.Equal(t, 404, nextStatusCode(t, sms, "GET", "http://localhost/cart"))
+}
@@ -7,14 +7,18 @@ package staticMock
 
 import (
 	"encoding/json"
+	"fmt"
+	"io/fs"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/pb33f/ranch/model"
 	"github.com/pb33f/ranch/service"
 	"github.com/pb33f/wiretap/daemon"
+	"gopkg.in/yaml.v3"
 )
 
 const (
@@ -31,6 +35,11 @@ type StaticMockDefinitionRequest struct {
 	Header      *map[string]any `json:"header,omitempty"`
 	Body        interface{}     `json:"body,omitempty"`
 	QueryParams *map[string]any `json:"queryParams,omitempty"`
+	Cookies     *map[string]any `json:"cookies,omitempty"`
+	// BodyMatchers are keyed by JSONPath, e.g. `$.pet.age`
+	BodyMatchers map[string]any `json:"bodyMatchers,omitempty"`
+	// XPath matchers are keyed by XPath, e.g. `/order/item[@sku='abc']/quantity`, for XML bodies
+	XPath map[string]any `json:"xpath,omitempty"`
 }
 
 type StaticMockDefinitionResponse struct {
@@ -38,29 +47,89 @@ type StaticMockDefinitionResponse struct {
 	StatusCode       int            `json:"statusCode,omitempty"`
 	Body             string         `json:"body,omitempty"`
 	BodyJsonFilename string         `json:"bodyJsonFilename,omitempty"`
+	// BodyFile and BodyBase64 are served as-is, without template replacement. BodyFile is relative to the static
+	// mock directory.
+	BodyFile    string `json:"bodyFile,omitempty"`
+	BodyBase64  string `json:"bodyBase64,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	// Gzip encodes the body, if the client accepts it. A body that is already compressed is sent as-is.
+	Gzip bool `json:"gzip,omitempty"`
+	// Weight is used to pick between responses at random, the default weight is 1.
+	Weight float64 `json:"weight,omitempty"`
+	// Template renders the body and header values as Go templates, instead of only replacing `${}` request values.
+	Template bool `json:"template,omitempty"`
 }
 
 type StaticMockDefinition struct {
+	// Id identifies the definition, it defaults to the file name and position of the definition in the file.
+	Id string `json:"id,omitempty"`
+	// Priority orders overlapping definitions, the highest priority is checked first. Definitions with the same
+	// priority are ordered by specificity.
+	Priority int                          `json:"priority,omitempty"`
 	Request  StaticMockDefinitionRequest  `json:"request,omitempty"`
 	Response StaticMockDefinitionResponse `json:"response,omitempty"`
+	// Responses are returned in turn instead of Response, following the ResponseMode (sequence, cycle or random).
+	Responses    []StaticMockDefinitionResponse `json:"responses,omitempty"`
+	ResponseMode string                         `json:"responseMode,omitempty"`
+	// Proxy forwards the request to the API instead of returning a response, and patches the response of the API.
+	Proxy *StaticMockProxyPatch `json:"proxy,omitempty"`
+	// Scenario names a state machine. When RequiredState is set, the definition only matches while the scenario is
+	// in that state. When the definition matches, the scenario moves to NewState.
+	Scenario      string `json:"scenario,omitempty"`
+	RequiredState string `json:"requiredState,omitempty"`
+	NewState      string `json:"newState,omitempty"`
+	Source        string `json:"-"`
+	// Specificity is calculated from the number and type of matchers in the request definition.
+	Specificity int `json:"-"`
 }
 
 type StaticMockService struct {
 	logger          *slog.Logger
 	wiretapService  *daemon.WiretapService
 	mockDefinitions []StaticMockDefinition
+	loadErrors      []*MockDefinitionLoadError
+	violations      []*MockContractViolation
+	state           *mockState
+	journal         *requestJournal
 }
 
 func NewStaticMockService(wiretapService *daemon.WiretapService, logger *slog.Logger) *StaticMockService {
-	mockDefinitions := loadStaticMockRequestsAndResponses(wiretapService, logger)
+	mockDefinitions, loadErrors, violations := loadStaticMockRequestsAndResponses(wiretapService, logger)
 
 	return &StaticMockService{
 		logger:          logger,
 		wiretapService:  wiretapService,
 		mockDefinitions: mockDefinitions,
+		loadErrors:      loadErrors,
+		violations:      violations,
+		state:           newMockState(),
+		journal:         newRequestJournal(),
 	}
 }
 
+// LoadErrors returns the problems found the last time the mock definitions were loaded.
+func (sms *StaticMockService) LoadErrors() []*MockDefinitionLoadError {
+	sms.state.lock.Lock()
+	defer sms.state.lock.Unlock()
+	return sms.loadErrors
+}
+
+// ContractViolations returns the definitions that did not comply with the contract the last time the mock
+// definitions were loaded.
+func (sms *StaticMockService) ContractViolations() []*MockContractViolation {
+	sms.state.lock.Lock()
+	defer sms.state.lock.Unlock()
+	return sms.violations
+}
+
+// definitions returns the mock definitions currently loaded. A reload replaces the slice rather than changing it,
+// so it can be used without holding the lock.
+func (sms *StaticMockService) definitions() []StaticMockDefinition {
+	sms.state.lock.Lock()
+	defer sms.state.lock.Unlock()
+	return sms.mockDefinitions
+}
+
 // getDefinitionFromJson converts a JSON object to a StaticMockDefinition
 func getDefinitionFromJson(mockInterface map[string]interface{}) (StaticMockDefinition, error) {
 	var mockDefinition StaticMockDefinition
@@ -78,70 +147,180 @@ func getDefinitionFromJson(mockInterface map[string]interface{}) (StaticMockDefi
 	return mockDefinition, nil
 }
 
-// loadStaticMockRequestsAndResponses loads the static mock definitions from the JSON files
-func loadStaticMockRequestsAndResponses(wiretapService *daemon.WiretapService, logger *slog.Logger) []StaticMockDefinition {
+// isMockDefinitionFile checks if a file is a JSON or YAML mock definition.
+func isMockDefinitionFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// loadStaticMockRequestsAndResponses loads the static mock definitions from the JSON and YAML files in the
+// mock definitions directory. A file that cannot be read, parsed or does not match the definition schema is
+// reported as a load error and skipped, it does not prevent the other files from loading. When a contract is
+// loaded, every definition is checked against it, and non-compliant definitions are refused in strict mode.
+// While recording, recorded definitions are not loaded, so recorded requests keep reaching the API.
+func loadStaticMockRequestsAndResponses(wiretapService *daemon.WiretapService,
+	logger *slog.Logger) ([]StaticMockDefinition, []*MockDefinitionLoadError, []*MockContractViolation) {
+
 	var staticMockDefinitions []StaticMockDefinition
+	var loadErrors []*MockDefinitionLoadError
 
 	if len(wiretapService.StaticMockDir) == 0 {
-		return staticMockDefinitions
+		return staticMockDefinitions, loadErrors, nil
 	}
 
 	mocksPath := wiretapService.StaticMockDir + MockDefinitionsPath
-
-	files, err := os.ReadDir(mocksPath)
+	resetTemplateCache()
+	resetRegexCache()
+
+	// definitions can be organised into subdirectories, such as those created by importing a collection.
+	var files []string
+	err := filepath.WalkDir(mocksPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && isMockDefinitionFile(entry.Name()) &&
+			!(wiretapService.StaticMockRecord && isRecordedFile(entry.Name())) {
+			files = append(files, path)
+		}
+		return nil
+	})
 	if err != nil {
-		logger.Error(err.Error())
+		logger.Error("unable to read mock definitions directory", "path", mocksPath, "error", err.Error())
 	}
 
 	// Loop through & read each mock definition file
-	for _, file := range files {
-		// Check if it's a regular file (not a directory)
-		if !file.IsDir() {
-			filePath := mocksPath + "/" + file.Name()
-			data, err := os.ReadFile(filePath)
-			if err != nil {
-				logger.Error("Error reading file %s: %v\n", filePath, err)
-				continue
-			}
+	for _, filePath := range files {
+		definitions, errs := loadMockDefinitionFile(filePath)
+		for _, e := range errs {
+			logger.Error("unable to load mock definition", "file", e.File, "index", e.Index, "error", e.Err.Error())
+		}
+		staticMockDefinitions = append(staticMockDefinitions, definitions...)
+		loadErrors = append(loadErrors, errs...)
+	}
 
-			var mockDefinitions interface{}
+	// explicit ids must be unique, or explanations and logs are ambiguous.
+	seen := make(map[string]string)
+	unique := staticMockDefinitions[:0]
+	for _, definition := range staticMockDefinitions {
+		if source, found := seen[definition.Id]; found {
+			loadErr := &MockDefinitionLoadError{File: definition.Source, Index: -1,
+				Err: fmt.Errorf("duplicate mock definition id '%s', already defined in '%s'", definition.Id, source)}
+			logger.Error("unable to load mock definition", "file", loadErr.File, "error", loadErr.Err.Error())
+			loadErrors = append(loadErrors, loadErr)
+			continue
+		}
+		seen[definition.Id] = definition.Source
+		unique = append(unique, definition)
+	}
 
-			err = json.Unmarshal(data, &mockDefinitions)
-			if err != nil {
-				logger.Error("Error parsing json file %s: %v\n", filePath, err)
-				continue
-			}
+	violations := checkContractCompliance(wiretapService.GetDocumentModel(), wiretapService.StaticMockDir, unique)
+	for _, v := range violations {
+		if wiretapService.StaticMockStrict {
+			logger.Error("refusing mock definition that does not comply with the contract", "id", v.Id,
+				"file", v.Source, "response", v.Response, "violation", v.Message)
+		} else {
+			logger.Warn("mock definition does not comply with the contract", "id", v.Id,
+				"file", v.Source, "response", v.Response, "violation", v.Message)
+		}
+	}
+	if wiretapService.StaticMockStrict {
+		unique = refuseNonCompliant(unique, violations)
+	}
 
-			switch mdJson := mockDefinitions.(type) {
-			// If the content of the file is a JSON object (key-value pairs)
-			case map[string]interface{}:
-				mockDefinition, err := getDefinitionFromJson(mdJson)
-				if err != nil {
-					logger.Error(err.Error())
-					continue
-				}
-				staticMockDefinitions = append(staticMockDefinitions, mockDefinition)
-
-			// If the content of the file is a JSON array (array of requests)
-			case []interface{}:
-				// You can iterate over the array
-				for _, item := range mdJson {
-					mockDefinition, err := getDefinitionFromJson(item.(map[string]interface{}))
-					if err != nil {
-						logger.Error(err.Error())
-						continue
-					}
-					staticMockDefinitions = append(staticMockDefinitions, mockDefinition)
-				}
+	sortStaticMockDefinitions(unique)
+	return unique, loadErrors, violations
+}
 
-			default:
-				// If it's neither an object nor an array
-				logger.Error("JSON not in the right format. \nFile => %s\n JSON => \n%s", file.Name(), string(data))
-			}
+// loadMockDefinitionFile reads a single JSON or YAML file, containing a mock definition or an array of them.
+func loadMockDefinitionFile(filePath string) ([]StaticMockDefinition, []*MockDefinitionLoadError) {
+	fileError := func(index int, err error) []*MockDefinitionLoadError {
+		return []*MockDefinitionLoadError{{File: filePath, Index: index, Err: err}}
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fileError(-1, err)
+	}
+
+	var mockDefinitions interface{}
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".yaml", ".yml":
+		// YAML is converted to JSON, so both formats are decoded and validated the same way.
+		var yamlDefinitions interface{}
+		if err = yaml.Unmarshal(data, &yamlDefinitions); err != nil {
+			return nil, fileError(-1, fmt.Errorf("invalid YAML: %w", err))
+		}
+		if data, err = json.Marshal(yamlDefinitions); err != nil {
+			return nil, fileError(-1, fmt.Errorf("YAML cannot be converted to JSON: %w", err))
 		}
 	}
+	if err = json.Unmarshal(data, &mockDefinitions); err != nil {
+		return nil, fileError(-1, fmt.Errorf("invalid JSON: %w", err))
+	}
+
+	var items []interface{}
+	switch md := mockDefinitions.(type) {
+	// If the content of the file is an object (a single definition)
+	case map[string]interface{}:
+		items = []interface{}{md}
+	// If the content of the file is an array (array of definitions)
+	case []interface{}:
+		items = md
+	default:
+		return nil, fileError(-1, fmt.Errorf("a mock definition file must contain an object or an array of objects"))
+	}
 
-	return staticMockDefinitions
+	var definitions []StaticMockDefinition
+	var errs []*MockDefinitionLoadError
+	for i, item := range items {
+		if err = validateMockDefinition(item); err != nil {
+			errs = append(errs, &MockDefinitionLoadError{File: filePath, Index: i, Err: err})
+			continue
+		}
+		mockDefinition, err := getDefinitionFromJson(item.(map[string]interface{}))
+		if err != nil {
+			errs = append(errs, &MockDefinitionLoadError{File: filePath, Index: i, Err: err})
+			continue
+		}
+		if err = checkXPathMatchers(mockDefinition.Request); err != nil {
+			errs = append(errs, &MockDefinitionLoadError{File: filePath, Index: i, Err: err})
+			continue
+		}
+		if err = checkMatchers(mockDefinition.Request); err != nil {
+			errs = append(errs, &MockDefinitionLoadError{File: filePath, Index: i, Err: err})
+			continue
+		}
+		if err = checkBase64Bodies(mockDefinition); err != nil {
+			errs = append(errs, &MockDefinitionLoadError{File: filePath, Index: i, Err: err})
+			continue
+		}
+		// definitions live in the mock-definitions directory, body files are found alongside it.
+		if err = compileResponseTemplates(mockDefinition, staticMockDirOf(filePath)); err != nil {
+			errs = append(errs, &MockDefinitionLoadError{File: filePath, Index: i, Err: err})
+			continue
+		}
+		mockDefinition.Source = filePath
+		if mockDefinition.Id == "" {
+			mockDefinition.Id = fmt.Sprintf("%s#%d", filepath.Base(filePath), i)
+		}
+		mockDefinition.Specificity = mockDefinition.Request.specificity()
+		definitions = append(definitions, mockDefinition)
+	}
+	return definitions, errs
+}
+
+// staticMockDirOf returns the static mock directory of a definition file, the parent of the mock definitions
+// directory the file is in, or in a subdirectory of.
+func staticMockDirOf(filePath string) string {
+	for dir := filepath.Dir(filePath); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if "/"+filepath.Base(dir) == MockDefinitionsPath {
+			return filepath.Dir(dir)
+		}
+	}
+	return filepath.Dir(filepath.Dir(filePath))
 }
 
 // StartWatcher Function to start a watcher on mock-definitions folder
@@ -157,10 +336,15 @@ func (sms *StaticMockService) StartWatcher() {
 		sms.logger.Error("Error when creating fsnotify.NewWatcher '%s'", err.Error(), err)
 	}
 
-	err = watcher.Add(pathToWatch)
-	if err != nil {
-		sms.logger.Error("Error adding path to watch. path => '%s'", pathToWatch, err)
-	}
+	// fsnotify does not watch recursively, so every subdirectory is watched as well.
+	_ = filepath.WalkDir(pathToWatch, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && entry.IsDir() {
+			if err = watcher.Add(path); err != nil {
+				sms.logger.Error("Error adding path to watch. path => '%s'", path, err)
+			}
+		}
+		return nil
+	})
 
 	go func(sms *StaticMockService) {
 		// Event loop
@@ -172,8 +356,17 @@ func (sms *StaticMockService) StartWatcher() {
 					return
 				}
 				eventsToWatch := event.Has(fsnotify.Write) || event.Has(fsnotify.Create) || event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename)
-				fileTypeIsJson := strings.HasSuffix(strings.ToLower(event.Name), ".json")
-				if eventsToWatch && fileTypeIsJson {
+				if event.Has(fsnotify.Create) {
+					if info, statErr := os.Stat(event.Name); statErr == nil && info.IsDir() {
+						_ = watcher.Add(event.Name)
+						sms.handleStaticMockChange()
+						continue
+					}
+				}
+				if sms.wiretapService.StaticMockRecord && isRecordedFile(event.Name) {
+					continue
+				}
+				if eventsToWatch && isMockDefinitionFile(event.Name) {
 					sms.handleStaticMockChange()
 				}
 			case err := <-watcher.Errors:
@@ -190,14 +383,30 @@ func (sms *StaticMockService) StartWatcher() {
 // so that the entire wiretap service doesn't need a restart
 func (sms *StaticMockService) handleStaticMockChange() {
 	sms.logger.Info("Mock definitions modified. Rebuilding mocks...")
-	sms.mockDefinitions = loadStaticMockRequestsAndResponses(sms.wiretapService, sms.logger)
-	sms.logger.Info("New mock definitions loaded")
+	mockDefinitions, loadErrors, violations := loadStaticMockRequestsAndResponses(sms.wiretapService, sms.logger)
+
+	sms.state.lock.Lock()
+	sms.mockDefinitions, sms.loadErrors, sms.violations = mockDefinitions, loadErrors, violations
+	sms.state.lock.Unlock()
+
+	sms.logger.Info("New mock definitions loaded", "definitions", len(mockDefinitions), "errors",
+		len(loadErrors), "violations", len(violations))
 }
 
 func (sms *StaticMockService) HandleServiceRequest(request *model.Request, core service.FabricServiceCore) {
 	switch request.RequestCommand {
 	case IncomingHttpRequest:
 		sms.HandleStaticMockRequest(request)
+	case ExplainStaticMockRequest:
+		sms.explainStaticMock(request, core)
+	case ResetStaticMockState:
+		sms.resetStaticMockState(request, core)
+	case VerifyStaticMockRequest:
+		sms.verifyStaticMock(request, core)
+	case GetStaticMockJournal:
+		sms.getStaticMockJournal(request, core)
+	case ResetStaticMockJournal:
+		sms.resetStaticMockJournal(request, core)
 	default:
 		core.HandleUnknownRequest(request)
 	}
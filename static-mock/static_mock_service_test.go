@@ -0,0 +1,51 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package staticMock
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/pb33f/wiretap/daemon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticMockService_ReloadWhileServing(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "mock-definitions"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "mock-definitions", "pets.yaml"), []byte(`- request:
+    method: GET
+    urlPath: /pets
+  response:
+    statusCode: 200`), 0644))
+
+	sms := &StaticMockService{logger: slog.Default(), state: newMockState(), journal: newRequestJournal(),
+		wiretapService: &daemon.WiretapService{StaticMockDir: dir}}
+	sms.handleStaticMockChange()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			sms.handleStaticMockChange()
+		}()
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodGet, "http://localhost/pets", nil)
+			assert.NotNil(t, sms.checkStaticMockExists(req))
+			sms.ExplainMatch(req)
+		}()
+	}
+	wg.Wait()
+	assert.Empty(t, sms.LoadErrors())
+	assert.Len(t, sms.Journal(), 4)
+}
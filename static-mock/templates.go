@@ -0,0 +1,304 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package staticMock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	firstNames = []string{"Ada", "Alan", "Grace", "Linus", "Margaret", "Dennis", "Barbara", "Ken", "Radia", "Tim",
+		"Frances", "Edsger", "Katherine", "Donald", "Hedy", "Guido"}
+	lastNames = []string{"Lovelace", "Turing", "Hopper", "Torvalds", "Hamilton", "Ritchie", "Liskov", "Thompson",
+		"Perlman", "Berners-Lee", "Allen", "Dijkstra", "Johnson", "Knuth", "Lamarr", "van Rossum"}
+	companies = []string{"Acme", "Globex", "Initech", "Umbrella", "Hooli", "Stark Industries", "Wayne Enterprises",
+		"Cyberdyne", "Soylent", "Tyrell"}
+	cities = []string{"London", "Paris", "New York", "Tokyo", "Berlin", "Sydney", "Toronto", "Lisbon", "Nairobi",
+		"Singapore", "Austin", "Oslo"}
+	words = []string{"alpha", "bravo", "charlie", "delta", "echo", "foxtrot", "golf", "hotel", "india", "juliet",
+		"kilo", "lima", "mike", "november", "oscar", "papa"}
+)
+
+const randomAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// dateLayouts are the named layouts accepted by the date helper, any other layout is used as a Go time layout.
+var dateLayouts = map[string]string{
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"RFC1123":     time.RFC1123,
+	"date":        time.DateOnly,
+	"time":        time.TimeOnly,
+	"datetime":    time.DateTime,
+}
+
+// templateFuncs are the helpers available to static mock templates.
+var templateFuncs = template.FuncMap{
+	"uuid": func() string { return uuid.New().String() },
+	"now":  time.Now,
+	"date": func(layout string, t time.Time) string {
+		switch layout {
+		case "unix":
+			return strconv.FormatInt(t.Unix(), 10)
+		case "unixMilli":
+			return strconv.FormatInt(t.UnixMilli(), 10)
+		}
+		if named, ok := dateLayouts[layout]; ok {
+			layout = named
+		}
+		return t.Format(layout)
+	},
+	"dateAdd": func(duration string, t time.Time) (time.Time, error) {
+		d, err := time.ParseDuration(duration)
+		if err != nil {
+			return t, err
+		}
+		return t.Add(d), nil
+	},
+	"randomInt": func(min, max int) int {
+		if max <= min {
+			return min
+		}
+		return min + rand.Intn(max-min+1)
+	},
+	"randomFloat": func(min, max float64) float64 { return min + rand.Float64()*(max-min) },
+	"randomString": func(length int) string {
+		b := make([]byte, length)
+		for i := range b {
+			b[i] = randomAlphabet[rand.Intn(len(randomAlphabet))]
+		}
+		return string(b)
+	},
+	"randomItem": func(items ...any) any {
+		if len(items) == 1 {
+			if list, ok := items[0].([]any); ok {
+				items = list
+			}
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		return items[rand.Intn(len(items))]
+	},
+	"firstName": func() string { return pick(firstNames) },
+	"lastName":  func() string { return pick(lastNames) },
+	"fullName":  func() string { return pick(firstNames) + " " + pick(lastNames) },
+	"email": func() string {
+		return strings.ToLower(pick(firstNames)+"."+strings.ReplaceAll(pick(lastNames), " ", "")) + "@example.com"
+	},
+	"company": func() string { return pick(companies) },
+	"city":    func() string { return pick(cities) },
+	"word":    func() string { return pick(words) },
+	"add":     func(a, b any) (any, error) { return arithmetic(a, b, func(x, y float64) float64 { return x + y }) },
+	"sub":     func(a, b any) (any, error) { return arithmetic(a, b, func(x, y float64) float64 { return x - y }) },
+	"mul":     func(a, b any) (any, error) { return arithmetic(a, b, func(x, y float64) float64 { return x * y }) },
+	"div": func(a, b any) (any, error) {
+		if y, err := toFloat(b); err == nil && y == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return arithmetic(a, b, func(x, y float64) float64 { return x / y })
+	},
+	"mod": func(a, b any) (any, error) {
+		if y, err := toFloat(b); err == nil && y == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return arithmetic(a, b, math.Mod)
+	},
+	"seq": func(n int) []int {
+		s := make([]int, n)
+		for i := range s {
+			s[i] = i
+		}
+		return s
+	},
+	"default": func(fallback, value any) any {
+		if value == nil || value == "" {
+			return fallback
+		}
+		return value
+	},
+	"contains": func(substr string, s any) bool { return strings.Contains(fmt.Sprint(s), substr) },
+	"upper":    strings.ToUpper,
+	"lower":    strings.ToLower,
+	"json": func(value any) (string, error) {
+		b, err := json.Marshal(value)
+		return string(b), err
+	},
+}
+
+func pick(list []string) string {
+	return list[rand.Intn(len(list))]
+}
+
+func toFloat(value any) (float64, error) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	}
+	return strconv.ParseFloat(fmt.Sprint(value), 64)
+}
+
+// arithmetic applies an operation to two numbers, returning an integer if the result is whole.
+func arithmetic(a, b any, op func(x, y float64) float64) (any, error) {
+	x, err := toFloat(a)
+	if err != nil {
+		return nil, fmt.Errorf("'%v' is not a number", a)
+	}
+	y, err := toFloat(b)
+	if err != nil {
+		return nil, fmt.Errorf("'%v' is not a number", b)
+	}
+	result := op(x, y)
+	if result == math.Trunc(result) && math.Abs(result) < math.MaxInt64 {
+		return int64(result), nil
+	}
+	return result, nil
+}
+
+// templateData is the request, as seen by a static mock template.
+type templateData struct {
+	Method       string
+	Path         string
+	PathSegments []string
+	Host         string
+	Body         any
+	request      *http.Request
+}
+
+// Header returns the first value of a request header.
+func (td *templateData) Header(name string) string {
+	return td.request.Header.Get(name)
+}
+
+// Cookie returns the value of a request cookie.
+func (td *templateData) Cookie(name string) string {
+	if c, err := td.request.Cookie(name); err == nil {
+		return c.Value
+	}
+	return ""
+}
+
+// Query returns the first value of a query parameter.
+func (td *templateData) Query(name string) string {
+	return td.request.URL.Query().Get(name)
+}
+
+// QueryAll returns every value of a query parameter.
+func (td *templateData) QueryAll(name string) []string {
+	return td.request.URL.Query()[name]
+}
+
+func newTemplateData(request *http.Request) *templateData {
+	td := &templateData{
+		Method:       request.Method,
+		Path:         request.URL.Path,
+		PathSegments: strings.Split(strings.Trim(request.URL.Path, "/"), "/"),
+		Host:         request.Host,
+		request:      request,
+	}
+	td.Body = decodeRequestBody(request)
+	return td
+}
+
+// templateCache holds compiled templates, by source, so each template is only parsed once. It is cleared every
+// time the definitions are loaded, so it only holds the templates of the current definitions.
+var templateCache sync.Map
+
+// resetTemplateCache forgets the templates compiled for previously loaded definitions.
+func resetTemplateCache() {
+	templateCache.Clear()
+}
+
+// compileTemplate parses a template, or returns it from the cache.
+func compileTemplate(source string) (*template.Template, error) {
+	if cached, ok := templateCache.Load(source); ok {
+		return cached.(*template.Template), nil
+	}
+	tmpl, err := template.New("static-mock").Funcs(templateFuncs).Option("missingkey=zero").Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	templateCache.Store(source, tmpl)
+	return tmpl, nil
+}
+
+// renderTemplate renders a template source against the request.
+func renderTemplate(source string, request *http.Request) (string, error) {
+	tmpl, err := compileTemplate(source)
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	if err = tmpl.Execute(&out, newTemplateData(request)); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// compileResponseTemplates parses every template in the responses of a definition, so mistakes are reported when
+// the definition is loaded, rather than when a request is made. Only responses and proxy patches that are marked
+// as templates are parsed.
+func compileResponseTemplates(definition StaticMockDefinition, staticMockDir string) error {
+	if definition.Proxy != nil && definition.Proxy.Template {
+		for name, value := range definition.Proxy.Header {
+			if _, err := compileTemplate(fmt.Sprint(value)); err != nil {
+				return fmt.Errorf("invalid template in proxy header '%s': %w", name, err)
+			}
+		}
+	}
+	for _, response := range append([]StaticMockDefinitionResponse{definition.Response}, definition.Responses...) {
+		if !response.Template {
+			continue
+		}
+		sources := map[string]string{"body": response.Body}
+		if response.BodyJsonFilename != "" {
+			if file, err := os.ReadFile(staticMockDir + MockBodyJsonsPath + response.BodyJsonFilename); err == nil {
+				sources[response.BodyJsonFilename] = string(file)
+			}
+		}
+		for name, value := range response.Header {
+			sources["header '"+name+"'"] = fmt.Sprint(value)
+		}
+		for name, source := range sources {
+			if _, err := compileTemplate(source); err != nil {
+				return fmt.Errorf("invalid template in %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// renderHeaderTemplates renders the header values of a response definition that is a template.
+func renderHeaderTemplates(header http.Header, response StaticMockDefinitionResponse, request *http.Request) {
+	if !response.Template {
+		return
+	}
+	for name, value := range response.Header {
+		rendered, err := renderTemplate(fmt.Sprint(value), request)
+		if err != nil {
+			panic(err)
+		}
+		header.Set(name, rendered)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package staticMock
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pb33f/wiretap/daemon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost/pets/42?tag=a&tag=b&limit=2",
+		strings.NewReader(`{"name": "fido", "toys": [{"name": "ball"}, {"name": "rope"}], "age": 3}`))
+	req.Header.Set("X-Tenant", "acme")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "s1"})
+
+	rendered, err := renderTemplate(`{
+  "id": "{{ uuid }}",
+  "pet": {{ index .PathSegments 1 }},
+  "name": "{{ upper .Body.name }}",
+  "nextAge": {{ add .Body.age 1 }},
+  "half": {{ div .Body.age 2 }},
+  "tenant": "{{ .Header "X-Tenant" }}",
+  "session": "{{ .Cookie "session" }}",
+  "tags": {{ json (.QueryAll "tag") }},
+  "toys": [{{ range $i, $toy := .Body.toys }}{{ if $i }}, {{ end }}"{{ $toy.name }}"{{ end }}],
+  "adult": {{ if gt .Body.age 2.0 }}true{{ else }}false{{ end }},
+  "owner": "{{ default "nobody" .Body.owner }}",
+  "year": {{ len (date "2006" now) }},
+  "roll": {{ randomInt 1 6 }},
+  "code": "{{ randomString 8 }}",
+  "person": "{{ fullName }}"
+}`, req)
+	require.NoError(t, err)
+
+	var out map[string]any
+	require.NoError(t, json.Unmarshal([]byte(rendered), &out), rendered)
+	_, err = uuid.Parse(out["id"].(string))
+	assert.NoError(t, err)
+	assert.Equal(t, 42.0, out["pet"])
+	assert.Equal(t, "FIDO", out["name"])
+	assert.Equal(t, 4.0, out["nextAge"])
+	assert.Equal(t, 1.5, out["half"])
+	assert.Equal(t, "acme", out["tenant"])
+	assert.Equal(t, "s1", out["session"])
+	assert.Equal(t, []any{"a", "b"}, out["tags"])
+	assert.Equal(t, []any{"ball", "rope"}, out["toys"])
+	assert.Equal(t, true, out["adult"])
+	assert.Equal(t, "nobody", out["owner"])
+	assert.Equal(t, 4.0, out["year"])
+	assert.GreaterOrEqual(t, out["roll"], 1.0)
+	assert.LessOrEqual(t, out["roll"], 6.0)
+	assert.Len(t, out["code"], 8)
+	assert.Contains(t, out["person"], " ")
+
+	// the request body can still be read after rendering.
+	body, _ := io.ReadAll(req.Body)
+	assert.Contains(t, string(body), "fido")
+
+	plain, err := renderTemplate(`{"test": "${queryParams.limit}"}`, req)
+	require.NoError(t, err)
+	assert.Equal(t, `{"test": "${queryParams.limit}"}`, plain)
+}
+
+func TestLoadMockDefinitionFile_InvalidTemplate(t *testing.T) {
+	path := writeDefinition(t, "bad.yaml", `- request:
+    method: GET
+  response:
+    template: true
+    body: '{"id": "{{ uuid }"}'
+- request:
+    method: GET
+  response:
+    template: true
+    header:
+      X-Request-Id: '{{ nope }}'
+- request:
+    method: GET
+  response:
+    template: true
+    header:
+      X-Request-Id: '{{ uuid }}'
+    body: '{"id": "{{ uuid }}"}'`)
+
+	definitions, errs := loadMockDefinitionFile(path)
+	require.Len(t, definitions, 1)
+	require.Len(t, errs, 2)
+	assert.ErrorContains(t, errs[0].Err, "invalid template in body")
+	assert.ErrorContains(t, errs[1].Err, `function "nope" not defined`)
+
+	sms := &StaticMockService{logger: slog.Default(), state: newMockState(),
+		wiretapService: &daemon.WiretapService{}}
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/pets?limit=5", nil)
+	resp := sms.getStaticMockResponse(definitions[0], req)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Len(t, resp.Header.Get("X-Request-Id"), 36)
+	assert.NotContains(t, string(body), "{{")
+}
+
+func TestLoadMockDefinitionFile_NotTemplate(t *testing.T) {
+	path := writeDefinition(t, "literal.yaml", `- request:
+    method: GET
+  response:
+    header:
+      X-Mustache: '{{name}}'
+    body: '{"greeting": "Hello {{name}}", "limit": "${queryParams.limit}"}'`)
+
+	definitions, errs := loadMockDefinitionFile(path)
+	require.Empty(t, errs)
+	require.Len(t, definitions, 1)
+
+	sms := &StaticMockService{logger: slog.Default(), state: newMockState(),
+		wiretapService: &daemon.WiretapService{}}
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/pets?limit=5", nil)
+	resp := sms.getStaticMockResponse(definitions[0], req)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "{{name}}", resp.Header.Get("X-Mustache"))
+	assert.Equal(t, `{"greeting": "Hello {{name}}", "limit": "5"}`, string(body))
+}
@@ -0,0 +1,248 @@
+// Copyright 2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+//
+// SPDX-License-Identifier: AGPL
+
+package staticMock
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// xmlNode is an element of a parsed XML document. Names are local names, namespaces are ignored.
+type xmlNode struct {
+	name     string
+	attrs    map[string]string
+	children []*xmlNode
+	text     strings.Builder
+}
+
+// innerText returns the text of the node and all of its descendants.
+func (n *xmlNode) innerText() string {
+	var sb strings.Builder
+	var walk func(node *xmlNode)
+	walk = func(node *xmlNode) {
+		sb.WriteString(node.text.String())
+		for _, c := range node.children {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.TrimSpace(sb.String())
+}
+
+func (n *xmlNode) descendants() []*xmlNode {
+	var nodes []*xmlNode
+	for _, c := range n.children {
+		nodes = append(nodes, c)
+		nodes = append(nodes, c.descendants()...)
+	}
+	return nodes
+}
+
+// parseXML reads an XML document into a tree, under a document node.
+func parseXML(document []byte) (*xmlNode, error) {
+	root := &xmlNode{}
+	stack := []*xmlNode{root}
+	decoder := xml.NewDecoder(bytes.NewReader(document))
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		current := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name.Local, attrs: make(map[string]string)}
+			for _, a := range t.Attr {
+				node.attrs[a.Name.Local] = a.Value
+			}
+			current.children = append(current.children, node)
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			current.text.Write(t)
+		}
+	}
+	if len(root.children) == 0 {
+		return nil, fmt.Errorf("document has no root element")
+	}
+	return root, nil
+}
+
+// xpathStep is a single step of an XPath expression.
+type xpathStep struct {
+	descendant bool
+	test       string
+	predicates []string
+}
+
+// parseXPath reads the supported subset of XPath: absolute paths of element names or `*`, with `//` for
+// descendants, ending in an element, `@attribute` or `text()`, and `[n]`, `[@attribute='value']` or
+// `[child='value']` predicates.
+func parseXPath(expression string) ([]xpathStep, error) {
+	if !strings.HasPrefix(expression, "/") {
+		return nil, fmt.Errorf("xpath '%s' must start with '/'", expression)
+	}
+	var steps []xpathStep
+	rest := expression
+	for rest != "" {
+		step := xpathStep{}
+		switch {
+		case strings.HasPrefix(rest, "//"):
+			step.descendant = true
+			rest = rest[2:]
+		case strings.HasPrefix(rest, "/"):
+			rest = rest[1:]
+		default:
+			return nil, fmt.Errorf("invalid xpath '%s'", expression)
+		}
+		depth, end := 0, len(rest)
+		for i, r := range rest {
+			if r == '[' {
+				depth++
+			} else if r == ']' {
+				depth--
+			} else if r == '/' && depth == 0 {
+				end = i
+				break
+			}
+		}
+		segment := rest[:end]
+		rest = rest[end:]
+		if open := strings.Index(segment, "["); open >= 0 {
+			if !strings.HasSuffix(segment, "]") {
+				return nil, fmt.Errorf("invalid xpath '%s'", expression)
+			}
+			for _, p := range strings.Split(segment[open+1:len(segment)-1], "][") {
+				p = strings.TrimSpace(p)
+				if _, err := strconv.Atoi(p); err != nil && !strings.Contains(p, "=") {
+					return nil, fmt.Errorf("unsupported xpath predicate '[%s]'", p)
+				}
+				step.predicates = append(step.predicates, p)
+			}
+			segment = segment[:open]
+		}
+		if segment == "" {
+			return nil, fmt.Errorf("invalid xpath '%s'", expression)
+		}
+		step.test = segment
+		steps = append(steps, step)
+	}
+	for i, step := range steps[:len(steps)-1] {
+		if strings.HasPrefix(step.test, "@") || step.test == "text()" {
+			return nil, fmt.Errorf("xpath '%s' can only select '%s' in the last step", expression, steps[i].test)
+		}
+	}
+	return steps, nil
+}
+
+// checkXPathMatchers parses every XPath matcher of a request definition, so unsupported expressions are reported
+// when the definition is loaded.
+func checkXPathMatchers(request StaticMockDefinitionRequest) error {
+	for expression := range request.XPath {
+		if _, err := parseXPath(expression); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// evaluateXPath returns the values selected by an expression. Elements are returned as their text content.
+func evaluateXPath(document *xmlNode, expression string) ([]any, error) {
+	steps, err := parseXPath(expression)
+	if err != nil {
+		return nil, err
+	}
+	context := []*xmlNode{document}
+	for i, step := range steps {
+		last := i == len(steps)-1
+		if last && (strings.HasPrefix(step.test, "@") || step.test == "text()") {
+			var values []any
+			for _, node := range context {
+				candidates := []*xmlNode{node}
+				if step.descendant {
+					candidates = append(candidates, node.descendants()...)
+				}
+				for _, c := range candidates {
+					if step.test == "text()" {
+						if text := strings.TrimSpace(c.text.String()); text != "" {
+							values = append(values, text)
+						}
+					} else if v, ok := c.attrs[step.test[1:]]; ok {
+						values = append(values, v)
+					}
+				}
+			}
+			return values, nil
+		}
+
+		var next []*xmlNode
+		for _, node := range context {
+			candidates := node.children
+			if step.descendant {
+				candidates = node.descendants()
+			}
+			var matched []*xmlNode
+			for _, c := range candidates {
+				if step.test == "*" || step.test == c.name {
+					matched = append(matched, c)
+				}
+			}
+			for _, predicate := range step.predicates {
+				if matched, err = applyXPathPredicate(matched, predicate); err != nil {
+					return nil, err
+				}
+			}
+			next = append(next, matched...)
+		}
+		context = next
+	}
+	values := make([]any, 0, len(context))
+	for _, node := range context {
+		values = append(values, node.innerText())
+	}
+	return values, nil
+}
+
+// applyXPathPredicate filters nodes by a position, attribute or child predicate.
+func applyXPathPredicate(nodes []*xmlNode, predicate string) ([]*xmlNode, error) {
+	if position, err := strconv.Atoi(predicate); err == nil {
+		if position < 1 || position > len(nodes) {
+			return nil, nil
+		}
+		return []*xmlNode{nodes[position-1]}, nil
+	}
+	name, value, found := strings.Cut(predicate, "=")
+	if !found {
+		return nil, fmt.Errorf("unsupported xpath predicate '[%s]'", predicate)
+	}
+	name = strings.TrimSpace(name)
+	value = strings.Trim(strings.TrimSpace(value), `'"`)
+	var matched []*xmlNode
+	for _, node := range nodes {
+		if strings.HasPrefix(name, "@") {
+			if node.attrs[name[1:]] == value {
+				matched = append(matched, node)
+			}
+			continue
+		}
+		for _, c := range node.children {
+			if c.name == name && c.innerText() == value {
+				matched = append(matched, node)
+				break
+			}
+		}
+	}
+	return matched, nil
+}
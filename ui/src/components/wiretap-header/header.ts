@@ -1,6 +1,7 @@
 import {customElement, property} from "lit/decorators.js";
 import {html, LitElement, TemplateResult} from "lit";
 import headerCss from "./header.css";
+import {ShadowFinding} from "@/model/shadow_finding";
 
 
 @customElement('wiretap-header')
@@ -25,6 +26,9 @@ export class HeaderComponent extends LitElement {
     @property({type: Boolean})
     noSpec: boolean;
 
+    @property({type: Array})
+    shadowFindings: ShadowFinding[] = [];
+
     render() {
 
         let headerMetrics: TemplateResult
@@ -45,7 +49,8 @@ export class HeaderComponent extends LitElement {
                         responses="${this.responses}"
                         violations="${this.violations}"
                         violationsDelta="${this.violationsDelta}"
-                        compliance="${this.compliance}">
+                        compliance="${this.compliance}"
+                        .shadowFindings=${this.shadowFindings}>
                 </wiretap-header-metrics>`
         }
 
@@ -2,6 +2,7 @@ import {customElement, property} from "lit/decorators.js";
 import {LitElement} from "lit";
 import {html} from "lit";
 import metricsCss from "./metrics.css";
+import {ShadowFinding} from "@/model/shadow_finding";
 
 @customElement('wiretap-header-metrics')
 export class HeaderMetricsComponent extends LitElement {
@@ -25,12 +26,23 @@ export class HeaderMetricsComponent extends LitElement {
     @property({type: Boolean})
     noSpec: boolean;
 
+    @property({type: Array})
+    shadowFindings: ShadowFinding[] = [];
+
     render() {
         return html`
             <wiretap-metric title="Compliance" value="${this.compliance}" postfix="%" end colorizeValue></wiretap-metric>
             <wiretap-metric title="Requests" value="${this.requests}"></wiretap-metric>
             <wiretap-metric title="Responses" value="${this.responses}"></wiretap-metric>
             ${this.violations <= 0 ? null : html`<wiretap-metric title="Violations" value="${this.violations}"></wiretap-metric>`}
+            ${this.shadowFindings.length <= 0 ? null : html`
+                <sl-tooltip placement="bottom">
+                    <div slot="content">
+                        ${this.shadowFindings.map((finding: ShadowFinding) =>
+                                html`${finding.message} (${finding.count})<br/>`)}
+                    </div>
+                    <wiretap-metric title="Shadow API" value="${this.shadowFindings.length}"></wiretap-metric>
+                </sl-tooltip>`}
         `
     }
 }
\ No newline at end of file
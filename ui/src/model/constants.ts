@@ -6,6 +6,7 @@ export const WiretapReportChannel = "report";
 
 export const WiretapConfigurationChannel = "configuration";
 export const WiretapStaticChannel = "wiretap-static-change";
+export const WiretapShadowChannel = "shadow-broadcast";
 
 export const WiretapHttpTransactionStore = "http-transaction-store";
 export const WiretapSelectedTransactionStore = "selected-transaction-store";
@@ -0,0 +1,15 @@
+export interface ShadowFinding {
+    kind: string;
+    method: string;
+    path: string;
+    statusCode?: number;
+    contentType?: string;
+    message: string;
+    count: number;
+    examples: string[];
+}
+
+// ShadowFindingKey identifies the finding traffic was aggregated into, the same way wiretap does.
+export function ShadowFindingKey(finding: ShadowFinding): string {
+    return [finding.kind, finding.method, finding.path, finding.statusCode ?? 0, finding.contentType ?? ""].join(" ");
+}
@@ -16,8 +16,9 @@ import {
     WiretapHttpTransactionStore, WiretapLinkCacheKey, WiretapLinkCacheStore,
     WiretapLocalStorage, WiretapReportChannel,
     WiretapSelectedTransactionStore,
-    WiretapSpecStore, WiretapStaticChannel,
+    WiretapShadowChannel, WiretapSpecStore, WiretapStaticChannel,
 } from "@/model/constants";
+import {ShadowFinding, ShadowFindingKey} from "@/model/shadow_finding";
 
 declare global {
     interface Window {
@@ -42,6 +43,7 @@ export class WiretapComponent extends LitElement {
     private readonly _wiretapReportChannel: Channel;
     private readonly _wiretapConfigChannel: Channel;
     private readonly _staticNotificationChannel: Channel;
+    private readonly _shadowChannel: Channel;
     private readonly _wiretapPort: string;
     private readonly _wiretapHost: string;
     private readonly _wiretapVersion: string;
@@ -49,6 +51,8 @@ export class WiretapComponent extends LitElement {
     private _specChannelSubscription: Subscription;
     private _configChannelSubscription: Subscription;
     private _staticChannelSubscription: Subscription;
+    private _shadowChannelSubscription: Subscription;
+    private _shadowFindings: Map<string, ShadowFinding> = new Map<string, ShadowFinding>();
     private _useTLS: boolean = false;
     private _headerStatsDefaultPrecision: number = 0;
     private _complianceStatPrecision: number = 2;
@@ -76,6 +80,9 @@ export class WiretapComponent extends LitElement {
     @property({type: Number})
     complianceLevel: number = 100.0;
 
+    @property({type: Array})
+    shadowFindings: ShadowFinding[] = [];
+
     constructor() {
         super();
         //configure local storage
@@ -149,6 +156,7 @@ export class WiretapComponent extends LitElement {
         this._wiretapReportChannel = this._bus.createChannel(WiretapReportChannel);
         this._wiretapConfigChannel = this._bus.createChannel(WiretapConfigurationChannel);
         this._staticNotificationChannel = this._bus.createChannel(WiretapStaticChannel);
+        this._shadowChannel = this._bus.createChannel(WiretapShadowChannel);
 
         // map local bus channels to broker destinations.
         this._bus.mapChannelToBrokerDestination(TopicPrefix + WiretapChannel, WiretapChannel);
@@ -157,12 +165,14 @@ export class WiretapComponent extends LitElement {
         this._bus.mapChannelToBrokerDestination(QueuePrefix + WiretapReportChannel, WiretapReportChannel);
         this._bus.mapChannelToBrokerDestination(QueuePrefix + WiretapConfigurationChannel, WiretapConfigurationChannel);
         this._bus.mapChannelToBrokerDestination(TopicPrefix + WiretapStaticChannel, WiretapStaticChannel);
+        this._bus.mapChannelToBrokerDestination(TopicPrefix + WiretapShadowChannel, WiretapShadowChannel);
 
         // handle incoming messages on different channels.
         this._transactionChannelSubscription = this._wiretapChannel.subscribe(this.wireTransactionHandler());
         this._specChannelSubscription = this._wiretapSpecChannel.subscribe(this.specHandler());
         this._configChannelSubscription = this._wiretapConfigChannel.subscribe(this.configHandler());
         this._staticChannelSubscription = this._staticNotificationChannel.subscribe(this.staticHandler());
+        this._shadowChannelSubscription = this._shadowChannel.subscribe(this.shadowHandler());
 
 
         // load previous transactions from local storage.
@@ -272,6 +282,15 @@ export class WiretapComponent extends LitElement {
         }
     }
 
+    // shadowHandler keeps the latest state of every undocumented path, operation, status code and content type.
+    shadowHandler(): BusCallback<CommandResponse> {
+        return (msg: CommandResponse) => {
+            const finding = msg.payload as ShadowFinding;
+            this._shadowFindings.set(ShadowFindingKey(finding), finding);
+            this.shadowFindings = Array.from(this._shadowFindings.values());
+        }
+    }
+
     wireTransactionHandler(): BusCallback {
         return (msg: CommandResponse) => {
             const wiretapMessage = msg.payload as HttpTransaction
@@ -352,6 +371,8 @@ export class WiretapComponent extends LitElement {
         this.requestCount = 0;
         this.violatedTransactions = 0;
         this.violationsCount = 0;
+        this._shadowFindings.clear();
+        this.shadowFindings = [];
         this.calcComplianceLevel();
         localforage.clear().then(() => {
             window.location.reload()
@@ -403,7 +424,8 @@ export class WiretapComponent extends LitElement {
                         responses="${this.responseCount.toFixed(this._headerStatsDefaultPrecision)}"
                         violations="${this.violationsCount.toFixed(this._headerStatsDefaultPrecision)}"
                         violationsDelta="${this.violatedTransactions.toFixed(this._headerStatsDefaultPrecision)}"
-                        compliance="${this.complianceLevel.toFixed(this._complianceStatPrecision)}">
+                        compliance="${this.complianceLevel.toFixed(this._complianceStatPrecision)}"
+                        .shadowFindings=${this.shadowFindings}>
                 </wiretap-header>
 
             </pb33f-header>